@@ -16,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 
 	routev1 "github.com/openshift/api/route/v1"
 
@@ -64,6 +65,28 @@ func main() {
 	// be added before calling pflag.Parse().
 	pflag.CommandLine.AddFlagSet(zap.FlagSet())
 
+	pflag.IntVar(&common.KeycloakAPIConcurrency, "keycloak-api-concurrency", common.KeycloakAPIConcurrency,
+		"Maximum number of concurrent admin API requests sent to a single Keycloak instance")
+	pflag.DurationVar(&common.RealmCacheTTL, "realm-cache-ttl", common.RealmCacheTTL,
+		"Time for which realm-wide reads (clients, client scopes) are shared between the custom resources of a realm")
+
+	// Work on the same keycloak instance and realm is serialized, so higher values mainly help with multiple instances
+	pflag.IntVar(&common.KeycloakMaxConcurrentReconciles, "keycloak-max-concurrent-reconciles", common.KeycloakMaxConcurrentReconciles,
+		"Maximum number of concurrent reconciles of Keycloak resources")
+	pflag.IntVar(&common.KeycloakRealmMaxConcurrentReconciles, "keycloakrealm-max-concurrent-reconciles", common.KeycloakRealmMaxConcurrentReconciles,
+		"Maximum number of concurrent reconciles of KeycloakRealm resources")
+	pflag.IntVar(&common.KeycloakClientMaxConcurrentReconciles, "keycloakclient-max-concurrent-reconciles", common.KeycloakClientMaxConcurrentReconciles,
+		"Maximum number of concurrent reconciles of KeycloakClient resources")
+	pflag.IntVar(&common.KeycloakUserMaxConcurrentReconciles, "keycloakuser-max-concurrent-reconciles", common.KeycloakUserMaxConcurrentReconciles,
+		"Maximum number of concurrent reconciles of KeycloakUser resources")
+	pflag.IntVar(&common.KeycloakBackupMaxConcurrentReconciles, "keycloakbackup-max-concurrent-reconciles", common.KeycloakBackupMaxConcurrentReconciles,
+		"Maximum number of concurrent reconciles of KeycloakBackup resources")
+
+	// Spans are exported using OTLP/HTTP, configured with the standard OpenTelemetry environment variables
+	// (OTEL_EXPORTER_OTLP_*, OTEL_TRACES_SAMPLER). The flag overrides the endpoint.
+	otlpEndpoint := pflag.String("otlp-endpoint", tracing.EndpointFromEnv(),
+		"OTLP/HTTP traces endpoint (e.g. http://otel-collector:4318/v1/traces), tracing is disabled if empty")
+
 	// Add flags registered by imported packages (e.g. glog and
 	// controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
@@ -81,6 +104,22 @@ func main() {
 
 	printVersion()
 
+	tracingOptions, err := tracing.OptionsFromEnv()
+	if err != nil {
+		log.Error(err, "Invalid tracing configuration")
+		os.Exit(1)
+	}
+	tracingOptions.Endpoint = *otlpEndpoint
+	shutdownTracing, err := tracing.Setup(tracingOptions)
+	if err != nil {
+		log.Error(err, "Failed to set up tracing")
+		os.Exit(1)
+	}
+	defer shutdownTracing()
+	if tracing.Enabled() {
+		log.Info(fmt.Sprintf("Exporting traces to '%v'", *otlpEndpoint))
+	}
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
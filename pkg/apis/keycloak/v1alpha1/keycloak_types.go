@@ -2,7 +2,9 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type TLSTerminationType string
@@ -27,6 +29,10 @@ type KeycloakSpec struct {
 	// +listType=set
 	// +optional
 	Extensions []string `json:"extensions,omitempty"`
+	// Verified extensions and themes, downloaded from a URL and checked against a sha256 checksum,
+	// copied from an OCI image or, for themes, mounted from a ConfigMap.
+	// +optional
+	ExtensionSources []KeycloakExtensionSource `json:"extensionSources,omitempty"`
 	// Number of Keycloak instances in HA mode. Default is 1.
 	// +optional
 	Instances int `json:"instances,omitempty"`
@@ -40,7 +46,7 @@ type KeycloakSpec struct {
 	//     apiVersion: v1
 	//     kind: Secret
 	//     metadata:
-	//         name: keycloak-db-secret
+	//         name: example-keycloak-db-secret
 	//         namespace: keycloak
 	//     stringData:
 	//         POSTGRES_DATABASE: <Database Name>
@@ -64,7 +70,25 @@ type KeycloakSpec struct {
 	// Profile used for controlling Operator behavior. Default is empty.
 	// +optional
 	Profile string `json:"profile,omitempty"`
-	// Specify PodDisruptionBudget configuration. This field is deprecated and will be ignored on K8s >=1.25
+	// Image of Keycloak, or of RH-SSO with the RHSSO profile, overriding the image configured for the operator.
+	// It can be pinned to a digest, e.g. quay.io/keycloak/keycloak:15.0.2@sha256:<digest>. Changing it migrates
+	// the instance with the configured migration strategy.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Image of the init container, overriding the image configured for the operator.
+	// +optional
+	InitContainerImage string `json:"initContainerImage,omitempty"`
+	// Image of the embedded PostgreSQL, overriding the image configured for the operator. Unencrypted backups
+	// of the instance are dumped and restored with it as well.
+	// +optional
+	PostgresImage string `json:"postgresImage,omitempty"`
+	// Major version of the embedded PostgreSQL: 10, 12, 13 or 15. Defaults to 10. Raising the version upgrades
+	// the database by dumping it and restoring the dump into a new data directory, the previous data directory
+	// is kept on the volume. If postgresImage is set, it has to run this version.
+	// +optional
+	PostgresVersion string `json:"postgresVersion,omitempty"`
+	// Specify PodDisruptionBudget configuration. The PodDisruptionBudget is created with policy/v1, or with
+	// policy/v1beta1 on clusters not serving policy/v1.
 	// +optional
 	PodDisruptionBudget PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
 	// Resources (Requests and Limits) and ImagePullPolicy for KeycloakDeployment.
@@ -92,6 +116,107 @@ type KeycloakSpec struct {
 	// Defaults to false.
 	// +optional
 	DisableReplicasSyncing bool `json:"disableReplicasSyncing,omitempty"`
+	// Controls the serving certificate issued by cert-manager. On OpenShift the serving certificate
+	// is created by the service CA unless this is enabled.
+	// +optional
+	CertManager KeycloakCertManager `json:"certManager,omitempty"`
+	// Controls the HorizontalPodAutoscaler of Keycloak. Replicas are not synced from Instances
+	// while it is enabled.
+	// +optional
+	Autoscaling KeycloakAutoscaling `json:"autoscaling,omitempty"`
+}
+
+type ExtensionSourceType string
+
+var (
+	DefaultExtensionSourceType   ExtensionSourceType
+	ExtensionExtensionSourceType ExtensionSourceType = "extension"
+	ThemeExtensionSourceType     ExtensionSourceType = "theme"
+)
+
+// KeycloakExtensionSource is an extension or a theme. Exactly one of url, image and configMap has to be set.
+type KeycloakExtensionSource struct {
+	// Name of the extension. Used to name the init container and, for themes, the theme directory.
+	Name string `json:"name"`
+	// Kind of the source: "extension" or "theme". If unspecified, defaults to "extension".
+	// +optional
+	Type ExtensionSourceType `json:"type,omitempty"`
+	// URL of the JAR file of an extension.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// Hex encoded sha256 checksum of the file at url. Required if url is set.
+	// +optional
+	SHA256 string `json:"sha256,omitempty"`
+	// Name of a secret with the "username" and "password" used to download the file at url.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// OCI image containing the extension JARs or the theme. The image needs a shell and cp.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Directory in the image that is copied. If unspecified, defaults to "/extensions" for
+	// extensions and "/theme" for themes.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// ConfigMap holding the files of a theme.
+	// +optional
+	ConfigMap *corev1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+}
+
+type KeycloakCertManager struct {
+	// If set to true, the Operator will create a cert-manager Certificate for the Keycloak service
+	// and store it in the serving certificate secret.
+	Enabled bool `json:"enabled,omitempty"`
+	// Name of the Issuer or ClusterIssuer signing the certificate.
+	IssuerName string `json:"issuerName,omitempty"`
+	// Kind of the issuer: "Issuer" or "ClusterIssuer". If unspecified, defaults to "Issuer".
+	// +optional
+	IssuerKind string `json:"issuerKind,omitempty"`
+	// If set to true, the host of the external access is added to the DNS names of the certificate.
+	// +optional
+	IncludeExternalHost bool `json:"includeExternalHost,omitempty"`
+}
+
+type KeycloakAutoscaling struct {
+	// If set to true, the Operator will create an autoscaling/v2 HorizontalPodAutoscaler for Keycloak.
+	Enabled bool `json:"enabled,omitempty"`
+	// Minimum number of replicas. If unspecified, defaults to Instances or 1.
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// Maximum number of replicas.
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+	// Target average CPU utilization in percent of the requested CPU.
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// Target average memory utilization in percent of the requested memory.
+	// +optional
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// Custom metrics, e.g. the active sessions exposed by the monitoring service.
+	// +optional
+	Metrics []KeycloakAutoscalingMetric `json:"metrics,omitempty"`
+	// Seconds to look back when scaling down, to avoid flapping. If unspecified, the default of the cluster is used.
+	// +optional
+	ScaleDownStabilizationWindowSeconds *int32 `json:"scaleDownStabilizationWindowSeconds,omitempty"`
+}
+
+type AutoscalingMetricType string
+
+var (
+	DefaultAutoscalingMetricType AutoscalingMetricType
+	// The metric is averaged over the Keycloak pods.
+	PodsAutoscalingMetricType AutoscalingMetricType = "Pods"
+	// The metric describes the Keycloak monitoring service.
+	ServiceAutoscalingMetricType AutoscalingMetricType = "Service"
+)
+
+type KeycloakAutoscalingMetric struct {
+	// Name of the metric, as provided by the custom metrics API.
+	Name string `json:"name"`
+	// Type of the metric: "Pods" or "Service". If unspecified, defaults to "Pods".
+	// +kubebuilder:validation:Enum={Pods,Service}
+	// +optional
+	Type AutoscalingMetricType `json:"type,omitempty"`
+	// Target value of the metric per pod.
+	TargetAverageValue resource.Quantity `json:"targetAverageValue"`
 }
 
 type DeploymentSpec struct {
@@ -103,6 +228,40 @@ type DeploymentSpec struct {
 	// +kubebuilder:default:=Always
 	// +optional
 	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// Secrets used to pull the images of the Pods.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Affinity settings for the Pods. Takes precedence over experimental.affinity.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// Tolerations of the Pods.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Node labels the Pods are scheduled on.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Topology spread constraints of the Pods.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// Name of the PriorityClass of the Pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Name of the ServiceAccount of the Pods. Takes precedence over experimental.serviceAccountName.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Security context of the Pods, e.g. runAsNonRoot, fsGroup and seccompProfile.
+	// +optional
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// Security context of the Containers, e.g. readOnlyRootFilesystem, allowPrivilegeEscalation
+	// and capabilities.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+	// Grace period for the Pods to terminate, in seconds.
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// Additional entries of the hosts file of the Pods.
+	// +optional
+	HostAliases []corev1.HostAlias `json:"hostAliases,omitempty"`
 }
 
 type KeycloakDeploymentSpec struct {
@@ -113,6 +272,14 @@ type KeycloakDeploymentSpec struct {
 	// List of labels to set in the keycloak pods
 	// +optional
 	PodLabels map[string]string `json:"podlabels,omitempty"`
+	// Additional containers running next to Keycloak, e.g. log shippers or database proxies. They are merged
+	// by name with the containers of the StatefulSet. Containers added by others are preserved.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+	// Additional init containers, run after the init container installing the extensions. They are merged
+	// by name with the init containers of the StatefulSet. Init containers added by others are preserved.
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
 
 	// Experimental section
 	// NOTE: This section might change or get removed without any notice. It may also cause
@@ -123,6 +290,15 @@ type KeycloakDeploymentSpec struct {
 
 type PostgresqlDeploymentSpec struct {
 	DeploymentSpec `json:",inline"`
+	// Specify PodDisruptionBudget configuration of the embedded PostgreSQL. As PostgreSQL runs a single pod, the
+	// default `minAvailable: 1` blocks all voluntary evictions, node drains wait until the pod is deleted manually.
+	// Set `maxUnavailable: 1` to let drains evict PostgreSQL.
+	// +optional
+	PodDisruptionBudget PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
+	// Size of the PersistentVolumeClaim of the embedded PostgreSQL, e.g. 10Gi. Defaults to 1Gi. Increasing the
+	// size expands the claim online if its StorageClass allows volume expansion, the claim can't shrink.
+	// +optional
+	StorageSize string `json:"storageSize,omitempty"`
 }
 
 type ExperimentalSpec struct {
@@ -141,9 +317,11 @@ type ExperimentalSpec struct {
 	// +optional
 	Volumes VolumesSpec `json:"volumes,omitempty"`
 	// Affinity settings
+	// Deprecated: use keycloakDeploymentSpec.affinity
 	//+optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
 	// ServiceAccountName settings
+	// Deprecated: use keycloakDeploymentSpec.serviceAccountName
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 }
@@ -190,10 +368,8 @@ type KeycloakExternalAccess struct {
 	// TLS Termination type for the external access. Setting this field to "reencrypt" will
 	// terminate TLS on the Ingress/Route level. Setting this field to "passthrough" will
 	// send encrypted traffic to the Pod. If unspecified, defaults to "reencrypt".
-	// Note, that this setting has no effect on Ingress
-	// as Ingress TLS settings are not reconciled by this operator. In other words,
-	// Ingress TLS configuration is the same in both cases and it is up to the user
-	// to configure TLS section of the Ingress.
+	// Note, that this setting has no effect on Ingress. Use tlsSecretName to configure
+	// the TLS section of the Ingress.
 	TLSTermination TLSTerminationType `json:"tlsTermination,omitempty"`
 	// If set, the Operator will use value of host for Ingress host
 	// instead of default value keycloak.local. Using this setting in OpenShift
@@ -201,16 +377,103 @@ type KeycloakExternalAccess struct {
 	// allowed to modify the hostname.
 	// +optional
 	Host string `json:"host,omitempty"`
+	// Name of the IngressClass implementing the Ingress. If unspecified, the default class of the cluster is used.
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// Ingress controller specific annotations added to the Ingress: "nginx", "haproxy" or "none".
+	// The profile configures HTTPS towards Keycloak and blocks the metrics (and if blockAdminPaths is set
+	// the admin) paths. With "none" only the annotations set by the user are added, the metrics
+	// stay reachable unless the user blocks them.
+	// If unspecified, defaults to "nginx".
+	// +kubebuilder:validation:Enum=nginx;haproxy;none
+	// +optional
+	IngressProfile IngressProfile `json:"ingressProfile,omitempty"`
+	// Additional annotations of the Ingress. They take precedence over the annotations of the profile.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Additional labels of the Ingress.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Name of the secret holding the TLS certificate of the host. If unspecified, the TLS section
+	// of an existing Ingress is kept as it is.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+	// Path under which Keycloak is exposed on the host. If unspecified, defaults to "/".
+	// The nginx and haproxy profiles strip the prefix before requests reach Keycloak, which
+	// is configured with the prefixed frontend URL. With "none" the user needs to add the rewrite.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9._~/-]*$`
+	// +optional
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// Path type of the Ingress rule: "Prefix", "Exact" or "ImplementationSpecific".
+	// If unspecified, defaults to "ImplementationSpecific". The nginx profile requires
+	// "ImplementationSpecific" with a path prefix.
+	// +kubebuilder:validation:Enum=Prefix;Exact;ImplementationSpecific
+	// +optional
+	PathType string `json:"pathType,omitempty"`
+	// If set to true, the admin console and admin REST API are blocked on the Ingress,
+	// using the annotations of the ingress profile.
+	// +optional
+	BlockAdminPaths bool `json:"blockAdminPaths,omitempty"`
+	// Kind of resource exposing Keycloak: "route", "ingress" or "gateway". If unspecified, a Route
+	// is used on OpenShift and an Ingress otherwise.
+	// +optional
+	Mode ExternalAccessMode `json:"mode,omitempty"`
+	// Gateway API settings, used if mode is "gateway".
+	// +optional
+	Gateway KeycloakGatewayAccess `json:"gateway,omitempty"`
+}
+
+type ExternalAccessMode string
+
+var (
+	DefaultExternalAccessMode ExternalAccessMode
+	RouteExternalAccessMode   ExternalAccessMode = "route"
+	IngressExternalAccessMode ExternalAccessMode = "ingress"
+	GatewayExternalAccessMode ExternalAccessMode = "gateway"
+)
+
+// KeycloakGatewayAccess attaches Keycloak to a Gateway of the Gateway API. With the "reencrypt" TLS termination
+// an HTTPRoute is created, with "passthrough" a TLSRoute. The HTTPRoute forwards the requests from the Gateway to
+// the HTTP port of Keycloak, use "passthrough" to keep the traffic encrypted up to Keycloak.
+type KeycloakGatewayAccess struct {
+	// Name of the Gateway the route is attached to.
+	Name string `json:"name,omitempty"`
+	// Namespace of the Gateway. If unspecified, defaults to the namespace of the Keycloak CR.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the listener of the Gateway the route is attached to. If unspecified, the route
+	// is attached to all listeners accepting it.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+	// Hostnames of the route. If unspecified, defaults to the host of the external access.
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
 }
 
+type IngressProfile string
+
+var (
+	DefaultIngressProfile IngressProfile
+	NginxIngressProfile   IngressProfile = "nginx"
+	HAProxyIngressProfile IngressProfile = "haproxy"
+	NoneIngressProfile    IngressProfile = "none"
+)
+
 type KeycloakExternalDatabase struct {
 	// If set to true, the Operator will use an external database pointing to Keycloak. The embedded database (externalDatabase.enabled = false) is deprecated.
 	Enabled bool `json:"enabled,omitempty"`
 }
 
 type PodDisruptionBudgetConfig struct {
-	// If set to true, the operator will create a PodDistruptionBudget for the Keycloak deployment and set its `maxUnavailable` value to 1.
+	// If set to true, the operator will create a PodDisruptionBudget. Unless minAvailable or maxUnavailable is set,
+	// it uses `maxUnavailable: 1` for Keycloak and `minAvailable: 1` for the embedded PostgreSQL.
 	Enabled bool `json:"enabled,omitempty"`
+	// Number or percentage of pods that must remain available. Only one of minAvailable and maxUnavailable can be set.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// Number or percentage of pods that can be unavailable. Only one of minAvailable and maxUnavailable can be set.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 }
 
 type MultiAvailablityZonesConfig struct {
@@ -225,6 +488,33 @@ type MigrateConfig struct {
 	// Set it to config backup policy for migration
 	// +optional
 	Backups BackupConfig `json:"backups,omitempty"`
+	// If set to true, rolling migrations across major versions, which change the database schema, fall back to the
+	// recreate strategy. Otherwise they are refused.
+	// +optional
+	FallbackToRecreate bool `json:"fallbackToRecreate,omitempty"`
+	// Seconds Keycloak has to become ready after the new image was deployed, the migration fails afterwards.
+	// Defaults to 600.
+	// +optional
+	ReadinessTimeoutSeconds int32 `json:"readinessTimeoutSeconds,omitempty"`
+	// If set to true, the migration backup is restored and the previous image deployed again when the migration
+	// fails. The previous image stays deployed until the image changes. Requires backups to be enabled.
+	// +optional
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+	// Window in which disruptive changes are applied: image migrations, recreations of the StatefulSet and the
+	// scale-downs to zero they need. They are held until the window opens, other changes are applied immediately.
+	// Disruptive changes that were started are completed after the window closes.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+}
+
+// MaintenanceWindow opens on a cron schedule and stays open for a duration.
+type MaintenanceWindow struct {
+	// Cron schedule in UTC with minute, hour, day of month, month and day of week on which the window opens,
+	// e.g. "0 2 * * 6" for Saturdays at 02:00.
+	Schedule string `json:"schedule"`
+	// Minutes the window stays open. Defaults to 60.
+	// +optional
+	DurationMinutes int32 `json:"durationMinutes,omitempty"`
 }
 
 type MigrationStrategy string
@@ -259,8 +549,121 @@ type KeycloakStatus struct {
 	ExternalURL string `json:"externalURL,omitempty"`
 	// The secret where the admin credentials are to be found.
 	CredentialSecret string `json:"credentialSecret"`
+	// True if the resources of this instance use the fixed names of operator versions that supported only one
+	// Keycloak per namespace. Set for instances that already owned such resources when the operator was upgraded.
+	LegacyNames bool `json:"legacyNames,omitempty"`
+	// True if Keycloak keeps being exposed by an Ingress on OpenShift unless externalAccess.mode is set. Set for
+	// instances that already owned an Ingress when the operator was upgraded to detect OpenShift.
+	LegacyIngress bool `json:"legacyIngress,omitempty"`
+	// Image of Keycloak deployed by the StatefulSet.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Digest of the Keycloak image run by all pods, e.g. quay.io/keycloak/keycloak@sha256:<digest>. Empty while
+	// the pods run different images.
+	// +optional
+	ImageDigest string `json:"imageDigest,omitempty"`
+	// Plan and progress of the last migration to a new Keycloak image.
+	// +optional
+	Migration *MigrationPlan `json:"migration,omitempty"`
+	// Disruptive changes held until the maintenance window opens.
+	// +optional
+	PendingMaintenance *PendingMaintenance `json:"pendingMaintenance,omitempty"`
+	// Version, storage and upgrade of the embedded PostgreSQL.
+	// +optional
+	Postgres *PostgresStatus `json:"postgres,omitempty"`
+}
+
+// PostgresStatus describes the embedded PostgreSQL.
+// +k8s:openapi-gen=true
+type PostgresStatus struct {
+	// Major version of PostgreSQL the data directory was created or last upgraded with.
+	Version string `json:"version"`
+	// Capacity of the PersistentVolumeClaim.
+	// +optional
+	StorageCapacity string `json:"storageCapacity,omitempty"`
+	// Progress of the expansion of the PersistentVolumeClaim.
+	// +optional
+	StorageMessage string `json:"storageMessage,omitempty"`
+	// Plan and progress of the last upgrade to a new major version.
+	// +optional
+	Upgrade *PostgresUpgrade `json:"upgrade,omitempty"`
 }
 
+// PostgresUpgrade describes the upgrade of the embedded PostgreSQL to a new major version.
+// +k8s:openapi-gen=true
+type PostgresUpgrade struct {
+	// Major version PostgreSQL is upgraded from.
+	FromVersion string `json:"fromVersion"`
+	// Major version PostgreSQL is upgraded to.
+	ToVersion string `json:"toVersion"`
+	// Image of the version upgraded from.
+	FromImage string `json:"fromImage"`
+	// Image of the version upgraded to.
+	ToImage string `json:"toImage"`
+	// Name of the KeycloakBackup with the dump restored into the new version.
+	Backup string `json:"backup"`
+	// Current phase of the upgrade: backing-up, upgrading, restoring, succeeded or failed.
+	Phase PostgresUpgradePhase `json:"phase"`
+	// Reason for the failure of the upgrade, or where the data of the previous version is kept once it succeeded.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type PostgresUpgradePhase string
+
+var (
+	PostgresUpgradePhaseBackingUp PostgresUpgradePhase = "backing-up"
+	PostgresUpgradePhaseUpgrading PostgresUpgradePhase = "upgrading"
+	PostgresUpgradePhaseRestoring PostgresUpgradePhase = "restoring"
+	PostgresUpgradePhaseSucceeded PostgresUpgradePhase = "succeeded"
+	PostgresUpgradePhaseFailed    PostgresUpgradePhase = "failed"
+)
+
+// PendingMaintenance describes the disruptive changes waiting for the maintenance window.
+// +k8s:openapi-gen=true
+type PendingMaintenance struct {
+	// Disruptive changes waiting for the window, e.g. the migration to a new image.
+	Changes []string `json:"changes"`
+	// Next time the maintenance window opens.
+	// +optional
+	NextWindow *metav1.Time `json:"nextWindow,omitempty"`
+}
+
+// MigrationPlan describes how Keycloak is migrated to a new image.
+// +k8s:openapi-gen=true
+type MigrationPlan struct {
+	// Strategy of the migration, recreate or rolling.
+	Strategy MigrationStrategy `json:"strategy"`
+	// Image Keycloak is migrated from.
+	FromImage string `json:"fromImage"`
+	// Image Keycloak is migrated to.
+	ToImage string `json:"toImage"`
+	// Name of the KeycloakBackup taken before the migration.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+	// Current phase of the migration: backing-up, upgrading, verifying, succeeded or failed.
+	Phase MigrationPhase `json:"phase"`
+	// Time the new image was deployed, Keycloak has to become ready within the readiness timeout.
+	// +optional
+	UpgradeStarted *metav1.Time `json:"upgradeStarted,omitempty"`
+	// True once the migration backup was restored and the previous image deployed again.
+	// +optional
+	RolledBack bool `json:"rolledBack,omitempty"`
+	// Reason for the strategy, if it differs from the configured one, or for the failure of the migration.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type MigrationPhase string
+
+var (
+	MigrationPhaseBackingUp MigrationPhase = "backing-up"
+	MigrationPhaseUpgrading MigrationPhase = "upgrading"
+	MigrationPhaseVerifying MigrationPhase = "verifying"
+	MigrationPhaseSucceeded MigrationPhase = "succeeded"
+	MigrationPhaseFailed    MigrationPhase = "failed"
+)
+
 type StatusPhase string
 
 var (
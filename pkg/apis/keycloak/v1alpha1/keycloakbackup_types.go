@@ -7,16 +7,41 @@ import (
 // KeycloakBackupSpec defines the desired state of KeycloakBackup.
 // +k8s:openapi-gen=true
 type KeycloakBackupSpec struct {
+	// Type of the backup. database (default) dumps the whole database, realms exports the realms with the admin
+	// API into one JSON file per realm. Realm exports contain the role mappings and groups of users, but not their
+	// credentials and client secrets, the admin API doesn't export them. They can't be combined with aws, encryption
+	// and verify.
+	// +kubebuilder:validation:Enum=database;realms
+	// +optional
+	Type BackupType `json:"type,omitempty"`
+	// Realms exported by realms backups and imported by their restore. All realms are exported and all but master
+	// are imported if empty.
+	// +optional
+	Realms []string `json:"realms,omitempty"`
+	// Controls how the restore of realms backups handles users, clients, groups, roles and identity providers
+	// that already exist, FAIL (default), SKIP or OVERWRITE. Existing users and clients are skipped by OVERWRITE,
+	// their credentials and secrets aren't part of the backup. Imported users have no password and imported clients
+	// get new secrets. Missing realms are created.
+	// +kubebuilder:validation:Enum=FAIL;SKIP;OVERWRITE
+	// +optional
+	ImportPolicy RealmImportPolicy `json:"importPolicy,omitempty"`
 	// Controls automatic restore behavior.
-	// Currently not implemented.
-	//
-	// In the future this will be used to trigger automatic restore for a given KeycloakBackup.
-	// Each backup will correspond to a single snapshot of the database (stored either in a
-	// Persistent Volume or AWS). If a user wants to restore it, all he/she needs to do is to
-	// change this flag to true.
-	// Potentially, it will be possible to restore a single backup multiple times.
+	// Has no effect, restores are triggered by restoreGeneration.
 	// +optional
 	Restore bool `json:"restore,omitempty"`
+	// Restores the backup into the database of the Keycloak instance, by a Job named
+	// <backup name>-restore-<generation>, unless status.restoredGeneration already equals it. Increase it to
+	// restore again or to retry a failed restore.
+	// The restore waits for the backups of this CR to finish. Database restores wait for Keycloak to be scaled
+	// down (instances: 0), realms backups are imported with the admin API. Encrypted backups are decrypted with
+	// encryption.privateKeysSecretName. Restores of aws backups are not supported.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RestoreGeneration int64 `json:"restoreGeneration,omitempty"`
+	// Name of the backup file to restore, e.g. backup-20240101030000.sql, or the directory of a realms backup,
+	// e.g. realms-20240101030000. The latest succeeded backup of the history is restored if not set.
+	// +optional
+	RestoreFile string `json:"restoreFile,omitempty"`
 	// If provided, an automatic database backup will be created on AWS S3 instead of
 	// a local Persistent Volume. If this property is not provided - a local
 	// Persistent Volume backup will be chosen.
@@ -28,6 +53,148 @@ type KeycloakBackupSpec struct {
 	// Name of the StorageClass for Postgresql Backup Persistent Volume Claim
 	// +optional
 	StorageClassName *string `json:"storageClassName,omitempty"`
+	// If specified, local backups are created by a CronJob using this schedule instead of a single Job.
+	// AWS backups are scheduled with aws.schedule.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// Controls how many backups are kept in the Persistent Volume or the destination. Every backup is written to its
+	// own timestamped file, older files are pruned after each backup. All backups are kept if not set.
+	// +optional
+	Retention KeycloakBackupRetention `json:"retention,omitempty"`
+	// If provided, backups are uploaded to this destination instead of a local Persistent Volume.
+	// Can't be combined with aws.
+	// +optional
+	Destination *KeycloakBackupDestination `json:"destination,omitempty"`
+	// If provided, local and destination backups are encrypted. AWS backups are encrypted with
+	// aws.encryptionKeySecretName.
+	// +optional
+	Encryption *KeycloakBackupEncryption `json:"encryption,omitempty"`
+	// If true, the latest local or destination backup is verified by a Job named <backup job>-verify. It restores the
+	// backup into a temporary database, compares the checksum and checks the realms and the schema version.
+	// Encrypted backups are decrypted with encryption.privateKeysSecretName. The result is recorded in the history.
+	// +optional
+	Verify bool `json:"verify,omitempty"`
+}
+
+type BackupType string
+
+var (
+	DatabaseBackupType BackupType = "database"
+	RealmsBackupType   BackupType = "realms"
+)
+
+type RealmImportPolicy string
+
+var (
+	RealmImportPolicyFail      RealmImportPolicy = "FAIL"
+	RealmImportPolicySkip      RealmImportPolicy = "SKIP"
+	RealmImportPolicyOverwrite RealmImportPolicy = "OVERWRITE"
+)
+
+type BackupEncryptionType string
+
+var (
+	AgeBackupEncryptionType BackupEncryptionType = "age"
+	GPGBackupEncryptionType BackupEncryptionType = "gpg"
+)
+
+// KeycloakBackupEncryption defines the keys backups are encrypted to. Every backup is encrypted to all public keys,
+// so keys are rotated by adding the new key and removing the old one once the backups encrypted to it are pruned.
+// +k8s:openapi-gen=true
+type KeycloakBackupEncryption struct {
+	// Tool used for the encryption, age or gpg. The gpg binary of the PostgreSQL image is used, age requires
+	// the RELATED_IMAGE_BACKUP_ENCRYPTOR image of the operator, which has no default, to provide pg_dump, psql and age
+	// of PostgreSQL 10, and RELATED_IMAGE_BACKUP_ENCRYPTOR_<version> of the other versions.
+	// +kubebuilder:validation:Enum=age;gpg
+	Type BackupEncryptionType `json:"type"`
+	// Name of a Secret with the public keys. For age the AGE_RECIPIENTS key holds one recipient per line,
+	// for gpg the GPG_PUBLIC_KEYS key holds the armored public keys.
+	// +kubebuilder:validation:Required
+	PublicKeysSecretName string `json:"publicKeysSecretName"`
+	// Name of a Secret with the private keys used by restores. For age the AGE_IDENTITIES key holds one identity
+	// per line, for gpg the GPG_PRIVATE_KEYS key holds the armored private keys.
+	// +optional
+	PrivateKeysSecretName string `json:"privateKeysSecretName,omitempty"`
+}
+
+// KeycloakBackupDestination defines the storage backups are uploaded to. Exactly one of s3, gcs and azure has to
+// be set.
+// +k8s:openapi-gen=true
+type KeycloakBackupDestination struct {
+	// AWS S3 or an S3-compatible storage like MinIO or Ceph RGW.
+	// +optional
+	S3 *KeycloakBackupS3Destination `json:"s3,omitempty"`
+	// Google Cloud Storage.
+	// +optional
+	GCS *KeycloakBackupGCSDestination `json:"gcs,omitempty"`
+	// Azure Blob Storage.
+	// +optional
+	Azure *KeycloakBackupAzureDestination `json:"azure,omitempty"`
+}
+
+// KeycloakBackupS3Destination defines a bucket of AWS S3 or an S3-compatible storage.
+// +k8s:openapi-gen=true
+type KeycloakBackupS3Destination struct {
+	// Name of the bucket.
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+	// Path inside the bucket the backups are stored under.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// URL of an S3-compatible storage, e.g. http://minio.minio.svc:9000. AWS S3 is used if not set.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region of the bucket.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Use path-style addressing (<endpoint>/<bucket>) instead of virtual-hosted-style addressing.
+	// Most S3-compatible storages require it.
+	// +optional
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+	// Name of a Secret with the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY keys.
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// KeycloakBackupGCSDestination defines a bucket of Google Cloud Storage.
+// +k8s:openapi-gen=true
+type KeycloakBackupGCSDestination struct {
+	// Name of the bucket.
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+	// Path inside the bucket the backups are stored under.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Name of a Secret with a service account key in the credentials.json key.
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// KeycloakBackupAzureDestination defines a container of Azure Blob Storage.
+// +k8s:openapi-gen=true
+type KeycloakBackupAzureDestination struct {
+	// Name of the container.
+	// +kubebuilder:validation:Required
+	Container string `json:"container"`
+	// Path inside the container the backups are stored under.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Name of a Secret with the AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY keys.
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// KeycloakBackupRetention defines the retention policy of local backups.
+// +k8s:openapi-gen=true
+type KeycloakBackupRetention struct {
+	// Number of backups to keep.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Count int32 `json:"count,omitempty"`
+	// Number of days a backup is kept.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxAgeDays int32 `json:"maxAgeDays,omitempty"`
 }
 
 // KeycloakAWSSpec defines the desired state of KeycloakBackupSpec.
@@ -81,6 +248,52 @@ var (
 	BackupPhaseFailing     BackupStatusPhase = "failing"
 )
 
+type BackupResult string
+
+var (
+	BackupResultSucceeded BackupResult = "succeeded"
+	BackupResultFailed    BackupResult = "failed"
+)
+
+type BackupVerificationResult string
+
+var (
+	BackupVerified   BackupVerificationResult = "verified"
+	BackupUnverified BackupVerificationResult = "unverified"
+)
+
+// KeycloakBackupHistoryEntry describes a single run of a backup.
+// +k8s:openapi-gen=true
+type KeycloakBackupHistoryEntry struct {
+	// Name of the Job that ran the backup.
+	Job string `json:"job"`
+	// Time the backup was started.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Name of the dump file or the directory of the realm exports in the Persistent Volume or the destination.
+	// +optional
+	File string `json:"file,omitempty"`
+	// Size of the dump file in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+	// Fingerprints of the gpg keys or age recipients the dump file is encrypted to.
+	// +optional
+	KeyFingerprints []string `json:"keyFingerprints,omitempty"`
+	// SHA-256 checksum of the dump file.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+	// Result of the backup.
+	Result BackupResult `json:"result"`
+	// Result of the verification, unverified if the dump file couldn't be restored or lacks Keycloak data.
+	// +optional
+	Verification BackupVerificationResult `json:"verification,omitempty"`
+	// Number of realms found by the verification.
+	// +optional
+	RealmCount int32 `json:"realmCount,omitempty"`
+	// Latest Liquibase changeset of the Keycloak schema found by the verification.
+	// +optional
+	SchemaVersion string `json:"schemaVersion,omitempty"`
+}
+
 // KeycloakBackupStatus defines the observed state of KeycloakBackup.
 // +k8s:openapi-gen=true
 type KeycloakBackupStatus struct {
@@ -92,6 +305,15 @@ type KeycloakBackupStatus struct {
 	Ready bool `json:"ready"`
 	// A map of all the secondary resources types and names created for this CR. e.g "Deployment": [ "DeploymentName1", "DeploymentName2" ]
 	SecondaryResources map[string][]string `json:"secondaryResources,omitempty"`
+	// Finished local and destination backups, newest first.
+	// +optional
+	History []KeycloakBackupHistoryEntry `json:"history,omitempty"`
+	// The restoreGeneration of the last restore that succeeded.
+	// +optional
+	RestoredGeneration int64 `json:"restoredGeneration,omitempty"`
+	// The restoreGeneration of the last restore that failed.
+	// +optional
+	FailedRestoreGeneration int64 `json:"failedRestoreGeneration,omitempty"`
 }
 
 // KeycloakBackup is the Schema for the keycloakbackups API.
@@ -121,3 +343,8 @@ func init() {
 func (i *KeycloakBackup) UpdateStatusSecondaryResources(kind string, resourceName string) {
 	i.Status.SecondaryResources = UpdateStatusSecondaryResources(i.Status.SecondaryResources, kind, resourceName)
 }
+
+// IsRestoreRequested returns true if the restoreGeneration has not been restored yet
+func (i *KeycloakBackup) IsRestoreRequested() bool {
+	return i.Spec.RestoreGeneration != 0 && i.Spec.RestoreGeneration != i.Status.RestoredGeneration
+}
@@ -31,6 +31,9 @@ type KeycloakUserStatus struct {
 	Phase StatusPhase `json:"phase"`
 	// Human-readable message indicating details about current operator phase or error.
 	Message string `json:"message"`
+	// Keyed hashes of the credentials last applied to the user, by Keycloak instance and realm.
+	// +optional
+	CredentialsHashes map[string]string `json:"credentialsHashes,omitempty"`
 }
 
 // KeycloakUser is the Schema for the keycloakusers API.
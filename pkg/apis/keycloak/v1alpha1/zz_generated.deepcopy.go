@@ -9,6 +9,7 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -98,6 +99,59 @@ func (in *ClientMappingsRepresentation) DeepCopy() *ClientMappingsRepresentation
 func (in *DeploymentSpec) DeepCopyInto(out *DeploymentSpec) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.HostAliases != nil {
+		in, out := &in.HostAliases, &out.HostAliases
+		*out = make([]v1.HostAlias, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -793,6 +847,66 @@ func (in *KeycloakAWSSpec) DeepCopy() *KeycloakAWSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakAutoscaling) DeepCopyInto(out *KeycloakAutoscaling) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetMemoryUtilizationPercentage != nil {
+		in, out := &in.TargetMemoryUtilizationPercentage, &out.TargetMemoryUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]KeycloakAutoscalingMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ScaleDownStabilizationWindowSeconds != nil {
+		in, out := &in.ScaleDownStabilizationWindowSeconds, &out.ScaleDownStabilizationWindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakAutoscaling.
+func (in *KeycloakAutoscaling) DeepCopy() *KeycloakAutoscaling {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakAutoscaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakAutoscalingMetric) DeepCopyInto(out *KeycloakAutoscalingMetric) {
+	*out = *in
+	out.TargetAverageValue = in.TargetAverageValue.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakAutoscalingMetric.
+func (in *KeycloakAutoscalingMetric) DeepCopy() *KeycloakAutoscalingMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakAutoscalingMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakBackup) DeepCopyInto(out *KeycloakBackup) {
 	*out = *in
@@ -821,6 +935,107 @@ func (in *KeycloakBackup) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupAzureDestination) DeepCopyInto(out *KeycloakBackupAzureDestination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupAzureDestination.
+func (in *KeycloakBackupAzureDestination) DeepCopy() *KeycloakBackupAzureDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupAzureDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupDestination) DeepCopyInto(out *KeycloakBackupDestination) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(KeycloakBackupS3Destination)
+		**out = **in
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		*out = new(KeycloakBackupGCSDestination)
+		**out = **in
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(KeycloakBackupAzureDestination)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupDestination.
+func (in *KeycloakBackupDestination) DeepCopy() *KeycloakBackupDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupEncryption) DeepCopyInto(out *KeycloakBackupEncryption) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupEncryption.
+func (in *KeycloakBackupEncryption) DeepCopy() *KeycloakBackupEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupGCSDestination) DeepCopyInto(out *KeycloakBackupGCSDestination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupGCSDestination.
+func (in *KeycloakBackupGCSDestination) DeepCopy() *KeycloakBackupGCSDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupGCSDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupHistoryEntry) DeepCopyInto(out *KeycloakBackupHistoryEntry) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.KeyFingerprints != nil {
+		in, out := &in.KeyFingerprints, &out.KeyFingerprints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupHistoryEntry.
+func (in *KeycloakBackupHistoryEntry) DeepCopy() *KeycloakBackupHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakBackupList) DeepCopyInto(out *KeycloakBackupList) {
 	*out = *in
@@ -854,9 +1069,46 @@ func (in *KeycloakBackupList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupRetention) DeepCopyInto(out *KeycloakBackupRetention) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupRetention.
+func (in *KeycloakBackupRetention) DeepCopy() *KeycloakBackupRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupS3Destination) DeepCopyInto(out *KeycloakBackupS3Destination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupS3Destination.
+func (in *KeycloakBackupS3Destination) DeepCopy() *KeycloakBackupS3Destination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupS3Destination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakBackupSpec) DeepCopyInto(out *KeycloakBackupSpec) {
 	*out = *in
+	if in.Realms != nil {
+		in, out := &in.Realms, &out.Realms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	out.AWS = in.AWS
 	if in.InstanceSelector != nil {
 		in, out := &in.InstanceSelector, &out.InstanceSelector
@@ -868,6 +1120,17 @@ func (in *KeycloakBackupSpec) DeepCopyInto(out *KeycloakBackupSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	out.Retention = in.Retention
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(KeycloakBackupDestination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(KeycloakBackupEncryption)
+		**out = **in
+	}
 	return
 }
 
@@ -899,6 +1162,13 @@ func (in *KeycloakBackupStatus) DeepCopyInto(out *KeycloakBackupStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]KeycloakBackupHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -912,6 +1182,22 @@ func (in *KeycloakBackupStatus) DeepCopy() *KeycloakBackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakCertManager) DeepCopyInto(out *KeycloakCertManager) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakCertManager.
+func (in *KeycloakCertManager) DeepCopy() *KeycloakCertManager {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakCertManager)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakClient) DeepCopyInto(out *KeycloakClient) {
 	*out = *in
@@ -1126,6 +1412,20 @@ func (in *KeycloakDeploymentSpec) DeepCopyInto(out *KeycloakDeploymentSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Experimental.DeepCopyInto(&out.Experimental)
 	return
 }
@@ -1140,6 +1440,27 @@ func (in *KeycloakDeploymentSpec) DeepCopy() *KeycloakDeploymentSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakExtensionSource) DeepCopyInto(out *KeycloakExtensionSource) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.ConfigMapVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakExtensionSource.
+func (in *KeycloakExtensionSource) DeepCopy() *KeycloakExtensionSource {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakExtensionSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakExternal) DeepCopyInto(out *KeycloakExternal) {
 	*out = *in
@@ -1159,6 +1480,21 @@ func (in *KeycloakExternal) DeepCopy() *KeycloakExternal {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakExternalAccess) DeepCopyInto(out *KeycloakExternalAccess) {
 	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Gateway.DeepCopyInto(&out.Gateway)
 	return
 }
 
@@ -1234,6 +1570,27 @@ func (in *KeycloakIdentityProviderMapper) DeepCopy() *KeycloakIdentityProviderMa
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakGatewayAccess) DeepCopyInto(out *KeycloakGatewayAccess) {
+	*out = *in
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakGatewayAccess.
+func (in *KeycloakGatewayAccess) DeepCopy() *KeycloakGatewayAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakGatewayAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakList) DeepCopyInto(out *KeycloakList) {
 	*out = *in
@@ -1582,18 +1939,27 @@ func (in *KeycloakSpec) DeepCopyInto(out *KeycloakSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	out.ExternalAccess = in.ExternalAccess
+	if in.ExtensionSources != nil {
+		in, out := &in.ExtensionSources, &out.ExtensionSources
+		*out = make([]KeycloakExtensionSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.ExternalAccess.DeepCopyInto(&out.ExternalAccess)
 	out.ExternalDatabase = in.ExternalDatabase
-	out.PodDisruptionBudget = in.PodDisruptionBudget
+	in.PodDisruptionBudget.DeepCopyInto(&out.PodDisruptionBudget)
 	in.KeycloakDeploymentSpec.DeepCopyInto(&out.KeycloakDeploymentSpec)
 	in.PostgresDeploymentSpec.DeepCopyInto(&out.PostgresDeploymentSpec)
-	out.Migration = in.Migration
+	in.Migration.DeepCopyInto(&out.Migration)
 	if in.StorageClassName != nil {
 		in, out := &in.StorageClassName, &out.StorageClassName
 		*out = new(string)
 		**out = **in
 	}
 	out.MultiAvailablityZones = in.MultiAvailablityZones
+	out.CertManager = in.CertManager
+	in.Autoscaling.DeepCopyInto(&out.Autoscaling)
 	return
 }
 
@@ -1625,6 +1991,21 @@ func (in *KeycloakStatus) DeepCopyInto(out *KeycloakStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(MigrationPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingMaintenance != nil {
+		in, out := &in.PendingMaintenance, &out.PendingMaintenance
+		*out = new(PendingMaintenance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Postgres != nil {
+		in, out := &in.Postgres, &out.Postgres
+		*out = new(PostgresStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1644,7 +2025,7 @@ func (in *KeycloakUser) DeepCopyInto(out *KeycloakUser) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -1740,6 +2121,13 @@ func (in *KeycloakUserSpec) DeepCopy() *KeycloakUserSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakUserStatus) DeepCopyInto(out *KeycloakUserStatus) {
 	*out = *in
+	if in.CredentialsHashes != nil {
+		in, out := &in.CredentialsHashes, &out.CredentialsHashes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -1753,6 +2141,22 @@ func (in *KeycloakUserStatus) DeepCopy() *KeycloakUserStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MappingsRepresentation) DeepCopyInto(out *MappingsRepresentation) {
 	*out = *in
@@ -1787,6 +2191,11 @@ func (in *MappingsRepresentation) DeepCopy() *MappingsRepresentation {
 func (in *MigrateConfig) DeepCopyInto(out *MigrateConfig) {
 	*out = *in
 	out.Backups = in.Backups
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		**out = **in
+	}
 	return
 }
 
@@ -1800,6 +2209,26 @@ func (in *MigrateConfig) DeepCopy() *MigrateConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationPlan) DeepCopyInto(out *MigrationPlan) {
+	*out = *in
+	if in.UpgradeStarted != nil {
+		in, out := &in.UpgradeStarted, &out.UpgradeStarted
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationPlan.
+func (in *MigrationPlan) DeepCopy() *MigrationPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MultiAvailablityZonesConfig) DeepCopyInto(out *MultiAvailablityZonesConfig) {
 	*out = *in
@@ -1816,9 +2245,44 @@ func (in *MultiAvailablityZonesConfig) DeepCopy() *MultiAvailablityZonesConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingMaintenance) DeepCopyInto(out *PendingMaintenance) {
+	*out = *in
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NextWindow != nil {
+		in, out := &in.NextWindow, &out.NextWindow
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingMaintenance.
+func (in *PendingMaintenance) DeepCopy() *PendingMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodDisruptionBudgetConfig) DeepCopyInto(out *PodDisruptionBudgetConfig) {
 	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
 	return
 }
 
@@ -1832,10 +2296,48 @@ func (in *PodDisruptionBudgetConfig) DeepCopy() *PodDisruptionBudgetConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresStatus) DeepCopyInto(out *PostgresStatus) {
+	*out = *in
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(PostgresUpgrade)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresStatus.
+func (in *PostgresStatus) DeepCopy() *PostgresStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresUpgrade) DeepCopyInto(out *PostgresUpgrade) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresUpgrade.
+func (in *PostgresUpgrade) DeepCopy() *PostgresUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgresqlDeploymentSpec) DeepCopyInto(out *PostgresqlDeploymentSpec) {
 	*out = *in
 	in.DeploymentSpec.DeepCopyInto(&out.DeploymentSpec)
+	in.PodDisruptionBudget.DeepCopyInto(&out.PodDisruptionBudget)
 	return
 }
 
@@ -167,13 +167,56 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupSpec(ref common.ReferenceCa
 				Description: "KeycloakBackupSpec defines the desired state of KeycloakBackup.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the backup. database (default) dumps the whole database, realms exports the realms with the admin API into one JSON file per realm. Realm exports contain the role mappings and groups of users, but not their credentials and client secrets, the admin API doesn't export them. They can't be combined with aws, encryption and verify.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"realms": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Realms exported by realms backups and imported by their restore. All realms are exported and all but master are imported if empty.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"importPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Controls how the restore of realms backups handles users, clients, groups, roles and identity providers that already exist, FAIL (default), SKIP or OVERWRITE. Existing users and clients are skipped by OVERWRITE, their credentials and secrets aren't part of the backup. Imported users have no password and imported clients get new secrets. Missing realms are created.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"restore": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Controls automatic restore behavior. Currently not implemented.\n\nIn the future this will be used to trigger automatic restore for a given KeycloakBackup. Each backup will correspond to a single snapshot of the database (stored either in a Persistent Volume or AWS). If a user wants to restore it, all he/she needs to do is to change this flag to true. Potentially, it will be possible to restore a single backup multiple times.",
+							Description: "Controls automatic restore behavior. Has no effect, restores are triggered by restoreGeneration.",
 							Type:        []string{"boolean"},
 							Format:      "",
 						},
 					},
+					"restoreFile": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the backup file to restore, e.g. backup-20240101030000.sql, or the directory of a realms backup, e.g. realms-20240101030000. The latest succeeded backup of the history is restored if not set.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"restoreGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Restores the backup into the database of the Keycloak instance, by a Job named <backup name>-restore-<generation>, unless status.restoredGeneration already equals it. Increase it to restore again or to retry a failed restore. The restore waits for the backups of this CR to finish. Database restores wait for Keycloak to be scaled down (instances: 0), realms backups are imported with the admin API. Encrypted backups are decrypted with encryption.privateKeysSecretName. Restores of aws backups are not supported.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
 					"aws": {
 						SchemaProps: spec.SchemaProps{
 							Description: "If provided, an automatic database backup will be created on AWS S3 instead of a local Persistent Volume. If this property is not provided - a local Persistent Volume backup will be chosen.",
@@ -194,11 +237,44 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupSpec(ref common.ReferenceCa
 							Format:      "",
 						},
 					},
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If specified, local backups are created by a CronJob using this schedule instead of a single Job. AWS backups are scheduled with aws.schedule.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"retention": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Controls how many backups are kept in the Persistent Volume or the destination. Every backup is written to its own timestamped file, older files are pruned after each backup. All backups are kept if not set.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupRetention"),
+						},
+					},
+					"destination": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If provided, backups are uploaded to this destination instead of a local Persistent Volume. Can't be combined with aws.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupDestination"),
+						},
+					},
+					"encryption": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If provided, local and destination backups are encrypted. AWS backups are encrypted with aws.encryptionKeySecretName.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupEncryption"),
+						},
+					},
+					"verify": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If true, the latest local or destination backup is verified by a Job named <backup job>-verify. It restores the backup into a temporary database, compares the checksum and checks the realms and the schema version. Encrypted backups are decrypted with encryption.privateKeysSecretName. The result is recorded in the history.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/keycloak/v1alpha1.KeycloakAWSSpec", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+			"./pkg/apis/keycloak/v1alpha1.KeycloakAWSSpec", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupDestination", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupEncryption", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupRetention", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
 	}
 }
 
@@ -256,10 +332,40 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupStatus(ref common.Reference
 							},
 						},
 					},
+					"history": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Finished local and destination backups, newest first.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupHistoryEntry"),
+									},
+								},
+							},
+						},
+					},
+					"restoredGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The restoreGeneration of the last restore that succeeded.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"failedRestoreGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The restoreGeneration of the last restore that failed.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
 				},
 				Required: []string{"phase", "message", "ready"},
 			},
 		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.KeycloakBackupHistoryEntry"},
 	}
 }
 
@@ -674,6 +780,20 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							},
 						},
 					},
+					"extensionSources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Verified extensions and themes, downloaded from a URL and checked against a sha256 checksum, copied from an OCI image or, for themes, mounted from a ConfigMap.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakExtensionSource"),
+									},
+								},
+							},
+						},
+					},
 					"instances": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Number of Keycloak instances in HA mode. Default is 1.",
@@ -690,7 +810,7 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 					},
 					"externalDatabase": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Controls external database settings. Using an external database requires providing a secret containing credentials as well as connection details. Here's an example of such secret:\n\n    apiVersion: v1\n    kind: Secret\n    metadata:\n        name: keycloak-db-secret\n        namespace: keycloak\n    stringData:\n        POSTGRES_DATABASE: <Database Name>\n        POSTGRES_EXTERNAL_ADDRESS: <External Database IP or URL (resolvable by K8s)>\n        POSTGRES_EXTERNAL_PORT: <External Database Port>\n        # Strongly recommended to use <'Keycloak CR Name'-postgresql>\n        POSTGRES_HOST: <Database Service Name>\n        POSTGRES_PASSWORD: <Database Password>\n        # Required for AWS Backup functionality\n        POSTGRES_SUPERUSER: true\n        POSTGRES_USERNAME: <Database Username>\n     type: Opaque\n\nBoth POSTGRES_EXTERNAL_ADDRESS and POSTGRES_EXTERNAL_PORT are specifically required for creating connection to the external database. The secret name is created using the following convention:\n      <Custom Resource Name>-db-secret\n\nFor more information, please refer to the Operator documentation.",
+							Description: "Controls external database settings. Using an external database requires providing a secret containing credentials as well as connection details. Here's an example of such secret:\n\n    apiVersion: v1\n    kind: Secret\n    metadata:\n        name: example-keycloak-db-secret\n        namespace: keycloak\n    stringData:\n        POSTGRES_DATABASE: <Database Name>\n        POSTGRES_EXTERNAL_ADDRESS: <External Database IP or URL (resolvable by K8s)>\n        POSTGRES_EXTERNAL_PORT: <External Database Port>\n        # Strongly recommended to use <'Keycloak CR Name'-postgresql>\n        POSTGRES_HOST: <Database Service Name>\n        POSTGRES_PASSWORD: <Database Password>\n        # Required for AWS Backup functionality\n        POSTGRES_SUPERUSER: true\n        POSTGRES_USERNAME: <Database Username>\n     type: Opaque\n\nBoth POSTGRES_EXTERNAL_ADDRESS and POSTGRES_EXTERNAL_PORT are specifically required for creating connection to the external database. The secret name is created using the following convention:\n      <Custom Resource Name>-db-secret\n\nFor more information, please refer to the Operator documentation.",
 							Default:     map[string]interface{}{},
 							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakExternalDatabase"),
 						},
@@ -702,9 +822,37 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							Format:      "",
 						},
 					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image of Keycloak, or of RH-SSO with the RHSSO profile, overriding the image configured for the operator. It can be pinned to a digest, e.g. quay.io/keycloak/keycloak:15.0.2@sha256:<digest>. Changing it migrates the instance with the configured migration strategy.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"initContainerImage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image of the init container, overriding the image configured for the operator.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"postgresImage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image of the embedded PostgreSQL, overriding the image configured for the operator. Unencrypted backups of the instance are dumped and restored with it as well.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"postgresVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Major version of the embedded PostgreSQL: 10, 12, 13 or 15. Defaults to 10. Raising the version upgrades the database by dumping it and restoring the dump into a new data directory, the previous data directory is kept on the volume. If postgresImage is set, it has to run this version.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"podDisruptionBudget": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Specify PodDisruptionBudget configuration. This field is deprecated and will be ignored on K8s >=1.25",
+							Description: "Specify PodDisruptionBudget configuration. The PodDisruptionBudget is created with policy/v1, or with policy/v1beta1 on clusters not serving policy/v1.",
 							Default:     map[string]interface{}{},
 							Ref:         ref("./pkg/apis/keycloak/v1alpha1.PodDisruptionBudgetConfig"),
 						},
@@ -758,11 +906,25 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							Format:      "",
 						},
 					},
+					"certManager": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Controls the serving certificate issued by cert-manager. On OpenShift the serving certificate is created by the service CA unless this is enabled.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakCertManager"),
+						},
+					},
+					"autoscaling": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Controls the HorizontalPodAutoscaler of Keycloak. Replicas are not synced from Instances while it is enabled.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakAutoscaling"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/keycloak/v1alpha1.KeycloakDeploymentSpec", "./pkg/apis/keycloak/v1alpha1.KeycloakExternal", "./pkg/apis/keycloak/v1alpha1.KeycloakExternalAccess", "./pkg/apis/keycloak/v1alpha1.KeycloakExternalDatabase", "./pkg/apis/keycloak/v1alpha1.MigrateConfig", "./pkg/apis/keycloak/v1alpha1.MultiAvailablityZonesConfig", "./pkg/apis/keycloak/v1alpha1.PodDisruptionBudgetConfig", "./pkg/apis/keycloak/v1alpha1.PostgresqlDeploymentSpec"},
+			"./pkg/apis/keycloak/v1alpha1.KeycloakAutoscaling", "./pkg/apis/keycloak/v1alpha1.KeycloakCertManager", "./pkg/apis/keycloak/v1alpha1.KeycloakDeploymentSpec", "./pkg/apis/keycloak/v1alpha1.KeycloakExtensionSource", "./pkg/apis/keycloak/v1alpha1.KeycloakExternal", "./pkg/apis/keycloak/v1alpha1.KeycloakExternalAccess", "./pkg/apis/keycloak/v1alpha1.KeycloakExternalDatabase", "./pkg/apis/keycloak/v1alpha1.MigrateConfig", "./pkg/apis/keycloak/v1alpha1.MultiAvailablityZonesConfig", "./pkg/apis/keycloak/v1alpha1.PodDisruptionBudgetConfig", "./pkg/apis/keycloak/v1alpha1.PostgresqlDeploymentSpec"},
 	}
 }
 
@@ -851,10 +1013,58 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakStatus(ref common.ReferenceCallba
 							Format:      "",
 						},
 					},
+					"legacyNames": {
+						SchemaProps: spec.SchemaProps{
+							Description: "True if the resources of this instance use the fixed names of operator versions that supported only one Keycloak per namespace. Set for instances that already owned such resources when the operator was upgraded.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"legacyIngress": {
+						SchemaProps: spec.SchemaProps{
+							Description: "True if Keycloak keeps being exposed by an Ingress on OpenShift unless externalAccess.mode is set. Set for instances that already owned an Ingress when the operator was upgraded to detect OpenShift.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image of Keycloak deployed by the StatefulSet.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"imageDigest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Digest of the Keycloak image run by all pods, e.g. quay.io/keycloak/keycloak@sha256:<digest>. Empty while the pods run different images.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"migration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Plan and progress of the last migration to a new Keycloak image.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.MigrationPlan"),
+						},
+					},
+					"pendingMaintenance": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Disruptive changes held until the maintenance window opens.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.PendingMaintenance"),
+						},
+					},
+					"postgres": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Version, storage and upgrade of the embedded PostgreSQL.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.PostgresStatus"),
+						},
+					},
 				},
 				Required: []string{"phase", "message", "ready", "version", "internalURL", "credentialSecret"},
 			},
 		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.MigrationPlan", "./pkg/apis/keycloak/v1alpha1.PendingMaintenance", "./pkg/apis/keycloak/v1alpha1.PostgresStatus"},
 	}
 }
 
@@ -957,6 +1167,22 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakUserStatus(ref common.ReferenceCa
 							Format:      "",
 						},
 					},
+					"credentialsHashes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Keyed hashes of the credentials last applied to the user, by Keycloak instance and realm.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"phase", "message"},
 			},
@@ -5,8 +5,8 @@ import (
 
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	grafanav1alpha1 "github.com/integr8ly/grafana-operator/v3/pkg/apis/integreatly/v1alpha1"
+	"github.com/jaconi-io/keycloak-operator/pkg/model"
 	routev1 "github.com/openshift/api/route/v1"
-	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -54,10 +54,15 @@ func (b *Background) autoDetectCapabilities() {
 	serviceMonitor := monitoringv1.SchemeGroupVersion.WithKind(monitoringv1.ServiceMonitorsKind)
 	grafanaDashboard := grafanav1alpha1.SchemeGroupVersion.WithKind(grafanav1alpha1.GrafanaDashboardKind)
 	route := routev1.SchemeGroupVersion.WithKind(RouteKind)
-	pdb := policyv1beta1.SchemeGroupVersion.WithKind(PodDisruptionBudgetKind)
+	pdb := model.PodDisruptionBudgetGroupVersionKind
+	pdbV1beta1 := model.PodDisruptionBudgetV1beta1GroupVersionKind
+	httpRoute := model.HTTPRouteGroupVersionKind
+	tlsRoute := model.TLSRouteGroupVersionKind
+	certificate := model.CertificateGroupVersionKind
+	hpa := model.HorizontalPodAutoscalerGroupVersionKind
 
 	resources, _ := resourcesExist(b.dc, []schema.GroupVersionKind{
-		openshift, prometheusRule, serviceMonitor, grafanaDashboard, route, pdb,
+		openshift, prometheusRule, serviceMonitor, grafanaDashboard, route, pdb, pdbV1beta1, httpRoute, tlsRoute, certificate, hpa,
 	})
 
 	// Set state that its Openshift (helps to differentiate between openshift and kubernetes)
@@ -70,7 +75,19 @@ func (b *Background) autoDetectCapabilities() {
 	// Set state that the Route kind exists. Used to determine when a route or an Ingress should be created
 	stateManager.SetState(RouteKind, resources[route])
 
-	stateManager.SetState(PodDisruptionBudgetKind, resources[pdb])
+	// policy/v1 is preferred, policy/v1beta1 is used on clusters older than Kubernetes 1.21
+	stateManager.SetState(PodDisruptionBudgetKind, resources[pdb] || resources[pdbV1beta1])
+	stateManager.SetState(PodDisruptionBudgetV1beta1Only, !resources[pdb] && resources[pdbV1beta1])
+
+	// Gateway API routes, used if the external access mode is gateway
+	stateManager.SetState(HTTPRouteKind, resources[httpRoute])
+	stateManager.SetState(TLSRouteKind, resources[tlsRoute])
+
+	// cert-manager, used to issue the serving certificate if enabled
+	stateManager.SetState(CertificateKind, resources[certificate])
+
+	// autoscaling/v2, used to create the HorizontalPodAutoscaler if enabled
+	stateManager.SetState(HorizontalPodAutoscalerKind, resources[hpa])
 }
 
 // resourcesExist is a multi-resource version of k8sutil.ResourceExists, to reduce strain on the Kubernetes API when
@@ -88,12 +105,21 @@ func resourcesExist(dc discovery.DiscoveryInterface, resources []schema.GroupVer
 	}
 
 	for _, apiList := range apiLists {
+		// The API server only sets the group and version of a resource if it differs from the list
+		groupVersion, err := schema.ParseGroupVersion(apiList.GroupVersion)
+		if err != nil {
+			continue
+		}
 		for _, r := range apiList.APIResources {
 			gvk := schema.GroupVersionKind{
 				Group:   r.Group,
 				Version: r.Version,
 				Kind:    r.Kind,
 			}
+			if gvk.Group == "" && gvk.Version == "" {
+				gvk.Group = groupVersion.Group
+				gvk.Version = groupVersion.Version
+			}
 
 			if _, ok := res[gvk]; ok {
 				res[gvk] = true
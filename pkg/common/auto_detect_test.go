@@ -0,0 +1,72 @@
+package common
+
+import (
+	"testing"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestAutoDetect_ResourcesExist_GroupVersionOfList(t *testing.T) {
+	// given
+	dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{
+		Resources: []*metav1.APIResourceList{
+			{
+				// Discovery only returns the kind of a resource, the group and version are set on the list
+				GroupVersion: "route.openshift.io/v1",
+				APIResources: []metav1.APIResource{{Name: "routes", Kind: RouteKind}},
+			},
+			{
+				GroupVersion: "policy/v1",
+				APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets", Kind: "PodDisruptionBudget"}},
+			},
+		},
+	}}
+
+	route := routev1.SchemeGroupVersion.WithKind(RouteKind)
+	pdb := model.PodDisruptionBudgetGroupVersionKind
+	pdbV1beta1 := model.PodDisruptionBudgetV1beta1GroupVersionKind
+	serviceMonitor := monitoringv1.SchemeGroupVersion.WithKind(monitoringv1.ServiceMonitorsKind)
+
+	// when
+	resources, err := resourcesExist(dc, []schema.GroupVersionKind{route, pdb, pdbV1beta1, serviceMonitor})
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, resources[route])
+	assert.True(t, resources[pdb])
+	assert.False(t, resources[pdbV1beta1])
+	assert.False(t, resources[serviceMonitor])
+}
+
+func TestAutoDetect_ResourcesExist_GroupVersionOfResource(t *testing.T) {
+	// given
+	dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{
+		Resources: []*metav1.APIResourceList{
+			{
+				// Subresources may belong to another group and version than the list
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments/scale", Group: "autoscaling", Version: "v1", Kind: "Scale"},
+				},
+			},
+		},
+	}}
+
+	scale := schema.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "Scale"}
+	appsScale := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Scale"}
+
+	// when
+	resources, err := resourcesExist(dc, []schema.GroupVersionKind{scale, appsScale})
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, resources[scale])
+	assert.False(t, resources[appsScale])
+}
@@ -5,6 +5,8 @@ import (
 
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
+	v13 "k8s.io/api/apps/v1"
 	v12 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	v1 "k8s.io/api/core/v1"
@@ -17,6 +19,13 @@ type BackupState struct {
 	LocalPersistentVolumeClaim *v1.PersistentVolumeClaim
 	AwsJob                     *v12.Job
 	AwsPeriodicJob             *v1beta1.CronJob
+	LocalPeriodicJob           *v1beta1.CronJob
+	LocalBackupJobs            []v12.Job
+	LocalBackupPods            []v1.Pod
+	RestoreJob                 *v12.Job
+	KeycloakDeployment         *v13.StatefulSet
+	VerificationJobs           []v12.Job
+	VerificationPods           []v1.Pod
 	Keycloak                   *kc.Keycloak
 }
 
@@ -31,6 +40,15 @@ func NewClusterState() *ClusterState {
 }
 
 func (i *BackupState) Read(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	context, span := tracing.StartSpan(context, "BackupState.Read", tracing.SpanKindInternal)
+	defer span.End()
+
+	err := i.read(context, cr, controllerClient)
+	span.RecordError(err)
+	return err
+}
+
+func (i *BackupState) read(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
 	err := i.readLocalBackupJob(context, cr, controllerClient)
 	if err != nil {
 		return err
@@ -51,16 +69,41 @@ func (i *BackupState) Read(context context.Context, cr *kc.KeycloakBackup, contr
 		return err
 	}
 
+	err = i.readLocalPeriodicBackupJob(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readLocalBackupHistory(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readRestoreJob(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readVerifications(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readKeycloakDeployment(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
 	return err
 }
 
 func (i *BackupState) readLocalBackupJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
 	// decide Job type first
-	if cr.Spec.AWS.CredentialsSecretName != "" {
+	if cr.Spec.AWS.CredentialsSecretName != "" || cr.Spec.Schedule != "" {
 		return nil
 	}
 
-	localBackupJob := model.PostgresqlBackup(cr)
+	localBackupJob := model.PostgresqlBackup(cr, i.Keycloak)
 	localBackupJobSelector := model.PostgresqlBackupSelector(cr)
 
 	err := controllerClient.Get(context, localBackupJobSelector, localBackupJob)
@@ -96,7 +139,7 @@ func (i *BackupState) readAwsBackupJob(context context.Context, cr *kc.KeycloakB
 		return nil
 	}
 
-	awsBackupJob := model.PostgresqlAWSBackup(cr)
+	awsBackupJob := model.PostgresqlAWSBackup(cr, i.Keycloak)
 	awsBackupJobSelector := model.PostgresqlAWSBackupSelector(cr)
 
 	err := controllerClient.Get(context, awsBackupJobSelector, awsBackupJob)
@@ -112,7 +155,11 @@ func (i *BackupState) readAwsBackupJob(context context.Context, cr *kc.KeycloakB
 }
 
 func (i *BackupState) readAwsPeriodicBackupJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
-	awsPeriodicBackupJob := model.PostgresqlAWSPeriodicBackup(cr)
+	if cr.Spec.AWS.CredentialsSecretName == "" {
+		return nil
+	}
+
+	awsPeriodicBackupJob := model.PostgresqlAWSPeriodicBackup(cr, i.Keycloak)
 	awsPeriodicBackupJobSelector := model.PostgresqlAWSPeriodicBackupSelector(cr)
 
 	err := controllerClient.Get(context, awsPeriodicBackupJobSelector, awsPeriodicBackupJob)
@@ -127,13 +174,179 @@ func (i *BackupState) readAwsPeriodicBackupJob(context context.Context, cr *kc.K
 	return nil
 }
 
+func (i *BackupState) readLocalPeriodicBackupJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if cr.Spec.AWS.CredentialsSecretName != "" || cr.Spec.Schedule == "" {
+		return nil
+	}
+
+	localPeriodicBackupJob := model.PostgresqlPeriodicBackup(cr, i.Keycloak)
+	localPeriodicBackupJobSelector := model.PostgresqlPeriodicBackupSelector(cr)
+
+	err := controllerClient.Get(context, localPeriodicBackupJobSelector, localPeriodicBackupJob)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.LocalPeriodicJob = localPeriodicBackupJob
+		cr.UpdateStatusSecondaryResources(i.LocalPeriodicJob.Kind, i.LocalPeriodicJob.Name)
+	}
+	return nil
+}
+
+// The Jobs and pods of the local backups, both of the single Job and the ones created by the CronJob
+func (i *BackupState) readLocalBackupHistory(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if cr.Spec.AWS.CredentialsSecretName != "" {
+		return nil
+	}
+
+	opts := []client.ListOption{
+		client.InNamespace(cr.Namespace),
+		client.MatchingLabels(model.PostgresqlBackupLabels(cr)),
+	}
+
+	jobs := &v12.JobList{}
+	err := controllerClient.List(context, jobs, opts...)
+	if err != nil {
+		return err
+	}
+	i.LocalBackupJobs = jobs.Items
+
+	pods := &v1.PodList{}
+	err = controllerClient.List(context, pods, opts...)
+	if err != nil {
+		return err
+	}
+	i.LocalBackupPods = pods.Items
+	return nil
+}
+
+func (i *BackupState) readRestoreJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if !cr.IsRestoreRequested() || cr.Spec.AWS.CredentialsSecretName != "" {
+		return nil
+	}
+
+	restoreJob := model.PostgresqlRestore(cr, i.Keycloak, "")
+	restoreJobSelector := model.PostgresqlRestoreSelector(cr)
+
+	err := controllerClient.Get(context, restoreJobSelector, restoreJob)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.RestoreJob = restoreJob
+		cr.UpdateStatusSecondaryResources(i.RestoreJob.Kind, i.RestoreJob.Name)
+	}
+	return nil
+}
+
+// The StatefulSet of Keycloak, database restores wait for it to be scaled down
+func (i *BackupState) readKeycloakDeployment(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if !cr.IsRestoreRequested() || cr.Spec.Type == kc.RealmsBackupType {
+		return nil
+	}
+
+	keycloakDeployment := &v13.StatefulSet{}
+	err := controllerClient.Get(context, model.KeycloakDeploymentSelector(i.Keycloak), keycloakDeployment)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.KeycloakDeployment = keycloakDeployment
+	}
+	return nil
+}
+
+func (i *BackupState) readVerifications(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if !cr.Spec.Verify || cr.Spec.AWS.CredentialsSecretName != "" {
+		return nil
+	}
+
+	opts := []client.ListOption{
+		client.InNamespace(cr.Namespace),
+		client.MatchingLabels(model.PostgresqlVerificationLabels(cr)),
+	}
+
+	jobs := &v12.JobList{}
+	err := controllerClient.List(context, jobs, opts...)
+	if err != nil {
+		return err
+	}
+	i.VerificationJobs = jobs.Items
+
+	pods := &v1.PodList{}
+	err = controllerClient.List(context, pods, opts...)
+	if err != nil {
+		return err
+	}
+	i.VerificationPods = pods.Items
+	return nil
+}
+
+// History returns the backup history of the CR including the results of the verifications
+func (i *BackupState) History(cr *kc.KeycloakBackup) []kc.KeycloakBackupHistoryEntry {
+	history := model.PostgresqlBackupHistory(cr, i.LocalBackupJobs, i.LocalBackupPods)
+	return model.PostgresqlBackupVerification(history, i.VerificationJobs, i.VerificationPods)
+}
+
+// HasVerification returns true if the Job verifying the backup of the history entry exists
+func (i *BackupState) HasVerification(entry *kc.KeycloakBackupHistoryEntry) bool {
+	for _, job := range i.VerificationJobs {
+		if job.Name == model.PostgresqlVerificationName(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRestored returns true if the restore Job of the restoreGeneration succeeded
+func (i *BackupState) IsRestored() (bool, error) {
+	return IsJobReady(i.RestoreJob)
+}
+
+// IsRestoreFailed returns true if the restore Job of the restoreGeneration failed
+func (i *BackupState) IsRestoreFailed() bool {
+	return IsJobFailed(i.RestoreJob)
+}
+
+// RestoreFile returns the file to restore, empty if there is no succeeded backup yet
+func (i *BackupState) RestoreFile(cr *kc.KeycloakBackup) string {
+	return model.PostgresqlRestoreFile(cr, i.History(cr))
+}
+
+// RestoreWaitReason returns why the restore Job can't be created yet, empty if it can. The restore waits for the
+// backups of the CR to succeed, so it neither restores a file being written nor overwrites the database while it is
+// dumped, and database restores wait for Keycloak to be scaled down.
+func (i *BackupState) RestoreWaitReason(cr *kc.KeycloakBackup) string {
+	if cr.Spec.Schedule == "" {
+		if ready, _ := IsJobReady(i.LocalPersistentVolumeJob); !ready {
+			return "restore is waiting for the backup to succeed"
+		}
+	}
+	for _, job := range i.LocalBackupJobs {
+		if ready, _ := IsJobReady(&job); !ready && !IsJobFailed(&job) {
+			return "restore is waiting for the running backup to finish"
+		}
+	}
+	if i.RestoreFile(cr) == "" {
+		return "restore is waiting for a succeeded backup"
+	}
+	deployment := i.KeycloakDeployment
+	if deployment != nil && (deployment.Spec.Replicas == nil || *deployment.Spec.Replicas > 0 || deployment.Status.Replicas > 0) {
+		return "restore is waiting for Keycloak to be scaled down to 0 instances"
+	}
+	return ""
+}
+
 func (i *BackupState) IsResourcesReady() (bool, error) {
 	switch {
 	case i.AwsJob != nil:
 		return IsJobReady(i.AwsJob)
 	case i.LocalPersistentVolumeJob != nil:
 		return IsJobReady(i.LocalPersistentVolumeJob)
-	case i.AwsPeriodicJob != nil:
+	case i.AwsPeriodicJob != nil, i.LocalPeriodicJob != nil:
 		// We don't manage readiness check for CronJobs
 		return true, nil
 	default:
@@ -15,6 +15,7 @@ import (
 
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
@@ -36,6 +37,7 @@ type Client struct {
 	URL         string
 	contextRoot string
 	token       string
+	context     context.Context
 }
 
 // T is a generic type for keycloak spec resources
@@ -61,7 +63,7 @@ func (c *Client) create(obj T, resourcePath, resourceName string) (string, error
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	res, err := c.requester.Do(req)
+	res, err := c.do(req)
 
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
@@ -83,6 +85,58 @@ func (c *Client) create(obj T, resourcePath, resourceName string) (string, error
 	return uid, nil
 }
 
+// Perform a request against the Keycloak api, trace it and record its latency
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.StartSpan(c.context, fmt.Sprintf("%s %s", req.Method, KeycloakAPIPathTemplate(req.URL.Path)), tracing.SpanKindClient)
+	defer span.End()
+
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.route", KeycloakAPIPathTemplate(req.URL.Path))
+	span.SetAttribute("keycloak.realm", keycloakAPIRealm(req.URL.Path))
+	tracing.Inject(ctx, req.Header)
+
+	// Requests are canceled with the context of the reconciliation, e.g. while waiting for a free request slot
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+
+	start := time.Now()
+	res, err := c.requester.Do(req)
+	ObserveKeycloakAPIRequest(req, res, start)
+
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		span.SetAttribute("http.status_code", res.StatusCode)
+		if res.StatusCode >= 400 {
+			span.RecordError(errors.Errorf("%s", res.Status))
+		}
+	}
+	return res, err
+}
+
+// Returns the realm an admin API path refers to
+func keycloakAPIRealm(path string) string {
+	segments := strings.Split(path, "/")
+	for index := 0; index < len(segments)-1; index++ {
+		if segments[index] == "realms" {
+			return segments[index+1]
+		}
+	}
+	return ""
+}
+
+// KeycloakClientWithContext returns a copy of the client that attaches its requests to the trace of the given context
+func KeycloakClientWithContext(keycloakClient KeycloakInterface, ctx context.Context) KeycloakInterface {
+	c, ok := keycloakClient.(*Client)
+	if !ok {
+		return keycloakClient
+	}
+	withContext := *c
+	withContext.context = ctx
+	return &withContext
+}
+
 func (c *Client) Endpoint() string {
 	return c.URL
 }
@@ -248,7 +302,7 @@ func (c *Client) get(resourcePath, resourceName string, unMarshalFunc func(body
 	}
 
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	res, err := c.requester.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return nil, errors.Wrapf(err, "error performing GET %s request", resourceName)
@@ -405,7 +459,7 @@ func (c *Client) update(obj T, resourcePath, resourceName string) error {
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Add("Authorization", "Bearer "+c.token)
-	res, err := c.requester.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return errors.Wrapf(err, "error performing UPDATE %s request", resourceName)
@@ -420,11 +474,16 @@ func (c *Client) update(obj T, resourcePath, resourceName string) error {
 }
 
 func (c *Client) UpdateRealm(realm *v1alpha1.KeycloakRealm) error {
-	return c.update(realm, fmt.Sprintf("realms/%s", realm.Spec.Realm.ID), "realm")
+	err := c.update(realm, fmt.Sprintf("realms/%s", realm.Spec.Realm.ID), "realm")
+	InvalidateRealmCache(c, realm.Spec.Realm.Realm)
+	return err
 }
 
 func (c *Client) UpdateClient(specClient *v1alpha1.KeycloakAPIClient, realmName string) error {
-	return c.update(specClient, fmt.Sprintf("realms/%s/clients/%s", realmName, specClient.ID), "client")
+	err := c.update(specClient, fmt.Sprintf("realms/%s/clients/%s", realmName, specClient.ID), "client")
+	// The cached clients of the realm are outdated
+	InvalidateRealmCache(c, realmName)
+	return err
 }
 
 func (c *Client) UpdateClientRole(clientID string, role, oldRole *v1alpha1.RoleRepresentation, realmName string) error {
@@ -481,7 +540,7 @@ func (c *Client) delete(resourcePath, resourceName string, obj T) error {
 	}
 
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	res, err := c.requester.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return errors.Wrapf(err, "error performing DELETE %s request", resourceName)
@@ -560,7 +619,7 @@ func (c *Client) list(resourcePath, resourceName string, unMarshalListFunc func(
 	}
 
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	res, err := c.requester.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return nil, errors.Wrapf(err, "error performing LIST %s request", resourceName)
@@ -814,17 +873,17 @@ func (c *Client) Ping() error {
 		return errors.Wrap(err, "error creating ping request")
 	}
 
-	res, err := c.requester.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return errors.Wrapf(err, "error performing ping request")
 	}
+	defer res.Body.Close()
 
 	logrus.Debugf("response status: %v, %v", res.StatusCode, res.Status)
 	if res.StatusCode != 200 {
 		return errors.Errorf("failed to ping, response status code: %v", res.StatusCode)
 	}
-	defer res.Body.Close()
 
 	return nil
 }
@@ -863,7 +922,7 @@ func (c *Client) login(user, pass string) error {
 	}
 
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	res, err := c.requester.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return errors.Wrap(err, "error performing token request")
@@ -1016,6 +1075,11 @@ type LocalConfigKeycloakFactory struct {
 
 // AuthenticatedClient returns an authenticated client for requesting endpoints from the Keycloak api
 func (i *LocalConfigKeycloakFactory) AuthenticatedClient(kc v1alpha1.Keycloak, insecureSsl bool) (KeycloakInterface, error) {
+	return i.AuthenticatedClientWithContext(context.Background(), kc, insecureSsl)
+}
+
+// AuthenticatedClientWithContext returns an authenticated client whose requests are traced as part of the given context
+func (i *LocalConfigKeycloakFactory) AuthenticatedClientWithContext(ctx context.Context, kc v1alpha1.Keycloak, insecureSsl bool) (KeycloakInterface, error) {
 	config, err := config2.GetConfig()
 	if err != nil {
 		return nil, err
@@ -1065,20 +1129,27 @@ func (i *LocalConfigKeycloakFactory) AuthenticatedClient(kc v1alpha1.Keycloak, i
 
 	client := &Client{
 		URL:         kcURL,
-		requester:   requester,
+		requester:   newLimitedRequester(requester, kc),
 		contextRoot: contextRoot,
+		context:     ctx,
 	}
 	if err := client.login(user, pass); err != nil {
+		RecordKeycloakLoginFailure()
 		return nil, err
 	}
 	return client, nil
 }
 
 func getKCServerCert(secretClient *kubernetes.Clientset, kc v1alpha1.Keycloak) ([]byte, error) {
-	sslCertsSecret, err := secretClient.CoreV1().Secrets(kc.Namespace).Get(context.TODO(), model.ServingCertSecretName, v12.GetOptions{})
+	sslCertsSecret, err := secretClient.CoreV1().Secrets(kc.Namespace).Get(context.TODO(), model.ServingCertSecretName(&kc), v12.GetOptions{})
 	switch {
 	case err == nil:
-		return sslCertsSecret.Data["tls.crt"], nil
+		// Certificates issued by cert-manager come with the CA, which stays valid across rotations
+		serverCert := sslCertsSecret.Data[model.ServingCertKey]
+		if ca := sslCertsSecret.Data[model.ServingCertCAKey]; len(ca) > 0 {
+			serverCert = append(append(append([]byte{}, serverCert...), '\n'), ca...)
+		}
+		return serverCert, nil
 	case k8sErrors.IsNotFound(err):
 		return nil, nil
 	default:
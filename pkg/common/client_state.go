@@ -5,6 +5,8 @@ import (
 
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
+	"golang.org/x/sync/errgroup"
 	v1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -36,6 +38,17 @@ func NewClientState(context context.Context, realm *kc.KeycloakRealm, keycloak k
 }
 
 func (i *ClientState) Read(context context.Context, cr *kc.KeycloakClient, realmClient KeycloakInterface, controllerClient client.Client) error {
+	context, span := tracing.StartSpan(context, "ClientState.Read", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("keycloak.realm", i.Realm.Spec.Realm.Realm)
+	span.SetAttribute("keycloak.client_id", cr.Spec.Client.ClientID)
+
+	err := i.read(context, cr, KeycloakClientWithContext(realmClient, context), controllerClient)
+	span.RecordError(err)
+	return err
+}
+
+func (i *ClientState) read(context context.Context, cr *kc.KeycloakClient, realmClient KeycloakInterface, controllerClient client.Client) error {
 	if cr.Spec.Client.ID == "" {
 		return nil
 	}
@@ -76,61 +89,64 @@ func (i *ClientState) Read(context context.Context, cr *kc.KeycloakClient, realm
 		return nil
 	}
 
-	i.Roles, err = realmClient.ListClientRoles(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
-	if err != nil {
-		return err
-	}
-
-	i.ScopeMappings, err = realmClient.ListScopeMappings(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
-	if err != nil {
-		return err
-	}
+	// The remaining reads are independent of each other and every goroutine only sets its own fields
+	group := errgroup.Group{}
+	group.SetLimit(KeycloakAPIConcurrency)
 
-	err = i.readClientScopes(cr, realmClient)
-	if err != nil {
+	group.Go(func() (err error) {
+		i.Roles, err = realmClient.ListClientRoles(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 		return err
-	}
+	})
 
-	err = i.readDefaultRoles(cr, realmClient)
-	if err != nil {
+	group.Go(func() (err error) {
+		i.ScopeMappings, err = realmClient.ListScopeMappings(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 		return err
-	}
+	})
 
-	if i.Client.ServiceAccountsEnabled {
-		user, err := realmClient.GetServiceAccountUser(i.Realm.Spec.Realm.Realm, cr.Spec.Client.ID)
-		if err != nil {
-			return err
-		}
+	i.readClientScopes(&group, cr, realmClient)
 
-		i.ServiceAccountUserState = NewUserState(i.Keycloak)
-		err = i.ServiceAccountUserState.ReadWithExistingAPIUser(realmClient, controllerClient, user, *i.Realm)
-		if err != nil {
-			return err
-		}
-	}
+	group.Go(func() error {
+		return i.readDefaultRoles(cr, realmClient)
+	})
 
-	return nil
+	if i.Client.ServiceAccountsEnabled {
+		group.Go(func() error {
+			user, err := realmClient.GetServiceAccountUser(i.Realm.Spec.Realm.Realm, cr.Spec.Client.ID)
+			if err != nil {
+				return err
+			}
+
+			userState := NewUserState(i.Keycloak)
+			err = userState.ReadWithExistingAPIUser(realmClient, controllerClient, user, *i.Realm)
+			if err != nil {
+				return err
+			}
+			i.ServiceAccountUserState = userState
+			return nil
+		})
+	}
+
+	return group.Wait()
 }
 
-func (i *ClientState) readClientScopes(cr *kc.KeycloakClient, realmClient KeycloakInterface) (err error) {
+func (i *ClientState) readClientScopes(group *errgroup.Group, cr *kc.KeycloakClient, realmClient KeycloakInterface) {
 	// It is not strictly a property of the client but rather of the realm.
 	// However could not figure out a better way to convey it to populate default and optional
 	// client scopes which requires client scope IDs.
-	i.AvailableClientScopes, err = realmClient.ListAvailableClientScopes(i.Realm.Spec.Realm.Realm)
-	if err != nil {
+	group.Go(func() (err error) {
+		i.AvailableClientScopes, err = ListCachedAvailableClientScopes(realmClient, i.Realm.Spec.Realm.Realm)
 		return err
-	}
+	})
 
-	i.DefaultClientScopes, err = realmClient.ListDefaultClientScopes(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
-	if err != nil {
+	group.Go(func() (err error) {
+		i.DefaultClientScopes, err = realmClient.ListDefaultClientScopes(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 		return err
-	}
+	})
 
-	i.OptionalClientScopes, err = realmClient.ListOptionalClientScopes(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
-	if err != nil {
+	group.Go(func() (err error) {
+		i.OptionalClientScopes, err = realmClient.ListOptionalClientScopes(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 		return err
-	}
-	return nil
+	})
 }
 
 func (i *ClientState) readClientSecret(context context.Context, cr *kc.KeycloakClient, clientSpec *kc.KeycloakAPIClient, controllerClient client.Client) error {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 	"github.com/pkg/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -89,7 +90,8 @@ func NewClusterAndKeycloakActionRunner(context context.Context, client client.Cl
 
 func (i *ClusterActionRunner) RunAll(desiredState DesiredClusterState) error {
 	for index, action := range desiredState {
-		msg, err := action.Run(i)
+		msg, err := i.run(action)
+		RecordClusterAction(action, err)
 		if err != nil {
 			log.Info(fmt.Sprintf("(%5d) %10s %s : %s", index, "FAILED", msg, err))
 			return err
@@ -100,6 +102,23 @@ func (i *ClusterActionRunner) RunAll(desiredState DesiredClusterState) error {
 	return nil
 }
 
+// Run a single action in its own span, all requests made by the action are part of that span
+func (i *ClusterActionRunner) run(action ClusterAction) (string, error) {
+	ctx, span := tracing.StartSpan(i.context, clusterActionName(action), tracing.SpanKindInternal)
+	defer span.End()
+
+	runner := *i
+	runner.context = ctx
+	if i.keycloakClient != nil {
+		runner.keycloakClient = KeycloakClientWithContext(i.keycloakClient, ctx)
+	}
+
+	msg, err := action.Run(&runner)
+	span.SetAttribute("action.message", msg)
+	span.RecordError(err)
+	return msg, err
+}
+
 func (i *ClusterActionRunner) Create(obj runtime.Object) error {
 	err := controllerutil.SetControllerReference(i.cr.(v1.Object), obj.(v1.Object), i.scheme)
 	if err != nil {
@@ -134,6 +153,7 @@ func (i *ClusterActionRunner) CreateRealm(obj *v1alpha1.KeycloakRealm) error {
 	}
 
 	_, err := i.keycloakClient.CreateRealm(obj)
+	InvalidateRealmCache(i.keycloakClient, obj.Spec.Realm.Realm)
 	return err
 }
 
@@ -148,6 +168,8 @@ func (i *ClusterActionRunner) CreateClient(obj *v1alpha1.KeycloakClient, realm s
 		return err
 	}
 
+	// The realm now has an additional client
+	InvalidateRealmCache(i.keycloakClient, realm)
 	obj.Spec.Client.ID = uid
 
 	return i.client.Update(i.context, obj)
@@ -157,7 +179,13 @@ func (i *ClusterActionRunner) UpdateClient(obj *v1alpha1.KeycloakClient, realm s
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client update when client is nil")
 	}
-	return i.keycloakClient.UpdateClient(obj.Spec.Client, realm)
+	err := i.keycloakClient.UpdateClient(obj.Spec.Client, realm)
+	if err != nil {
+		return err
+	}
+
+	RecordKeycloakWrite(WriteResourceClient, WriteResultApplied)
+	return nil
 }
 
 func (i *ClusterActionRunner) CreateClientRole(obj *v1alpha1.KeycloakClient, role *v1alpha1.RoleRepresentation, realm string) error {
@@ -243,6 +271,7 @@ func (i *ClusterActionRunner) DeleteRealm(obj *v1alpha1.KeycloakRealm) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform realm delete when client is nil")
 	}
+	InvalidateRealmCache(i.keycloakClient, obj.Spec.Realm.Realm)
 	return i.keycloakClient.DeleteRealm(obj.Spec.Realm.Realm)
 }
 
@@ -250,6 +279,7 @@ func (i *ClusterActionRunner) DeleteClient(obj *v1alpha1.KeycloakClient, realm s
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client delete when client is nil")
 	}
+	InvalidateRealmCache(i.keycloakClient, realm)
 	return i.keycloakClient.DeleteClient(obj.Spec.Client.ID, realm)
 }
 
@@ -279,6 +309,7 @@ func (i *ClusterActionRunner) UpdateUser(obj *v1alpha1.KeycloakUser, realm strin
 		return err
 	}
 
+	RecordKeycloakWrite(WriteResourceUser, WriteResultApplied)
 	return nil
 }
 
@@ -554,15 +585,19 @@ type PingAction struct {
 }
 
 type CreateUserAction struct {
-	Ref   *v1alpha1.KeycloakUser
-	Realm string
-	Msg   string
+	Ref               *v1alpha1.KeycloakUser
+	Realm             string
+	CredentialsTarget string
+	CredentialsHash   string
+	Msg               string
 }
 
 type UpdateUserAction struct {
-	Ref   *v1alpha1.KeycloakUser
-	Realm string
-	Msg   string
+	Ref               *v1alpha1.KeycloakUser
+	Realm             string
+	CredentialsTarget string
+	CredentialsHash   string
+	Msg               string
 }
 
 type DeleteUserAction struct {
@@ -694,11 +729,31 @@ func (i ConfigureRealmAction) Run(runner ActionRunner) (string, error) {
 }
 
 func (i CreateUserAction) Run(runner ActionRunner) (string, error) {
-	return i.Msg, runner.CreateUser(i.Ref, i.Realm)
+	err := runner.CreateUser(i.Ref, i.Realm)
+	if err == nil {
+		recordUserCredentials(i.Ref, i.CredentialsTarget, i.CredentialsHash)
+	}
+	return i.Msg, err
 }
 
 func (i UpdateUserAction) Run(runner ActionRunner) (string, error) {
-	return i.Msg, runner.UpdateUser(i.Ref, i.Realm)
+	err := runner.UpdateUser(i.Ref, i.Realm)
+	if err == nil {
+		recordUserCredentials(i.Ref, i.CredentialsTarget, i.CredentialsHash)
+	}
+	return i.Msg, err
+}
+
+// Remember the credentials applied to the realm of a Keycloak instance, they are persisted with the status
+func recordUserCredentials(obj *v1alpha1.KeycloakUser, target, hash string) {
+	if hash == "" {
+		delete(obj.Status.CredentialsHashes, target)
+		return
+	}
+	if obj.Status.CredentialsHashes == nil {
+		obj.Status.CredentialsHashes = map[string]string{}
+	}
+	obj.Status.CredentialsHashes[target] = hash
 }
 
 func (i DeleteUserAction) Run(runner ActionRunner) (string, error) {
@@ -2,9 +2,6 @@ package common
 
 import (
 	"context"
-	"time"
-
-	v1beta12 "k8s.io/api/policy/v1beta1"
 
 	v13 "github.com/openshift/api/route/v1"
 	v14 "k8s.io/api/networking/v1"
@@ -13,21 +10,17 @@ import (
 	grafanav1alpha1 "github.com/integr8ly/grafana-operator/v3/pkg/apis/integreatly/v1alpha1"
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 	v12 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// BackupTime is used for generating a unique Backup job name
-var BackupTime string
-
-func init() {
-	BackupTime = time.Now().Format("20060102-150405")
-}
-
 // The desired cluster state is defined by a list of actions that have to be run to
 // get from the current state to the desired state
 type DesiredClusterState []ClusterAction
@@ -59,17 +52,37 @@ type ClusterState struct {
 	KeycloakDiscoveryService        *v1.Service
 	KeycloakMonitoringService       *v1.Service
 	KeycloakDeployment              *v12.StatefulSet
+	KeycloakPods                    []v1.Pod
 	KeycloakAdminSecret             *v1.Secret
 	KeycloakIngress                 *v14.Ingress
 	KeycloakRoute                   *v13.Route
 	KeycloakMetricsRoute            *v13.Route
+	KeycloakHTTPRoute               *unstructured.Unstructured
+	KeycloakTLSRoute                *unstructured.Unstructured
+	KeycloakCertificate             *unstructured.Unstructured
+	KeycloakServingCert             *v1.Secret
+	KeycloakReferencedSecrets       []v1.Secret
+	KeycloakHorizontalPodAutoscaler *unstructured.Unstructured
+	KeycloakReferencedConfigMaps    []v1.ConfigMap
 	PostgresqlServiceEndpoints      *v1.Endpoints
-	PodDisruptionBudget             *v1beta12.PodDisruptionBudget
+	PodDisruptionBudget             *unstructured.Unstructured
+	PostgresqlPodDisruptionBudget   *unstructured.Unstructured
 	KeycloakProbes                  *v1.ConfigMap
 	KeycloakBackup                  *kc.KeycloakBackup
+	PostgresqlUpgradeBackup         *kc.KeycloakBackup
+	PostgresqlUpgradeJob            *batchv1.Job
 }
 
 func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	context, span := tracing.StartSpan(context, "ClusterState.Read", tracing.SpanKindInternal)
+	defer span.End()
+
+	err := i.read(context, cr, controllerClient)
+	span.RecordError(err)
+	return err
+}
+
+func (i *ClusterState) read(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
 	stateManager := GetStateManager()
 	routeKindExists, routeKeyExists := stateManager.GetState(RouteKind).(bool)
 	podDisruptionBudgetKindExists, podDisruptionBudgetKeyExists := stateManager.GetState(PodDisruptionBudgetKind).(bool)
@@ -149,6 +162,28 @@ func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controller
 		return err
 	}
 
+	err = i.readKeycloakPodsCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readKeycloakReferencedConfigCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	if cr.Spec.CertManager.Enabled {
+		err = i.readKeycloakCertificateCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = i.readKeycloakHorizontalPodAutoscalerCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
 	if podDisruptionBudgetKeyExists && podDisruptionBudgetKindExists {
 		err = i.readPodDisruptionCurrentState(context, cr, controllerClient)
 		if err != nil {
@@ -156,16 +191,22 @@ func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controller
 		}
 	}
 
+	// The resources of all external access modes are read, those of the modes not in use are removed
+	err = i.readKeycloakGatewayRoutesCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readKeycloakIngressCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
 	if routeKeyExists && routeKindExists {
 		err = i.readKeycloakRouteCurrentState(context, cr, controllerClient)
 		if err != nil {
 			return err
 		}
-	} else {
-		err = i.readKeycloakIngressCurrentState(context, cr, controllerClient)
-		if err != nil {
-			return err
-		}
 	}
 
 	if i.KeycloakRoute != nil {
@@ -180,6 +221,13 @@ func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controller
 		return err
 	}
 
+	if cr.Status.Postgres != nil && cr.Status.Postgres.Upgrade != nil {
+		err = i.readPostgresqlUpgradeCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Read other things
 	return nil
 }
@@ -362,7 +410,7 @@ func (i *ClusterState) readKeycloakGrafanaDashboardCurrentState(context context.
 func (i *ClusterState) readDatabaseSSLSecretCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
 	databaseSSLSecret := &v1.Secret{}
 	databaseSSLSecretSelector := client.ObjectKey{
-		Name:      model.DatabaseSecretSslCert,
+		Name:      model.DatabaseSecretSslCert(cr),
 		Namespace: cr.Namespace,
 	}
 
@@ -444,6 +492,21 @@ func (i *ClusterState) readKeycloakOrRHSSODeploymentCurrentState(context context
 	return nil
 }
 
+// The pods are read to resolve the digest of the image they run
+func (i *ClusterState) readKeycloakPodsCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	if i.KeycloakDeployment == nil {
+		return nil
+	}
+
+	pods := &v1.PodList{}
+	err := controllerClient.List(context, pods, client.InNamespace(cr.Namespace), client.MatchingLabels(model.GetLabelsSelector(cr)))
+	if err != nil {
+		return err
+	}
+	i.KeycloakPods = pods.Items
+	return nil
+}
+
 func (i *ClusterState) readKeycloakDiscoveryServiceCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
 	keycloakDiscoveryService := model.KeycloakDiscoveryService(cr)
 	keycloakDiscoveryServiceSelector := model.KeycloakDiscoveryServiceSelector(cr)
@@ -524,8 +587,120 @@ func (i *ClusterState) readKeycloakIngressCurrentState(context context.Context,
 	return nil
 }
 
+// Reads the cert-manager Certificate and the serving certificate secret it issues. The hash of the secret
+// is used to restart Keycloak after a rotation.
+func (i *ClusterState) readKeycloakCertificateCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	if certificateKindExists, _ := GetStateManager().GetState(CertificateKind).(bool); certificateKindExists {
+		certificate := model.KeycloakCertificate(cr)
+		err := controllerClient.Get(context, model.KeycloakCertificateSelector(cr), certificate)
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+		} else {
+			i.KeycloakCertificate = certificate.DeepCopy()
+			cr.UpdateStatusSecondaryResources(CertificateKind, i.KeycloakCertificate.GetName())
+		}
+	}
+
+	servingCert := model.ServingCertSecret(cr)
+	err := controllerClient.Get(context, model.ServingCertSecretSelector(cr), servingCert)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.KeycloakServingCert = servingCert.DeepCopy()
+	}
+	return nil
+}
+
+// Reads the Secrets and ConfigMaps used by the Keycloak pods. The hash of their content is used to restart Keycloak
+// after a change.
+func (i *ClusterState) readKeycloakReferencedConfigCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	i.KeycloakReferencedSecrets = []v1.Secret{}
+	for _, name := range model.KeycloakReferencedSecretNames(cr) {
+		secret := &v1.Secret{}
+		err := controllerClient.Get(context, client.ObjectKey{Name: name, Namespace: cr.Namespace}, secret)
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		i.KeycloakReferencedSecrets = append(i.KeycloakReferencedSecrets, *secret)
+	}
+
+	i.KeycloakReferencedConfigMaps = []v1.ConfigMap{}
+	for _, name := range model.KeycloakReferencedConfigMapNames(cr) {
+		configMap := &v1.ConfigMap{}
+		err := controllerClient.Get(context, client.ObjectKey{Name: name, Namespace: cr.Namespace}, configMap)
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		i.KeycloakReferencedConfigMaps = append(i.KeycloakReferencedConfigMaps, *configMap)
+	}
+	return nil
+}
+
+// Reads the HorizontalPodAutoscaler even if autoscaling is disabled, so that it can be removed
+func (i *ClusterState) readKeycloakHorizontalPodAutoscalerCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	if hpaKindExists, _ := GetStateManager().GetState(HorizontalPodAutoscalerKind).(bool); !hpaKindExists {
+		return nil
+	}
+
+	hpa := model.KeycloakHorizontalPodAutoscaler(cr)
+	err := controllerClient.Get(context, model.KeycloakHorizontalPodAutoscalerSelector(cr), hpa)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.KeycloakHorizontalPodAutoscaler = hpa.DeepCopy()
+		cr.UpdateStatusSecondaryResources(HorizontalPodAutoscalerKind, i.KeycloakHorizontalPodAutoscaler.GetName())
+	}
+	return nil
+}
+
+// Reads the HTTPRoute and the TLSRoute, so that the one no longer matching the TLS termination can be removed
+func (i *ClusterState) readKeycloakGatewayRoutesCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	stateManager := GetStateManager()
+
+	if httpRouteKindExists, _ := stateManager.GetState(HTTPRouteKind).(bool); httpRouteKindExists {
+		httpRoute := model.KeycloakHTTPRoute(cr)
+		err := controllerClient.Get(context, model.KeycloakHTTPRouteSelector(cr), httpRoute)
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+		} else {
+			i.KeycloakHTTPRoute = httpRoute.DeepCopy()
+			cr.UpdateStatusSecondaryResources(HTTPRouteKind, i.KeycloakHTTPRoute.GetName())
+		}
+	}
+
+	if tlsRouteKindExists, _ := stateManager.GetState(TLSRouteKind).(bool); tlsRouteKindExists {
+		tlsRoute := model.KeycloakTLSRoute(cr)
+		err := controllerClient.Get(context, model.KeycloakTLSRouteSelector(cr), tlsRoute)
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+		} else {
+			i.KeycloakTLSRoute = tlsRoute.DeepCopy()
+			cr.UpdateStatusSecondaryResources(TLSRouteKind, i.KeycloakTLSRoute.GetName())
+		}
+	}
+	return nil
+}
+
 func (i *ClusterState) readPodDisruptionCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
-	pdb := model.PodDisruptionBudget(cr)
+	groupVersionKind := GetPodDisruptionBudgetGroupVersionKind()
+
+	pdb := model.PodDisruptionBudget(cr, groupVersionKind)
 	pdbSelector := model.PodDisruptionBudgetSelector(cr)
 
 	err := controllerClient.Get(context, pdbSelector, pdb)
@@ -536,7 +711,20 @@ func (i *ClusterState) readPodDisruptionCurrentState(context context.Context, cr
 	} else {
 		i.PodDisruptionBudget = pdb.DeepCopy()
 		if cr.Spec.PodDisruptionBudget.Enabled {
-			cr.UpdateStatusSecondaryResources(i.PodDisruptionBudget.Kind, i.PodDisruptionBudget.Name)
+			cr.UpdateStatusSecondaryResources(i.PodDisruptionBudget.GetKind(), i.PodDisruptionBudget.GetName())
+		}
+	}
+
+	postgresqlPDB := model.PostgresqlPodDisruptionBudget(cr, groupVersionKind)
+	err = controllerClient.Get(context, model.PostgresqlPodDisruptionBudgetSelector(cr), postgresqlPDB)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.PostgresqlPodDisruptionBudget = postgresqlPDB.DeepCopy()
+		if cr.Spec.PostgresDeploymentSpec.PodDisruptionBudget.Enabled {
+			cr.UpdateStatusSecondaryResources(i.PostgresqlPodDisruptionBudget.GetKind(), i.PostgresqlPodDisruptionBudget.GetName())
 		}
 	}
 	return nil
@@ -564,7 +752,8 @@ func (i *ClusterState) IsResourcesReady(cr *kc.Keycloak) (bool, error) {
 	}
 
 	// If running on OpenShift, check the Route is ready
-	if cr.Spec.ExternalAccess.Enabled {
+	mode := cr.Spec.ExternalAccess.Mode
+	if cr.Spec.ExternalAccess.Enabled && (mode == kc.DefaultExternalAccessMode || mode == kc.RouteExternalAccessMode) {
 		stateManager := GetStateManager()
 		openshift, keyExists := stateManager.GetState(RouteKind).(bool)
 		if keyExists && openshift {
@@ -577,12 +766,16 @@ func (i *ClusterState) IsResourcesReady(cr *kc.Keycloak) (bool, error) {
 
 // Read Custom Resource KeycloakBackup for migration backup
 func (i *ClusterState) readKeycloakBackupCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	if MigrationBackupName(cr) == "" {
+		return nil
+	}
+
 	labelSelect := metav1.LabelSelector{
 		MatchLabels: cr.Labels,
 	}
 	backupCr := &kc.KeycloakBackup{}
 	backupCr.Namespace = cr.Namespace
-	backupCr.Name = model.MigrateBackupName + "-" + BackupTime
+	backupCr.Name = MigrationBackupName(cr)
 	backupCr.Spec.InstanceSelector = &labelSelect
 	backupCr.Spec.StorageClassName = cr.Spec.StorageClassName
 
@@ -599,3 +792,37 @@ func (i *ClusterState) readKeycloakBackupCurrentState(context context.Context, c
 	}
 	return nil
 }
+
+// Reads the backup and the Job of the upgrade of PostgreSQL in the status
+func (i *ClusterState) readPostgresqlUpgradeCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	backup := &kc.KeycloakBackup{}
+	err := controllerClient.Get(context, client.ObjectKey{Name: cr.Status.Postgres.Upgrade.Backup, Namespace: cr.Namespace}, backup)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.PostgresqlUpgradeBackup = backup.DeepCopy()
+	}
+
+	job := model.PostgresqlUpgrade(cr)
+	err = controllerClient.Get(context, model.PostgresqlUpgradeSelector(cr), job)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.PostgresqlUpgradeJob = job.DeepCopy()
+	}
+	return nil
+}
+
+// MigrationBackupName returns the name of the backup taken before migrating to the desired image, as recorded in the
+// status when the migration was planned. Empty if no backup was planned for a migration to the desired image.
+func MigrationBackupName(cr *kc.Keycloak) string {
+	migration := cr.Status.Migration
+	if migration != nil && migration.ToImage == model.Profiles.GetKeycloakOrRHSSOImage(cr) {
+		return migration.Backup
+	}
+	return ""
+}
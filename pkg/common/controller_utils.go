@@ -15,17 +15,21 @@ import (
 
 // These kinds are not provided by the openshift api
 const (
-	RouteKind                 = "Route"
-	JobKind                   = "Job"
-	CronJobKind               = "CronJob"
-	SecretKind                = "Secret"
-	StatefulSetKind           = "StatefulSet"
-	ServiceKind               = "Service"
-	IngressKind               = "Ingress"
-	DeploymentKind            = "Deployment"
-	PersistentVolumeClaimKind = "PersistentVolumeClaim"
-	PodDisruptionBudgetKind   = "PodDisruptionBudget"
-	OpenShiftAPIServerKind    = "OpenShiftAPIServer"
+	RouteKind                   = "Route"
+	JobKind                     = "Job"
+	CronJobKind                 = "CronJob"
+	SecretKind                  = "Secret"
+	StatefulSetKind             = "StatefulSet"
+	ServiceKind                 = "Service"
+	IngressKind                 = "Ingress"
+	DeploymentKind              = "Deployment"
+	PersistentVolumeClaimKind   = "PersistentVolumeClaim"
+	PodDisruptionBudgetKind     = "PodDisruptionBudget"
+	OpenShiftAPIServerKind      = "OpenShiftAPIServer"
+	HTTPRouteKind               = "HTTPRoute"
+	TLSRouteKind                = "TLSRoute"
+	CertificateKind             = "Certificate"
+	HorizontalPodAutoscalerKind = "HorizontalPodAutoscaler"
 )
 
 func WatchSecondaryResource(c controller.Controller, controllerName string, resourceKind string, objectTypetoWatch runtime.Object, cr runtime.Object) error {
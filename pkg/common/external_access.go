@@ -0,0 +1,32 @@
+package common
+
+import (
+	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+)
+
+// GetExternalAccessMode returns the kind of resource exposing Keycloak. Unless configured, a Route is used on
+// OpenShift and an Ingress otherwise. Instances exposed by an Ingress before OpenShift was detected keep it.
+func GetExternalAccessMode(cr *kc.Keycloak) kc.ExternalAccessMode {
+	if cr.Spec.ExternalAccess.Mode != kc.DefaultExternalAccessMode {
+		return cr.Spec.ExternalAccess.Mode
+	}
+	if cr.Status.LegacyIngress {
+		return kc.IngressExternalAccessMode
+	}
+
+	openshift, _ := GetStateManager().GetState(OpenShiftAPIServerKind).(bool)
+	if openshift {
+		return kc.RouteExternalAccessMode
+	}
+	return kc.IngressExternalAccessMode
+}
+
+// IsGatewayRouteKindAvailable returns true if the Gateway API route used for the TLS termination exists in the cluster
+func IsGatewayRouteKindAvailable(cr *kc.Keycloak) bool {
+	kind := HTTPRouteKind
+	if cr.Spec.ExternalAccess.TLSTermination == kc.PassthroughTLSTerminationType {
+		kind = TLSRouteKind
+	}
+	available, _ := GetStateManager().GetState(kind).(bool)
+	return available
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+)
+
+// Maximum number of concurrent reconciles per controller, configurable through operator flags
+var (
+	KeycloakMaxConcurrentReconciles       = 1
+	KeycloakRealmMaxConcurrentReconciles  = 1
+	KeycloakClientMaxConcurrentReconciles = 1
+	KeycloakUserMaxConcurrentReconciles   = 1
+	KeycloakBackupMaxConcurrentReconciles = 1
+)
+
+// KeyedLock serializes work on the same key while work on different keys proceeds in parallel
+type KeyedLock struct {
+	mutex sync.Mutex
+	locks map[string]*keyedLockEntry
+}
+
+type keyedLockEntry struct {
+	sync.Mutex
+	waiters int
+}
+
+func NewKeyedLock() *KeyedLock {
+	return &KeyedLock{
+		locks: map[string]*keyedLockEntry{},
+	}
+}
+
+// Lock blocks until the key is available and returns the function to release it
+func (l *KeyedLock) Lock(key string) func() {
+	l.mutex.Lock()
+	entry, ok := l.locks[key]
+	if !ok {
+		entry = &keyedLockEntry{}
+		l.locks[key] = entry
+	}
+	entry.waiters++
+	l.mutex.Unlock()
+
+	entry.Lock()
+
+	return func() {
+		entry.Unlock()
+
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		entry.waiters--
+		if entry.waiters == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
+
+var instanceRealmLock = NewKeyedLock()
+
+// LockKeycloakRealm serializes the admin API work of all controllers for a realm of a Keycloak instance
+func LockKeycloakRealm(keycloak v1alpha1.Keycloak, realm string) func() {
+	return instanceRealmLock.Lock(fmt.Sprintf("%v/%v/%v", keycloak.Namespace, keycloak.Name, realm))
+}
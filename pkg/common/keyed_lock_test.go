@@ -0,0 +1,63 @@
+package common
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedLock_SerializesSameKey(t *testing.T) {
+	// given
+	lock := NewKeyedLock()
+	var mutex sync.Mutex
+	var events []string
+
+	record := func(event string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, event)
+	}
+
+	// when
+	unlock := lock.Lock("keycloak/realm")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		release := lock.Lock("keycloak/realm")
+		record("second")
+		release()
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	record("first")
+	unlock()
+	<-done
+
+	// then
+	assert.Equal(t, []string{"first", "second"}, events)
+	assert.Len(t, lock.locks, 0)
+}
+
+func TestKeyedLock_DifferentKeysDoNotBlock(t *testing.T) {
+	// given
+	lock := NewKeyedLock()
+	unlock := lock.Lock("keycloak-a/realm")
+	defer unlock()
+
+	// when
+	acquired := make(chan struct{})
+	go func() {
+		release := lock.Lock("keycloak-b/realm")
+		release()
+		close(acquired)
+	}()
+
+	// then
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("lock on a different key was blocked")
+	}
+}
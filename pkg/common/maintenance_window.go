@@ -0,0 +1,151 @@
+package common
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// Minutes a maintenance window stays open, unless configured otherwise
+const defaultMaintenanceWindowMinutes = 60
+
+// The next window is searched within a year, schedules like "0 0 30 2 *" never open
+const maxMaintenanceWindowSearch = 366 * 24 * time.Hour
+
+// A parsed cron schedule, each field is a bit set of the matching values
+type cronSchedule struct {
+	minutes, hours, days, months, weekdays uint64
+	// Standard cron matches either the day of month or the day of week if both are restricted
+	daysRestricted, weekdaysRestricted bool
+}
+
+// ValidateMaintenanceWindow returns an error if the schedule of the window can't be parsed
+func ValidateMaintenanceWindow(window *kc.MaintenanceWindow) error {
+	if window == nil {
+		return nil
+	}
+	if window.DurationMinutes < 0 {
+		return errors.Errorf("maintenanceWindow.durationMinutes can't be negative")
+	}
+	_, err := parseCronSchedule(window.Schedule)
+	return err
+}
+
+// IsMaintenanceWindowOpen returns true if the window is open at the time, otherwise it returns the next time the
+// window opens. The next time is zero if the window doesn't open within a year.
+func IsMaintenanceWindowOpen(window *kc.MaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	schedule, err := parseCronSchedule(window.Schedule)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	duration := time.Duration(window.DurationMinutes) * time.Minute
+	if window.DurationMinutes == 0 {
+		duration = defaultMaintenanceWindowMinutes * time.Minute
+	}
+
+	now = now.UTC().Truncate(time.Minute)
+	for opened := now; opened.After(now.Add(-duration)); opened = opened.Add(-time.Minute) {
+		if schedule.matches(opened) {
+			return true, time.Time{}, nil
+		}
+	}
+	for next := now.Add(time.Minute); next.Before(now.Add(maxMaintenanceWindowSearch)); next = next.Add(time.Minute) {
+		if schedule.matches(next) {
+			return false, next, nil
+		}
+	}
+	return false, time.Time{}, nil
+}
+
+func (s *cronSchedule) matches(t time.Time) bool {
+	if s.minutes&(1<<uint(t.Minute())) == 0 || s.hours&(1<<uint(t.Hour())) == 0 || s.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.daysRestricted && s.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+func parseCronSchedule(schedule string) (*cronSchedule, error) {
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("schedule %q needs 5 fields: minute, hour, day of month, month and day of week", schedule)
+	}
+
+	parsed := &cronSchedule{
+		daysRestricted:     !strings.HasPrefix(fields[2], "*"),
+		weekdaysRestricted: !strings.HasPrefix(fields[4], "*"),
+	}
+	var err error
+	for i, field := range []struct {
+		bits     *uint64
+		min, max int
+	}{
+		{&parsed.minutes, 0, 59},
+		{&parsed.hours, 0, 23},
+		{&parsed.days, 1, 31},
+		{&parsed.months, 1, 12},
+		{&parsed.weekdays, 0, 7},
+	} {
+		*field.bits, err = parseCronField(fields[i], field.min, field.max)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schedule %q", schedule)
+		}
+	}
+
+	// Sunday is 0 or 7
+	if parsed.weekdays&(1<<7) != 0 {
+		parsed.weekdays |= 1
+	}
+	return parsed, nil
+}
+
+// Parses a comma separated list of values, ranges like 1-5 and *, each optionally with a step like */15
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if separator := strings.Index(part, "/"); separator >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[separator+1:])
+			if err != nil || step < 1 {
+				return 0, errors.Errorf("invalid step in %q", part)
+			}
+			part = part[:separator]
+		}
+
+		first, last := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			first, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, errors.Errorf("invalid value %q", part)
+			}
+			last = first
+			if len(bounds) == 2 {
+				last, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, errors.Errorf("invalid range %q", part)
+				}
+			} else if step > 1 {
+				last = max
+			}
+		}
+		if first < min || last > max || first > last {
+			return 0, errors.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for value := first; value <= last; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+// Saturday, 2026-10-17 02:30 UTC
+var saturdayNight = time.Date(2026, time.October, 17, 2, 30, 0, 0, time.UTC)
+
+func TestMaintenanceWindow_Test_Open_Within_Duration(t *testing.T) {
+	// given
+	window := &kc.MaintenanceWindow{Schedule: "0 2 * * 6"}
+
+	// when
+	open, next, err := IsMaintenanceWindowOpen(window, saturdayNight)
+
+	// then
+	assert.Nil(t, err)
+	assert.True(t, open)
+	assert.True(t, next.IsZero())
+}
+
+func TestMaintenanceWindow_Test_Closed_Until_Next_Window(t *testing.T) {
+	// given
+	window := &kc.MaintenanceWindow{Schedule: "0 2 * * 6", DurationMinutes: 15}
+
+	// when
+	open, next, err := IsMaintenanceWindowOpen(window, saturdayNight)
+
+	// then
+	assert.Nil(t, err)
+	assert.False(t, open)
+	assert.Equal(t, time.Date(2026, time.October, 24, 2, 0, 0, 0, time.UTC), next)
+}
+
+func TestMaintenanceWindow_Test_Schedule_Fields(t *testing.T) {
+	for schedule, expected := range map[string]bool{
+		"30 2 * * *":        true,
+		"*/15 1-3 * * *":    true,
+		"0,45 2 17 10 *":    true,
+		"0 2 * * 0,6":       true,
+		"0 2 * * 7":         false,
+		"0 2 1 * 6":         true, // day of month or day of week
+		"0 2 1 * *":         false,
+		"0 2 */2 * 1":       false, // a stepped wildcard doesn't restrict the days
+		"0 2 * 1-9 *":       false,
+		"0 22-23/1 * * 1-5": false,
+	} {
+		window := &kc.MaintenanceWindow{Schedule: schedule}
+		open, _, err := IsMaintenanceWindowOpen(window, saturdayNight)
+		assert.Nil(t, err, schedule)
+		assert.Equal(t, expected, open, schedule)
+	}
+}
+
+func TestMaintenanceWindow_Test_Validation(t *testing.T) {
+	assert.Nil(t, ValidateMaintenanceWindow(nil))
+	assert.Nil(t, ValidateMaintenanceWindow(&kc.MaintenanceWindow{Schedule: "0 2 * * 6"}))
+	assert.Error(t, ValidateMaintenanceWindow(&kc.MaintenanceWindow{Schedule: "0 2 * *"}))
+	assert.Error(t, ValidateMaintenanceWindow(&kc.MaintenanceWindow{Schedule: "60 2 * * 6"}))
+	assert.Error(t, ValidateMaintenanceWindow(&kc.MaintenanceWindow{Schedule: "0 5-2 * * 6"}))
+	assert.Error(t, ValidateMaintenanceWindow(&kc.MaintenanceWindow{Schedule: "*/0 2 * * 6"}))
+	assert.Error(t, ValidateMaintenanceWindow(&kc.MaintenanceWindow{Schedule: "0 2 * * SAT"}))
+	assert.Error(t, ValidateMaintenanceWindow(&kc.MaintenanceWindow{Schedule: "0 2 * * 6", DurationMinutes: -1}))
+}
@@ -0,0 +1,233 @@
+package common
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	MetricsNamespace = "keycloak_operator"
+
+	WriteResultApplied = "applied"
+	WriteResultSkipped = "skipped"
+
+	WriteResourceClient = "client"
+	WriteResourceUser   = "user"
+
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// Counts the client and user updates sent to Keycloak and the ones skipped because nothing changed
+var keycloakWrites = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "keycloak_writes_total",
+		Help:      "Number of Keycloak admin API updates applied or skipped because the resource was already up to date",
+	},
+	[]string{"resource", "result"},
+)
+
+var reconcileResults = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "reconcile_total",
+		Help:      "Number of reconciles per custom resource by result",
+	},
+	[]string{"controller", "namespace", "name", "result"},
+)
+
+var reconcileDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of reconciles per controller",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	},
+	[]string{"controller"},
+)
+
+var reconcileLastDuration = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "reconcile_last_duration_seconds",
+		Help:      "Duration of the last reconcile per custom resource",
+	},
+	[]string{"controller", "namespace", "name"},
+)
+
+var failingResources = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Name:      "failing_resources",
+		Help:      "Number of custom resources in the failing phase per controller",
+	},
+	[]string{"controller"},
+)
+
+var keycloakAPIRequestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "keycloak_api_request_duration_seconds",
+		Help:      "Latency of Keycloak admin API requests by method, resource path template and status code",
+		Buckets:   prometheus.DefBuckets,
+	},
+	[]string{"method", "path", "code"},
+)
+
+var clusterActions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "cluster_actions_total",
+		Help:      "Number of cluster actions run by action type and result",
+	},
+	[]string{"action", "result"},
+)
+
+var keycloakLoginFailures = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "keycloak_login_failures_total",
+		Help:      "Number of failed attempts to obtain an admin API token",
+	},
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		keycloakWrites,
+		reconcileResults,
+		reconcileDuration,
+		reconcileLastDuration,
+		failingResources,
+		keycloakAPIRequestDuration,
+		clusterActions,
+		keycloakLoginFailures,
+	)
+}
+
+// RecordKeycloakWrite counts an applied or skipped update of a Keycloak resource
+func RecordKeycloakWrite(resource, result string) {
+	keycloakWrites.WithLabelValues(resource, result).Inc()
+}
+
+// The custom resources currently failing, per controller
+var failing = struct {
+	sync.Mutex
+	resources map[string]map[types.NamespacedName]bool
+}{resources: map[string]map[types.NamespacedName]bool{}}
+
+func setFailing(controller string, key types.NamespacedName, isFailing bool) {
+	failing.Lock()
+	defer failing.Unlock()
+
+	resources, ok := failing.resources[controller]
+	if !ok {
+		resources = map[types.NamespacedName]bool{}
+		failing.resources[controller] = resources
+	}
+
+	if isFailing {
+		resources[key] = true
+	} else {
+		delete(resources, key)
+	}
+	failingResources.WithLabelValues(controller).Set(float64(len(resources)))
+}
+
+// RecordReconcileResult counts the outcome of a reconcile and tracks whether the custom resource is failing
+func RecordReconcileResult(controller, namespace, name string, issue error) {
+	result := ResultSuccess
+	if issue != nil {
+		result = ResultError
+	}
+	reconcileResults.WithLabelValues(controller, namespace, name, result).Inc()
+	setFailing(controller, types.NamespacedName{Namespace: namespace, Name: name}, issue != nil)
+}
+
+// ObserveReconcileDuration records the duration of a reconcile that started at the given time
+func ObserveReconcileDuration(controller string, request types.NamespacedName, start time.Time) {
+	duration := time.Since(start).Seconds()
+	reconcileDuration.WithLabelValues(controller).Observe(duration)
+	reconcileLastDuration.WithLabelValues(controller, request.Namespace, request.Name).Set(duration)
+}
+
+// ForgetReconciledResource removes the per custom resource metrics once the resource is gone
+func ForgetReconciledResource(controller string, request types.NamespacedName) {
+	setFailing(controller, request, false)
+	reconcileLastDuration.DeleteLabelValues(controller, request.Namespace, request.Name)
+	reconcileResults.DeleteLabelValues(controller, request.Namespace, request.Name, ResultSuccess)
+	reconcileResults.DeleteLabelValues(controller, request.Namespace, request.Name, ResultError)
+}
+
+// RecordClusterAction counts a cluster action run by its type
+func RecordClusterAction(action ClusterAction, issue error) {
+	result := ResultSuccess
+	if issue != nil {
+		result = ResultError
+	}
+	clusterActions.WithLabelValues(clusterActionName(action), result).Inc()
+}
+
+// Returns the type name of an action, e.g. UpdateClientAction
+func clusterActionName(action ClusterAction) string {
+	return reflect.Indirect(reflect.ValueOf(action)).Type().Name()
+}
+
+// RecordKeycloakLoginFailure counts a failed admin API token request
+func RecordKeycloakLoginFailure() {
+	keycloakLoginFailures.Inc()
+}
+
+// ObserveKeycloakAPIRequest records the latency of an admin API request. Failed requests without response use the
+// status code 0.
+func ObserveKeycloakAPIRequest(req *http.Request, res *http.Response, start time.Time) {
+	code := "0"
+	if res != nil {
+		code = strconv.Itoa(res.StatusCode)
+	}
+	keycloakAPIRequestDuration.WithLabelValues(req.Method, KeycloakAPIPathTemplate(req.URL.Path), code).Observe(time.Since(start).Seconds())
+}
+
+// Path segments that are followed by an identifier in the admin API
+var identifiedCollections = map[string]string{
+	"realms":                 "{realm}",
+	"clients":                "{id}",
+	"users":                  "{id}",
+	"roles":                  "{role}",
+	"roles-by-id":            "{id}",
+	"default-client-scopes":  "{id}",
+	"optional-client-scopes": "{id}",
+	"instances":              "{alias}",
+	"federated-identity":     "{provider}",
+	"flows":                  "{alias}",
+	"executions":             "{id}",
+	"config":                 "{id}",
+}
+
+// KeycloakAPIPathTemplate replaces the identifiers in an admin API path to keep the metric cardinality low, e.g.
+// /auth/admin/realms/master/users/1234 becomes realms/{realm}/users/{id}
+func KeycloakAPIPathTemplate(path string) string {
+	// Drop the context root and admin prefix
+	if index := strings.Index(path, "/admin/"); index >= 0 {
+		path = path[index+len("/admin/"):]
+	} else if index := strings.Index(path, "/realms/"); index >= 0 {
+		path = path[index+1:]
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for index := 1; index < len(segments); index++ {
+		if placeholder, ok := identifiedCollections[segments[index-1]]; ok {
+			segments[index] = placeholder
+			index++
+		}
+	}
+	return strings.Join(segments, "/")
+}
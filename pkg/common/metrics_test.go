@@ -0,0 +1,32 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestMetrics_KeycloakAPIPathTemplate(t *testing.T) {
+	assert.Equal(t, "realms", KeycloakAPIPathTemplate("/auth/admin/realms"))
+	assert.Equal(t, "realms/{realm}/users/{id}", KeycloakAPIPathTemplate("/auth/admin/realms/master/users/1234"))
+	assert.Equal(t, "realms/{realm}/users/{id}/role-mappings/clients/{id}", KeycloakAPIPathTemplate("/admin/realms/test/users/1/role-mappings/clients/2"))
+	assert.Equal(t, "realms/{realm}/clients/{id}/roles/{role}", KeycloakAPIPathTemplate("/auth/admin/realms/test/clients/1/roles/admin"))
+	assert.Equal(t, "realms/{realm}/protocol/openid-connect/token", KeycloakAPIPathTemplate("/auth/realms/master/protocol/openid-connect/token"))
+}
+
+func TestMetrics_FailingResources(t *testing.T) {
+	controller := "metrics-test-controller"
+
+	RecordReconcileResult(controller, "ns", "a", errors.New("failed"))
+	RecordReconcileResult(controller, "ns", "b", errors.New("failed"))
+	assert.Equal(t, float64(2), testutil.ToFloat64(failingResources.WithLabelValues(controller)))
+
+	RecordReconcileResult(controller, "ns", "a", nil)
+	assert.Equal(t, float64(1), testutil.ToFloat64(failingResources.WithLabelValues(controller)))
+
+	ForgetReconciledResource(controller, types.NamespacedName{Namespace: "ns", Name: "b"})
+	assert.Equal(t, float64(0), testutil.ToFloat64(failingResources.WithLabelValues(controller)))
+}
@@ -0,0 +1,20 @@
+package common
+
+import (
+	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PodDisruptionBudgetV1beta1Only is set if the cluster serves PodDisruptionBudgets with policy/v1beta1, but not yet
+// with policy/v1
+const PodDisruptionBudgetV1beta1Only = "PodDisruptionBudgetV1beta1Only"
+
+// GetPodDisruptionBudgetGroupVersionKind returns policy/v1, unless the cluster only serves policy/v1beta1. Both
+// versions serve the same objects, so PodDisruptionBudgets created with policy/v1beta1 are read and updated with
+// policy/v1 once the cluster has been upgraded.
+func GetPodDisruptionBudgetGroupVersionKind() schema.GroupVersionKind {
+	if v1beta1Only, _ := GetStateManager().GetState(PodDisruptionBudgetV1beta1Only).(bool); v1beta1Only {
+		return model.PodDisruptionBudgetV1beta1GroupVersionKind
+	}
+	return model.PodDisruptionBudgetGroupVersionKind
+}
@@ -63,3 +63,17 @@ func IsJobReady(job *v13.Job) (bool, error) {
 
 	return job.Status.Succeeded == 1, nil
 }
+
+// IsJobFailed returns true if the Job failed after its retries
+func IsJobFailed(job *v13.Job) bool {
+	if job == nil {
+		return false
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == v13.JobFailed && condition.Status == ConditionStatusSuccess {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+)
+
+// RealmCacheTTL is the time realm-wide admin API reads are shared between the custom resources of a realm
+var RealmCacheTTL = 30 * time.Second
+
+type realmCacheEntry struct {
+	clients               []*v1alpha1.KeycloakAPIClient
+	clientsExpiry         time.Time
+	availableScopes       []v1alpha1.KeycloakClientScope
+	availableScopesExpiry time.Time
+}
+
+var realmCache = struct {
+	sync.Mutex
+	entries map[string]*realmCacheEntry
+}{entries: map[string]*realmCacheEntry{}}
+
+func realmCacheKey(keycloakClient KeycloakInterface, realm string) string {
+	return keycloakClient.Endpoint() + "/" + realm
+}
+
+func getRealmCacheEntry(key string) *realmCacheEntry {
+	entry, ok := realmCache.entries[key]
+	if !ok {
+		entry = &realmCacheEntry{}
+		realmCache.entries[key] = entry
+	}
+	return entry
+}
+
+// ListCachedClients returns the clients of a realm, reusing a recent result read for another custom resource
+func ListCachedClients(keycloakClient KeycloakInterface, realm string) ([]*v1alpha1.KeycloakAPIClient, error) {
+	key := realmCacheKey(keycloakClient, realm)
+
+	realmCache.Lock()
+	entry := getRealmCacheEntry(key)
+	if time.Now().Before(entry.clientsExpiry) {
+		clients := append([]*v1alpha1.KeycloakAPIClient{}, entry.clients...)
+		realmCache.Unlock()
+		return clients, nil
+	}
+	realmCache.Unlock()
+
+	clients, err := keycloakClient.ListClients(realm)
+	if err != nil {
+		return nil, err
+	}
+
+	realmCache.Lock()
+	defer realmCache.Unlock()
+	entry = getRealmCacheEntry(key)
+	entry.clients = clients
+	entry.clientsExpiry = time.Now().Add(RealmCacheTTL)
+	return append([]*v1alpha1.KeycloakAPIClient{}, clients...), nil
+}
+
+// ListCachedAvailableClientScopes returns the client scopes of a realm, reusing a recent result read for another
+// custom resource
+func ListCachedAvailableClientScopes(keycloakClient KeycloakInterface, realm string) ([]v1alpha1.KeycloakClientScope, error) {
+	key := realmCacheKey(keycloakClient, realm)
+
+	realmCache.Lock()
+	entry := getRealmCacheEntry(key)
+	if time.Now().Before(entry.availableScopesExpiry) {
+		scopes := append([]v1alpha1.KeycloakClientScope{}, entry.availableScopes...)
+		realmCache.Unlock()
+		return scopes, nil
+	}
+	realmCache.Unlock()
+
+	scopes, err := keycloakClient.ListAvailableClientScopes(realm)
+	if err != nil {
+		return nil, err
+	}
+
+	realmCache.Lock()
+	defer realmCache.Unlock()
+	entry = getRealmCacheEntry(key)
+	entry.availableScopes = scopes
+	entry.availableScopesExpiry = time.Now().Add(RealmCacheTTL)
+	return append([]v1alpha1.KeycloakClientScope{}, scopes...), nil
+}
+
+// InvalidateRealmCache drops the cached reads of a realm after it was changed by the operator
+func InvalidateRealmCache(keycloakClient KeycloakInterface, realm string) {
+	realmCache.Lock()
+	defer realmCache.Unlock()
+	delete(realmCache.entries, realmCacheKey(keycloakClient, realm))
+}
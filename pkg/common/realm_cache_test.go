@@ -0,0 +1,235 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRealmCache_ListCachedClients(t *testing.T) {
+	// given
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		json, err := jsoniter.Marshal([]*v1alpha1.KeycloakAPIClient{{ID: "1", ClientID: "dummy"}})
+		assert.NoError(t, err)
+		_, err = w.Write(json)
+		assert.NoError(t, err)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &Client{
+		requester: server.Client(),
+		URL:       server.URL,
+		token:     "dummy",
+	}
+
+	// when
+	first, err := ListCachedClients(client, "dummy")
+	assert.NoError(t, err)
+	second, err := ListCachedClients(client, "dummy")
+	assert.NoError(t, err)
+
+	// then
+	assert.Len(t, first, 1)
+	assert.Equal(t, first, second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// a change of the realm requires a new read
+	InvalidateRealmCache(client, "dummy")
+	_, err = ListCachedClients(client, "dummy")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestRealmCache_UpdateClientInvalidatesClients(t *testing.T) {
+	// given
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPut {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		json, err := jsoniter.Marshal([]*v1alpha1.KeycloakAPIClient{{ID: "1", ClientID: "dummy"}})
+		assert.NoError(t, err)
+		_, err = w.Write(json)
+		assert.NoError(t, err)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &Client{
+		requester: server.Client(),
+		URL:       server.URL,
+		token:     "dummy",
+	}
+	_, err := ListCachedClients(client, "updated")
+	assert.NoError(t, err)
+
+	// when
+	err = client.UpdateClient(&v1alpha1.KeycloakAPIClient{ID: "1", ClientID: "dummy"}, "updated")
+	assert.NoError(t, err)
+	_, err = ListCachedClients(client, "updated")
+	assert.NoError(t, err)
+
+	// then
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestRequestLimiter_CapsConcurrentRequests(t *testing.T) {
+	// given
+	defer func(concurrency int) { KeycloakAPIConcurrency = concurrency }(KeycloakAPIConcurrency)
+	KeycloakAPIConcurrency = 2
+
+	var inFlight, maxInFlight int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		_, err := w.Write([]byte("{}"))
+		assert.NoError(t, err)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	keycloak := v1alpha1.Keycloak{ObjectMeta: v1.ObjectMeta{Name: "limited", Namespace: "dummy"}}
+	client := &Client{
+		requester: newLimitedRequester(server.Client(), keycloak),
+		URL:       server.URL,
+		token:     "dummy",
+	}
+
+	// when
+	wg := sync.WaitGroup{}
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetUser("dummy", "dummy")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// then
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+}
+
+func TestRequestLimiter_ReleasesSlotsOfFailedPings(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/auth/" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, err := w.Write([]byte("{}"))
+		assert.NoError(t, err)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	keycloak := v1alpha1.Keycloak{ObjectMeta: v1.ObjectMeta{Name: "failing-ping", Namespace: "dummy"}}
+	client := &Client{
+		requester: newLimitedRequester(server.Client(), keycloak),
+		URL:       server.URL,
+		token:     "dummy",
+	}
+
+	// when
+	done := make(chan error)
+	go func() {
+		for i := 0; i <= KeycloakAPIConcurrency; i++ {
+			assert.Error(t, client.Ping())
+		}
+		_, err := client.GetUser("dummy", "dummy")
+		done <- err
+	}()
+
+	// then
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request blocked on the slots of failed pings")
+	}
+}
+
+func TestRequestLimiter_WaitsForSlotUntilContextIsDone(t *testing.T) {
+	// given
+	keycloak := v1alpha1.Keycloak{ObjectMeta: v1.ObjectMeta{Name: "busy", Namespace: "dummy"}}
+	requester := newLimitedRequester(http.DefaultClient, keycloak).(*limitedRequester)
+	for i := 0; i < cap(requester.slots); i++ {
+		requester.slots <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < cap(requester.slots); i++ {
+			<-requester.slots
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://keycloak.invalid", nil)
+	assert.NoError(t, err)
+
+	// when
+	res, err := requester.Do(req)
+
+	// then
+	assert.Nil(t, res)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRequestLimiter_ClientRequestsUseClientContext(t *testing.T) {
+	// given
+	keycloak := v1alpha1.Keycloak{ObjectMeta: v1.ObjectMeta{Name: "busy-client", Namespace: "dummy"}}
+	requester := newLimitedRequester(http.DefaultClient, keycloak).(*limitedRequester)
+	for i := 0; i < cap(requester.slots); i++ {
+		requester.slots <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < cap(requester.slots); i++ {
+			<-requester.slots
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	client := KeycloakClientWithContext(&Client{
+		requester: requester,
+		URL:       "http://keycloak.invalid",
+		token:     "dummy",
+	}, ctx)
+
+	// when
+	done := make(chan error)
+	go func() {
+		_, err := client.GetUser("dummy", "dummy")
+		done <- err
+	}()
+
+	// then
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request ignored the context of the client")
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -25,6 +26,16 @@ func NewRealmState(context context.Context, keycloak kc.Keycloak) *RealmState {
 }
 
 func (i *RealmState) Read(cr *kc.KeycloakRealm, realmClient KeycloakInterface, controllerClient client.Client) error {
+	ctx, span := tracing.StartSpan(i.Context, "RealmState.Read", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("keycloak.realm", cr.Spec.Realm.Realm)
+
+	err := i.read(cr, KeycloakClientWithContext(realmClient, ctx), controllerClient)
+	span.RecordError(err)
+	return err
+}
+
+func (i *RealmState) read(cr *kc.KeycloakRealm, realmClient KeycloakInterface, controllerClient client.Client) error {
 	realm, err := realmClient.GetRealm(cr.Spec.Realm.Realm)
 	if err != nil {
 		i.Realm = nil
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+)
+
+// KeycloakAPIConcurrency is the maximum number of concurrent admin API requests sent to a single Keycloak instance
+var KeycloakAPIConcurrency = 10
+
+var requestLimiters = struct {
+	sync.Mutex
+	slots map[string]chan struct{}
+}{slots: map[string]chan struct{}{}}
+
+// Returns the request slots shared by all clients of a Keycloak instance
+func instanceRequestSlots(kc v1alpha1.Keycloak) chan struct{} {
+	requestLimiters.Lock()
+	defer requestLimiters.Unlock()
+
+	key := fmt.Sprintf("%v/%v", kc.Namespace, kc.Name)
+	slots, ok := requestLimiters.slots[key]
+	if !ok {
+		limit := KeycloakAPIConcurrency
+		if limit < 1 {
+			limit = 1
+		}
+		slots = make(chan struct{}, limit)
+		requestLimiters.slots[key] = slots
+	}
+	return slots
+}
+
+// limitedRequester caps the number of in-flight requests to a Keycloak instance. A slot is held until the response
+// body is closed.
+type limitedRequester struct {
+	requester Requester
+	slots     chan struct{}
+}
+
+func newLimitedRequester(requester Requester, kc v1alpha1.Keycloak) Requester {
+	return &limitedRequester{
+		requester: requester,
+		slots:     instanceRequestSlots(kc),
+	}
+}
+
+// Waits for a free slot until the context of the request is done
+func (l *limitedRequester) Do(req *http.Request) (*http.Response, error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	res, err := l.requester.Do(req)
+	if err != nil || res == nil || res.Body == nil {
+		<-l.slots
+		return res, err
+	}
+
+	res.Body = &releasingBody{ReadCloser: res.Body, release: func() { <-l.slots }}
+	return res, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -2,9 +2,12 @@ package common
 
 import (
 	"context"
+	"sync"
 
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
+	"golang.org/x/sync/errgroup"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -18,6 +21,7 @@ type UserState struct {
 	AvailableRealmRoles  []*v1alpha1.KeycloakUserRole
 	Clients              []*v1alpha1.KeycloakAPIClient
 	Secret               *v1.Secret
+	CredentialsKey       []byte
 	Keycloak             v1alpha1.Keycloak
 	Context              context.Context
 }
@@ -31,6 +35,22 @@ func NewUserState(keycloak v1alpha1.Keycloak) *UserState {
 }
 
 func (i *UserState) Read(keycloakClient KeycloakInterface, userClient client.Client, user *v1alpha1.KeycloakUser, realm v1alpha1.KeycloakRealm) error {
+	ctx, span := tracing.StartSpan(i.Context, "UserState.Read", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("keycloak.realm", realm.Spec.Realm.Realm)
+	span.SetAttribute("keycloak.username", user.Spec.User.UserName)
+
+	err := i.read(KeycloakClientWithContext(keycloakClient, ctx), userClient, user, realm)
+	span.RecordError(err)
+	return err
+}
+
+func (i *UserState) read(keycloakClient KeycloakInterface, userClient client.Client, user *v1alpha1.KeycloakUser, realm v1alpha1.KeycloakRealm) error {
+	err := i.readCredentialsKey(userClient)
+	if err != nil {
+		return err
+	}
+
 	apiUser, err := i.readUser(keycloakClient, user, realm.Spec.Realm.Realm)
 	if err != nil {
 		// If there was an error reading the user then don't attempt
@@ -49,12 +69,15 @@ func (i *UserState) ReadWithExistingAPIUser(keycloakClient KeycloakInterface, us
 
 	i.User = user
 
-	var err = i.readRealmRoles(keycloakClient, realm.Spec.Realm.Realm)
-	if err != nil {
-		return err
-	}
+	group := errgroup.Group{}
+	group.Go(func() error {
+		return i.readRealmRoles(keycloakClient, realm.Spec.Realm.Realm)
+	})
+	group.Go(func() error {
+		return i.readClientRoles(keycloakClient, realm.Spec.Realm.Realm)
+	})
 
-	err = i.readClientRoles(keycloakClient, realm.Spec.Realm.Realm)
+	err := group.Wait()
 	if err != nil {
 		return err
 	}
@@ -92,28 +115,40 @@ func (i *UserState) readRealmRoles(client KeycloakInterface, realm string) error
 }
 
 func (i *UserState) readClientRoles(client KeycloakInterface, realm string) error {
-	clients, err := client.ListClients(realm)
+	clients, err := ListCachedClients(client, realm)
 	if err != nil {
 		return err
 	}
 	i.Clients = clients
 
-	for _, c := range clients {
-		// Get all client roles of this user
-		roles, err := client.ListUserClientRoles(realm, c.ID, i.User.ID)
-		if err != nil {
-			return err
-		}
-		i.ClientRoles[c.ClientID] = roles
+	// Read the roles of all clients concurrently, the maps are shared between the goroutines
+	var mutex sync.Mutex
+	group := errgroup.Group{}
+	group.SetLimit(KeycloakAPIConcurrency)
 
-		// Get the roles that are still available to this user
-		availableRoles, err := client.ListAvailableUserClientRoles(realm, c.ID, i.User.ID)
-		if err != nil {
-			return err
-		}
-		i.AvailableClientRoles[c.ClientID] = availableRoles
+	for _, c := range clients {
+		c := c
+		group.Go(func() error {
+			// Get all client roles of this user
+			roles, err := client.ListUserClientRoles(realm, c.ID, i.User.ID)
+			if err != nil {
+				return err
+			}
+
+			// Get the roles that are still available to this user
+			availableRoles, err := client.ListAvailableUserClientRoles(realm, c.ID, i.User.ID)
+			if err != nil {
+				return err
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			i.ClientRoles[c.ClientID] = roles
+			i.AvailableClientRoles[c.ClientID] = availableRoles
+			return nil
+		})
 	}
-	return nil
+	return group.Wait()
 }
 
 func (i *UserState) readSecretState(userClient client.Client, realm *v1alpha1.KeycloakRealm) error {
@@ -133,6 +168,21 @@ func (i *UserState) readSecretState(userClient client.Client, realm *v1alpha1.Ke
 	return nil
 }
 
+// The admin password of the instance keys the hashes of the credentials applied to its users
+func (i *UserState) readCredentialsKey(userClient client.Client) error {
+	secret := &v1.Secret{}
+	err := userClient.Get(i.Context, model.KeycloakAdminSecretSelector(&i.Keycloak), secret)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	i.CredentialsKey = secret.Data[model.AdminPasswordProperty]
+	return nil
+}
+
 // Check if a realm role is part of the available roles for this user
 // Don't allow to assign unavailable roles
 func (i *UserState) GetAvailableRealmRole(name string) *v1alpha1.KeycloakUserRole {
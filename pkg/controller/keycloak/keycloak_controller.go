@@ -7,8 +7,6 @@ import (
 
 	"github.com/jaconi-io/keycloak-operator/version"
 
-	v1beta12 "k8s.io/api/policy/v1beta1"
-
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
 
 	"k8s.io/client-go/tools/record"
@@ -22,11 +20,14 @@ import (
 
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 	"github.com/pkg/errors"
 
 	networkingv1 "k8s.io/api/networking/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -67,7 +68,10 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(ControllerName, mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: common.KeycloakMaxConcurrentReconciles,
+	})
 	if err != nil {
 		return err
 	}
@@ -82,6 +86,21 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Secrets and ConfigMaps used by Keycloak are usually not owned by it, changes to them restart Keycloak
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: referencingKeycloaks(mgr.GetClient(), model.KeycloakReferencedSecretNames),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: referencingKeycloaks(mgr.GetClient(), model.KeycloakReferencedConfigMapNames),
+	})
+	if err != nil {
+		return err
+	}
+
 	if err := common.WatchSecondaryResource(c, ControllerName, common.StatefulSetKind, &appsv1.StatefulSet{}, &kc.Keycloak{}); err != nil {
 		return err
 	}
@@ -102,7 +121,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	if err := common.WatchSecondaryResource(c, ControllerName, common.PodDisruptionBudgetKind, &v1beta12.PodDisruptionBudget{}, &kc.Keycloak{}); err != nil {
+	if err := common.WatchSecondaryResource(c, ControllerName, common.PodDisruptionBudgetKind, model.PodDisruptionBudget(&kc.Keycloak{}, common.GetPodDisruptionBudgetGroupVersionKind()), &kc.Keycloak{}); err != nil {
 		return err
 	}
 
@@ -122,9 +141,52 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Certificate renewals update the status of the Certificate, which triggers the restart of Keycloak
+	if err := common.WatchSecondaryResource(c, ControllerName, common.CertificateKind, model.KeycloakCertificate(&kc.Keycloak{}), &kc.Keycloak{}); err != nil {
+		return err
+	}
+
+	if err := common.WatchSecondaryResource(c, ControllerName, common.HorizontalPodAutoscalerKind, model.KeycloakHorizontalPodAutoscaler(&kc.Keycloak{}), &kc.Keycloak{}); err != nil {
+		return err
+	}
+
+	if err := common.WatchSecondaryResource(c, ControllerName, common.HTTPRouteKind, model.KeycloakHTTPRoute(&kc.Keycloak{}), &kc.Keycloak{}); err != nil {
+		return err
+	}
+
+	if err := common.WatchSecondaryResource(c, ControllerName, common.TLSRouteKind, model.KeycloakTLSRoute(&kc.Keycloak{}), &kc.Keycloak{}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// referencingKeycloaks maps an object to the Keycloaks in its namespace that reference it by name
+func referencingKeycloaks(c client.Client, referencedNames func(cr *kc.Keycloak) []string) handler.ToRequestsFunc {
+	return func(object handler.MapObject) []reconcile.Request {
+		keycloaks := &kc.KeycloakList{}
+		err := c.List(context.TODO(), keycloaks, client.InNamespace(object.Meta.GetNamespace()))
+		if err != nil {
+			log.Error(err, "error listing keycloaks referencing object", "Namespace", object.Meta.GetNamespace(), "Name", object.Meta.GetName())
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for i := range keycloaks.Items {
+			for _, name := range referencedNames(&keycloaks.Items[i]) {
+				if name == object.Meta.GetName() {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+						Namespace: keycloaks.Items[i].Namespace,
+						Name:      keycloaks.Items[i].Name,
+					}})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
+
 // blank assignment to verify that ReconcileKeycloak implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileKeycloak{}
 
@@ -142,6 +204,12 @@ type ReconcileKeycloak struct {
 func (r *ReconcileKeycloak) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling Keycloak")
+	defer common.ObserveReconcileDuration(ControllerName, request.NamespacedName, time.Now())
+
+	ctx, span := tracing.StartSpan(r.context, "Keycloak.Reconcile", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("k8s.namespace.name", request.Namespace)
+	span.SetAttribute("k8s.resource.name", request.Name)
 
 	// Fetch the Keycloak instance
 	instance := &kc.Keycloak{}
@@ -152,6 +220,7 @@ func (r *ReconcileKeycloak) Reconcile(request reconcile.Request) (reconcile.Resu
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			common.ForgetReconciledResource(ControllerName, request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -167,15 +236,99 @@ func (r *ReconcileKeycloak) Reconcile(request reconcile.Request) (reconcile.Resu
 		return r.ManageError(instance, errors.Errorf("if external.enabled is true, unmanaged also needs to be true"))
 	}
 
-	if instance.Spec.ExternalAccess.Host != "" {
+	// Instances created by earlier operator versions keep their fixed resource names
+	if !instance.Status.LegacyNames {
+		instance.Status.LegacyNames, err = r.ownsLegacyNamedResources(instance)
+		if err != nil {
+			return r.ManageError(instance, err)
+		}
+		if instance.Status.LegacyNames {
+			log.Info(fmt.Sprintf("keycloak %v/%v owns resources with legacy names and will keep using them", instance.Namespace, instance.Name))
+		}
+	}
+
+	// Instances created before OpenShift was detected keep their Ingress instead of switching to a Route
+	if !instance.Status.LegacyIngress && common.GetExternalAccessMode(instance) == kc.RouteExternalAccessMode && instance.Spec.ExternalAccess.Mode == kc.DefaultExternalAccessMode {
+		instance.Status.LegacyIngress, err = r.ownsIngress(instance)
+		if err != nil {
+			return r.ManageError(instance, err)
+		}
+		if instance.Status.LegacyIngress {
+			log.Info(fmt.Sprintf("keycloak %v/%v owns an Ingress and will keep using it, set externalAccess.mode to route to switch", instance.Namespace, instance.Name))
+		}
+	}
+
+	if instance.Spec.ExternalAccess.Host != "" && common.GetExternalAccessMode(instance) == kc.RouteExternalAccessMode {
 		isOpenshift, _ := common.GetStateManager().GetState(common.OpenShiftAPIServerKind).(bool)
 		if isOpenshift {
 			return r.ManageError(instance, errors.Errorf("Setting Host in External Access on OpenShift is prohibited"))
 		}
 	}
 
+	if err := model.ValidateExternalAccess(instance); err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	if instance.Spec.ExternalAccess.Enabled && instance.Spec.ExternalAccess.PathPrefix != "" && common.GetExternalAccessMode(instance) == kc.RouteExternalAccessMode {
+		return r.ManageError(instance, errors.Errorf("externalAccess.pathPrefix is not supported with a Route"))
+	}
+
+	// Without a profile nothing blocks the metrics on the Ingress
+	if instance.Spec.ExternalAccess.Enabled && instance.Spec.ExternalAccess.IngressProfile == kc.NoneIngressProfile && common.GetExternalAccessMode(instance) == kc.IngressExternalAccessMode {
+		r.recorder.Event(instance, "Warning", "MetricsExposed", "the ingress profile none does not block the metrics of the master realm on the Ingress")
+	}
+
+	if instance.Spec.ExternalAccess.Enabled && instance.Spec.ExternalAccess.Mode == kc.GatewayExternalAccessMode {
+		if instance.Spec.ExternalAccess.Gateway.Name == "" {
+			return r.ManageError(instance, errors.Errorf("externalAccess.gateway.name is required if externalAccess.mode is gateway"))
+		}
+		if !common.IsGatewayRouteKindAvailable(instance) {
+			return r.ManageError(instance, errors.Errorf("the Gateway API route for tls termination %q is not available in the cluster", instance.Spec.ExternalAccess.TLSTermination))
+		}
+	}
+
+	if instance.Spec.CertManager.Enabled {
+		if instance.Spec.CertManager.IssuerName == "" {
+			return r.ManageError(instance, errors.Errorf("certManager.issuerName is required if certManager.enabled is true"))
+		}
+		if certificateKindExists, _ := common.GetStateManager().GetState(common.CertificateKind).(bool); !certificateKindExists {
+			return r.ManageError(instance, errors.Errorf("cert-manager is not available in the cluster"))
+		}
+	}
+
+	if instance.Spec.Autoscaling.Enabled {
+		if instance.Spec.Autoscaling.MaxReplicas < 1 || instance.Spec.Autoscaling.MaxReplicas < model.KeycloakAutoscalingMinReplicas(instance) {
+			return r.ManageError(instance, errors.Errorf("autoscaling.maxReplicas needs to be at least 1 and at least autoscaling.minReplicas"))
+		}
+		if hpaKindExists, _ := common.GetStateManager().GetState(common.HorizontalPodAutoscalerKind).(bool); !hpaKindExists {
+			return r.ManageError(instance, errors.Errorf("the autoscaling/v2 HorizontalPodAutoscaler is not available in the cluster"))
+		}
+	}
+
+	for _, pdb := range []kc.PodDisruptionBudgetConfig{instance.Spec.PodDisruptionBudget, instance.Spec.PostgresDeploymentSpec.PodDisruptionBudget} {
+		if pdb.MinAvailable != nil && pdb.MaxUnavailable != nil {
+			return r.ManageError(instance, errors.Errorf("only one of minAvailable and maxUnavailable can be set in a podDisruptionBudget"))
+		}
+	}
+
+	if instance.Spec.Migration.RollbackOnFailure && !instance.Spec.Migration.Backups.Enabled {
+		return r.ManageError(instance, errors.Errorf("migration.rollbackOnFailure requires migration.backups.enabled"))
+	}
+
+	if err := common.ValidateMaintenanceWindow(instance.Spec.Migration.MaintenanceWindow); err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	if err := model.ValidatePostgresql(instance); err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	if err := model.ValidateExtensionSources(instance); err != nil {
+		return r.ManageError(instance, err)
+	}
+
 	// Read current state
-	err = currentState.Read(r.context, instance, r.client)
+	err = currentState.Read(ctx, instance, r.client)
 	if err != nil {
 		return r.ManageError(instance, err)
 	}
@@ -184,18 +337,27 @@ func (r *ReconcileKeycloak) Reconcile(request reconcile.Request) (reconcile.Resu
 	reconciler := NewKeycloakReconciler()
 	desiredState := reconciler.Reconcile(currentState, instance)
 
-	// Perform migration if needed
-	migrator, err := GetMigrator(instance)
-	if err != nil {
-		return r.ManageError(instance, err)
+	// Perform migration if needed, Keycloak isn't migrated while the embedded PostgreSQL is upgraded
+	if !postgresqlUpgradeActive(instance) {
+		migrator, err := GetMigrator(instance)
+		if err != nil {
+			return r.ManageError(instance, err)
+		}
+		desiredState, err = migrator.Migrate(instance, currentState, desiredState)
+		if err != nil {
+			return r.ManageError(instance, err)
+		}
 	}
-	desiredState, err = migrator.Migrate(instance, currentState, desiredState)
-	if err != nil {
-		return r.ManageError(instance, err)
+
+	if !instance.Spec.ExternalDatabase.Enabled {
+		desiredState, err = upgradePostgresql(instance, currentState, desiredState)
+		if err != nil {
+			return r.ManageError(instance, err)
+		}
 	}
 
 	// Run the actions to reach the desired state
-	actionRunner := common.NewClusterActionRunner(r.context, r.client, r.scheme, instance)
+	actionRunner := common.NewClusterActionRunner(ctx, r.client, r.scheme, instance)
 	err = actionRunner.RunAll(desiredState)
 	if err != nil {
 		return r.ManageError(instance, err)
@@ -204,8 +366,40 @@ func (r *ReconcileKeycloak) Reconcile(request reconcile.Request) (reconcile.Resu
 	return r.ManageSuccess(instance, currentState)
 }
 
+// Checks if resources with the fixed names of earlier operator versions were created for the instance
+func (r *ReconcileKeycloak) ownsLegacyNamedResources(instance *kc.Keycloak) (bool, error) {
+	for selector, object := range model.LegacyNamedResources(instance) {
+		err := r.client.Get(r.context, selector, object)
+		if err != nil {
+			if kubeerrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+
+		if model.IsLegacyNamedResource(instance, object.(metav1.Object)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Checks if the operator created the Ingress of the instance
+func (r *ReconcileKeycloak) ownsIngress(instance *kc.Keycloak) (bool, error) {
+	ingress := &networkingv1.Ingress{}
+	err := r.client.Get(r.context, model.KeycloakIngressSelector(instance), ingress)
+	if err != nil {
+		if kubeerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return model.IsInstanceResource(instance, ingress), nil
+}
+
 func (r *ReconcileKeycloak) ManageError(instance *kc.Keycloak, issue error) (reconcile.Result, error) {
 	r.recorder.Event(instance, "Warning", "ProcessingError", issue.Error())
+	common.RecordReconcileResult(ControllerName, instance.Namespace, instance.Name, issue)
 
 	instance.Status.Message = issue.Error()
 	instance.Status.Ready = false
@@ -233,6 +427,7 @@ func (r *ReconcileKeycloak) ManageSuccess(instance *kc.Keycloak, currentState *c
 
 	instance.Status.Ready = resourcesReady
 	instance.Status.Message = ""
+	common.RecordReconcileResult(ControllerName, instance.Namespace, instance.Name, nil)
 
 	// If resources are ready and we have not errored before now, we are in a reconciling phase
 	if resourcesReady {
@@ -250,10 +445,19 @@ func (r *ReconcileKeycloak) ManageSuccess(instance *kc.Keycloak, currentState *c
 
 	if instance.Spec.External.URL != "" {
 		instance.Status.ExternalURL = instance.Spec.External.URL
-	} else if currentState.KeycloakRoute != nil && currentState.KeycloakRoute.Spec.Host != "" {
-		instance.Status.ExternalURL = fmt.Sprintf("https://%v", currentState.KeycloakRoute.Spec.Host)
-	} else if currentState.KeycloakIngress != nil && currentState.KeycloakIngress.Spec.Rules[0].Host != "" {
-		instance.Status.ExternalURL = fmt.Sprintf("https://%v", currentState.KeycloakIngress.Spec.Rules[0].Host)
+	} else if host := externalAccessHost(instance, currentState); host != "" {
+		instance.Status.ExternalURL = fmt.Sprintf("https://%v", host)
+	}
+
+	// Report the deployed image and the digest its pods run
+	if currentState.KeycloakDeployment != nil {
+		instance.Status.Image = currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
+		instance.Status.ImageDigest = model.KeycloakImageDigest(instance.Status.Image, currentState.KeycloakPods)
+	}
+
+	// Report the storage of the embedded PostgreSQL
+	if instance.Status.Postgres != nil && currentState.PostgresqlPersistentVolumeClaim != nil {
+		instance.Status.Postgres.StorageCapacity, instance.Status.Postgres.StorageMessage = model.PostgresqlStorageStatus(currentState.PostgresqlPersistentVolumeClaim)
 	}
 
 	// Let the clients know where the admin credentials are stored
@@ -279,3 +483,26 @@ func (r *ReconcileKeycloak) ManageSuccess(instance *kc.Keycloak, currentState *c
 func (r *ReconcileKeycloak) setVersion(instance *kc.Keycloak) {
 	instance.Status.Version = version.Version
 }
+
+// Returns the host of the resource exposing Keycloak in the current external access mode. The resources of the other
+// modes may still exist until they are removed.
+func externalAccessHost(instance *kc.Keycloak, currentState *common.ClusterState) string {
+	switch common.GetExternalAccessMode(instance) {
+	case kc.RouteExternalAccessMode:
+		if currentState.KeycloakRoute != nil {
+			return currentState.KeycloakRoute.Spec.Host
+		}
+	case kc.GatewayExternalAccessMode:
+		if hostnames := model.KeycloakGatewayRouteHostnames(currentState.KeycloakHTTPRoute); len(hostnames) > 0 {
+			return hostnames[0]
+		}
+		if hostnames := model.KeycloakGatewayRouteHostnames(currentState.KeycloakTLSRoute); len(hostnames) > 0 {
+			return hostnames[0]
+		}
+	default:
+		if currentState.KeycloakIngress != nil && len(currentState.KeycloakIngress.Spec.Rules) > 0 {
+			return currentState.KeycloakIngress.Spec.Rules[0].Host
+		}
+	}
+	return ""
+}
@@ -4,6 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
@@ -15,12 +19,23 @@ import (
 var errBackup = errors.New("migrate backup fails")
 var errNoMigrator = errors.New("migrator not found")
 var errSelectorCantBeMigrated = errors.New("statefulSet Selector mismatch; please use Recreate migration strategy")
+var errNoRollbackBackup = errors.New("migrate backup to roll back to not found")
+var errRollback = errors.New("restore of migrate backup fails")
+var errMajorVersionCantBeRolled = errors.New("migration across major versions changes the database schema; please use Recreate migration strategy or enable fallbackToRecreate")
+
+// Time Keycloak has to become ready after the new image was deployed, unless configured otherwise
+const defaultMigrationReadinessTimeout = 10 * time.Minute
+
+// The leading number of an image tag, e.g. 15 of 15.0.2 or v15
+var imageMajorVersionRegexp = regexp.MustCompile(`^v?([0-9]+)`)
 
 type Migrator interface {
 	Migrate(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error)
 }
 
 type RecreateMigrator struct {
+	// Reason reported in the migration plan, set if the recreate strategy wasn't configured
+	reason string
 }
 
 type RollingMigrator struct {
@@ -38,7 +53,10 @@ func GetMigrator(cr *v1alpha1.Keycloak) (Migrator, error) {
 }
 
 func (i *RecreateMigrator) Migrate(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
-	deployment, deploymentIndex := findDeployment(&desiredState)
+	deployment, deploymentIndex := findDeployment(cr, &desiredState)
+	if held, err := holdDisruptiveChanges(cr, currentState, deployment); held || err != nil {
+		return desiredState, err
+	}
 
 	// We can't modify existing selector on StatefulSet.
 	// The selector might be wrongly set by e.g. RH-SSO 7.5.2.
@@ -74,40 +92,81 @@ func (i *RecreateMigrator) Migrate(cr *v1alpha1.Keycloak, currentState *common.C
 		// no need to return now, we can let the DB backup to proceed
 	}
 
-	if needsImageMigration(cr, currentState) {
-		desiredImage := model.Profiles.GetKeycloakOrRHSSOImage(cr)
-		log.Info(fmt.Sprintf("Performing migration from '%s' to '%s'", currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image, desiredImage))
-
-		// The backup should be made when Keycloak container is down.
-		// This way, we minimize the chance of skipping important updated
-		// that administrators/users could have made just before the backup.
-		//
-		// The current replicas status is checked here in desired state instead
-		// of current state which is actually correct. KC controller creates
-		// the desired state from current state, i.e. clones current replicas
-		// status into the desired state.
-		if deployment != nil && deployment.Status.Replicas > 0 {
-			scaleDownAndDontUpgradeImage(deployment, currentState)
-			return desiredState, nil
-		}
+	if plan := activeMigration(cr); plan != nil {
+		return trackMigration(cr, plan, currentState, desiredState, deployment)
+	}
+	if !needsImageMigration(cr, currentState) {
+		clearPendingMigration(cr)
+		return desiredState, nil
+	}
 
-		if cr.Spec.Migration.Backups.Enabled {
-			var err error
-			desiredState, err = oneTimeLocalBackup(cr, currentState, desiredState)
-			if err != nil {
-				return desiredState, err
-			}
-		}
+	desiredImage := model.Profiles.GetKeycloakOrRHSSOImage(cr)
+	log.Info(fmt.Sprintf("Performing migration from '%s' to '%s'", currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image, desiredImage))
+	plan := migrationPlan(cr, currentState, v1alpha1.StrategyRecreate, i.reason)
+	cr.Status.Migration = plan
+
+	// The backup should be made when Keycloak container is down.
+	// This way, we minimize the chance of skipping important updated
+	// that administrators/users could have made just before the backup.
+	//
+	// The current replicas status is checked here in desired state instead
+	// of current state which is actually correct. KC controller creates
+	// the desired state from current state, i.e. clones current replicas
+	// status into the desired state.
+	if deployment != nil && deployment.Status.Replicas > 0 {
+		scaleDownAndDontUpgradeImage(deployment, currentState)
+		return desiredState, nil
+	}
+
+	if cr.Spec.Migration.Backups.Enabled && !migrationBackupCreated(currentState) {
+		return oneTimeLocalBackup(cr, currentState, desiredState)
 	}
 
+	startUpgrade(plan)
 	return desiredState, nil
 }
 
 func (i *RollingMigrator) Migrate(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
-	deployment, _ := findDeployment(&desiredState)
+	deployment, _ := findDeployment(cr, &desiredState)
 	if needsStatefulSetRecreation(currentState, deployment) {
 		return nil, errSelectorCantBeMigrated
 	}
+	if held, err := holdDisruptiveChanges(cr, currentState, deployment); held || err != nil {
+		return desiredState, err
+	}
+
+	if plan := activeMigration(cr); plan != nil {
+		return trackMigration(cr, plan, currentState, desiredState, deployment)
+	}
+	if !needsImageMigration(cr, currentState) {
+		clearPendingMigration(cr)
+		return desiredState, nil
+	}
+
+	// Old and new pods run side by side during a rolling migration, they can't share a database whose schema is
+	// changed by the new version
+	deployedImage := currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
+	desiredImage := model.Profiles.GetKeycloakOrRHSSOImage(cr)
+	if changesMajorVersion(deployedImage, desiredImage) {
+		if cr.Spec.Migration.FallbackToRecreate {
+			log.Info(fmt.Sprintf("Falling back to the recreate strategy for the migration from '%s' to '%s'", deployedImage, desiredImage))
+			reason := "rolling migration across major versions fell back to recreate"
+			return (&RecreateMigrator{reason: reason}).Migrate(cr, currentState, desiredState)
+		}
+		plan := migrationPlan(cr, currentState, v1alpha1.StrategyRolling, errMajorVersionCantBeRolled.Error())
+		plan.Phase = v1alpha1.MigrationPhaseFailed
+		cr.Status.Migration = plan
+		return nil, errMajorVersionCantBeRolled
+	}
+
+	log.Info(fmt.Sprintf("Performing rolling migration from '%s' to '%s'", deployedImage, desiredImage))
+	plan := migrationPlan(cr, currentState, v1alpha1.StrategyRolling, "")
+	cr.Status.Migration = plan
+	if cr.Spec.Migration.Backups.Enabled && !migrationBackupCreated(currentState) {
+		return rollingBackup(cr, currentState, desiredState, deployment)
+	}
+
+	startUpgrade(plan)
 	return desiredState, nil
 }
 
@@ -128,13 +187,13 @@ func needsStatefulSetRecreation(currentState *common.ClusterState, desiredDeploy
 	return !reflect.DeepEqual(currentState.KeycloakDeployment.Spec.Selector.MatchLabels, desiredDeployment.Spec.Selector.MatchLabels)
 }
 
-func findDeployment(desiredState *common.DesiredClusterState) (*v13.StatefulSet, int) {
+func findDeployment(cr *v1alpha1.Keycloak, desiredState *common.DesiredClusterState) (*v13.StatefulSet, int) {
 	for i, v := range *desiredState {
 		if (reflect.TypeOf(v) == reflect.TypeOf(common.GenericUpdateAction{})) {
 			updateAction := v.(common.GenericUpdateAction)
 			if (reflect.TypeOf(updateAction.Ref) == reflect.TypeOf(&v13.StatefulSet{})) {
 				statefulSet := updateAction.Ref.(*v13.StatefulSet)
-				if statefulSet.ObjectMeta.Name == model.KeycloakDeploymentName {
+				if statefulSet.ObjectMeta.Name == model.KeycloakDeploymentName(cr) {
 					return statefulSet, i
 				}
 			}
@@ -143,27 +202,244 @@ func findDeployment(desiredState *common.DesiredClusterState) (*v13.StatefulSet,
 	return nil, 0
 }
 
+// Keeps the deployed image and selector while the maintenance window is closed. Other changes of the StatefulSet are
+// applied. Returns true if disruptive changes are held.
+func holdDisruptiveChanges(cr *v1alpha1.Keycloak, currentState *common.ClusterState, deployment *v13.StatefulSet) (bool, error) {
+	cr.Status.PendingMaintenance = nil
+	window := cr.Spec.Migration.MaintenanceWindow
+	if window == nil || deployment == nil || disruptionStarted(cr, currentState) {
+		return false, nil
+	}
+
+	var changes []string
+	if needsImageMigration(cr, currentState) {
+		changes = append(changes, fmt.Sprintf("migration from '%s' to '%s'", currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image, model.Profiles.GetKeycloakOrRHSSOImage(cr)))
+	}
+	if needsStatefulSetRecreation(currentState, deployment) {
+		changes = append(changes, "recreation of the StatefulSet with a new selector")
+	}
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	open, next, err := common.IsMaintenanceWindowOpen(window, time.Now())
+	if err != nil || open {
+		return false, err
+	}
+
+	log.Info(fmt.Sprintf("Holding %s until the maintenance window opens", strings.Join(changes, " and ")))
+	deployment.Spec.Template.Spec.Containers[0].Image = currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
+	deployment.Spec.Selector = currentState.KeycloakDeployment.Spec.Selector
+	deployment.Spec.Template.Labels = currentState.KeycloakDeployment.Spec.Template.Labels
+	cr.Status.PendingMaintenance = &v1alpha1.PendingMaintenance{Changes: changes}
+	if !next.IsZero() {
+		cr.Status.PendingMaintenance.NextWindow = &metav1.Time{Time: next}
+	}
+	return true, nil
+}
+
+// Returns true if a disruptive change was started, it's completed even if the maintenance window closes meanwhile.
+// The StatefulSet is scaled down to zero before a recreate migration and the recreation of the StatefulSet.
+func disruptionStarted(cr *v1alpha1.Keycloak, currentState *common.ClusterState) bool {
+	plan := cr.Status.Migration
+	if plan != nil && plan.ToImage == model.Profiles.GetKeycloakOrRHSSOImage(cr) && (plan.UpgradeStarted != nil || plan.Phase != v1alpha1.MigrationPhaseFailed) {
+		return true
+	}
+	statefulSet := currentState.KeycloakDeployment
+	return statefulSet != nil && statefulSet.Spec.Replicas != nil && *statefulSet.Spec.Replicas == 0
+}
+
+// Returns true if both images have a version tag and the major versions differ
+func changesMajorVersion(deployedImage string, desiredImage string) bool {
+	deployedVersion, deployedOk := imageMajorVersion(deployedImage)
+	desiredVersion, desiredOk := imageMajorVersion(desiredImage)
+	return deployedOk && desiredOk && deployedVersion != desiredVersion
+}
+
+func imageMajorVersion(image string) (int, bool) {
+	// Digests don't carry a version
+	image = strings.SplitN(image, "@", 2)[0]
+	separator := strings.LastIndex(image, ":")
+	if separator < 0 || strings.Contains(image[separator:], "/") {
+		return 0, false
+	}
+
+	match := imageMajorVersionRegexp.FindStringSubmatch(image[separator+1:])
+	if match == nil {
+		return 0, false
+	}
+	version, err := strconv.Atoi(match[1])
+	return version, err == nil
+}
+
+func migrationPlan(cr *v1alpha1.Keycloak, currentState *common.ClusterState, strategy v1alpha1.MigrationStrategy, message string) *v1alpha1.MigrationPlan {
+	plan := &v1alpha1.MigrationPlan{
+		Strategy:  strategy,
+		FromImage: currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image,
+		ToImage:   model.Profiles.GetKeycloakOrRHSSOImage(cr),
+		Phase:     v1alpha1.MigrationPhaseUpgrading,
+		Message:   message,
+	}
+	if cr.Spec.Migration.Backups.Enabled {
+		plan.Backup = migrationBackupName(cr, plan)
+		plan.Phase = v1alpha1.MigrationPhaseBackingUp
+	}
+	return plan
+}
+
+// Every migration is backed up under a new name. A pending migration to the same image keeps its backup until the
+// image is deployed.
+func migrationBackupName(cr *v1alpha1.Keycloak, plan *v1alpha1.MigrationPlan) string {
+	pending := cr.Status.Migration
+	if pending != nil && pending.Backup != "" && pending.UpgradeStarted == nil && pending.ToImage == plan.ToImage {
+		return pending.Backup
+	}
+	return model.MigrateBackupName(cr) + "-" + time.Now().Format("20060102-150405")
+}
+
+// Returns the migration to the desired image once the image was deployed
+func activeMigration(cr *v1alpha1.Keycloak) *v1alpha1.MigrationPlan {
+	plan := cr.Status.Migration
+	if plan == nil || plan.UpgradeStarted == nil || plan.ToImage != model.Profiles.GetKeycloakOrRHSSOImage(cr) {
+		return nil
+	}
+	return plan
+}
+
+// Removes a migration that was abandoned before its image was deployed, finished migrations are kept in the status
+func clearPendingMigration(cr *v1alpha1.Keycloak) {
+	if cr.Status.Migration != nil && cr.Status.Migration.UpgradeStarted == nil {
+		cr.Status.Migration = nil
+	}
+}
+
+func startUpgrade(plan *v1alpha1.MigrationPlan) {
+	now := metav1.Now()
+	plan.Phase = v1alpha1.MigrationPhaseUpgrading
+	plan.UpgradeStarted = &now
+}
+
+// Follows the migration after the new image was deployed. The migration fails if Keycloak doesn't become ready in
+// time.
+func trackMigration(cr *v1alpha1.Keycloak, plan *v1alpha1.MigrationPlan, currentState *common.ClusterState, desiredState common.DesiredClusterState, deployment *v13.StatefulSet) (common.DesiredClusterState, error) {
+	switch plan.Phase {
+	case v1alpha1.MigrationPhaseSucceeded:
+		return desiredState, nil
+	case v1alpha1.MigrationPhaseFailed:
+		return rollback(cr, plan, currentState, desiredState, deployment)
+	}
+
+	timeout := migrationReadinessTimeout(cr)
+	switch {
+	case migrationReady(currentState.KeycloakDeployment, plan):
+		log.Info(fmt.Sprintf("Migration to '%s' succeeded", plan.ToImage))
+		plan.Phase = v1alpha1.MigrationPhaseSucceeded
+	case time.Since(plan.UpgradeStarted.Time) > timeout:
+		plan.Phase = v1alpha1.MigrationPhaseFailed
+		plan.Message = fmt.Sprintf("Keycloak didn't become ready within %v after deploying '%s'", timeout, plan.ToImage)
+		log.Info(plan.Message)
+		return rollback(cr, plan, currentState, desiredState, deployment)
+	case migrationRolledOut(currentState.KeycloakDeployment, plan):
+		plan.Phase = v1alpha1.MigrationPhaseVerifying
+	}
+	return desiredState, nil
+}
+
+func migrationReadinessTimeout(cr *v1alpha1.Keycloak) time.Duration {
+	if cr.Spec.Migration.ReadinessTimeoutSeconds > 0 {
+		return time.Duration(cr.Spec.Migration.ReadinessTimeoutSeconds) * time.Second
+	}
+	return defaultMigrationReadinessTimeout
+}
+
+// Returns true if all pods of the StatefulSet run the new image
+func migrationRolledOut(statefulSet *v13.StatefulSet, plan *v1alpha1.MigrationPlan) bool {
+	if statefulSet == nil || statefulSet.Spec.Template.Spec.Containers[0].Image != plan.ToImage {
+		return false
+	}
+	if statefulSet.Status.ObservedGeneration < statefulSet.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	return statefulSet.Status.Replicas == replicas && statefulSet.Status.UpdatedReplicas == replicas
+}
+
+// Returns true if all pods of the StatefulSet run the new image and are ready
+func migrationReady(statefulSet *v13.StatefulSet, plan *v1alpha1.MigrationPlan) bool {
+	return migrationRolledOut(statefulSet, plan) && statefulSet.Status.ReadyReplicas == statefulSet.Status.Replicas
+}
+
+// Restores the migration backup while Keycloak is scaled down and deploys the previous image again
+func rollback(cr *v1alpha1.Keycloak, plan *v1alpha1.MigrationPlan, currentState *common.ClusterState, desiredState common.DesiredClusterState, deployment *v13.StatefulSet) (common.DesiredClusterState, error) {
+	if !cr.Spec.Migration.RollbackOnFailure {
+		return desiredState, nil
+	}
+	if deployment != nil {
+		deployment.Spec.Template.Spec.Containers[0].Image = plan.FromImage
+	}
+	if plan.RolledBack {
+		return desiredState, nil
+	}
+
+	backup := currentState.KeycloakBackup
+	if plan.Backup == "" || backup == nil {
+		return nil, errNoRollbackBackup
+	}
+	if backup.Status.Phase == v1alpha1.BackupPhaseRestored {
+		log.Info(fmt.Sprintf("Rolled back to '%s'", plan.FromImage))
+		plan.RolledBack = true
+		return desiredState, nil
+	}
+
+	if deployment != nil {
+		deployment.Spec.Replicas = &[]int32{0}[0]
+	}
+	switch {
+	case currentState.KeycloakDeployment != nil && currentState.KeycloakDeployment.Status.Replicas > 0:
+		log.Info("Number of replicas decreased to 0 for the rollback")
+	case backup.Spec.RestoreGeneration == 0:
+		restore := backup.DeepCopy()
+		restore.Spec.RestoreGeneration = 1
+		desiredState = desiredState.AddAction(common.GenericUpdateAction{
+			Ref: restore,
+			Msg: "Restore migrate backup",
+		})
+	case backup.Status.Phase == v1alpha1.BackupPhaseFailing:
+		return nil, errRollback
+	default:
+		log.Info("wait for migrate backup's restore")
+	}
+	return desiredState, nil
+}
+
 func scaleDownAndDontUpgradeImage(deployment *v13.StatefulSet, currentState *common.ClusterState) {
 	log.Info("Number of replicas decreased to 0")
 	deployment.Spec.Replicas = &[]int32{0}[0]
 	deployment.Spec.Template.Spec.Containers[0].Image = currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
 }
 
+// The backup taken before a migration
+func migrationBackup(cr *v1alpha1.Keycloak) *v1alpha1.KeycloakBackup {
+	backupCr := &v1alpha1.KeycloakBackup{}
+	backupCr.Namespace = cr.Namespace
+	backupCr.Name = common.MigrationBackupName(cr)
+	labelSelect := metav1.LabelSelector{
+		MatchLabels: cr.Labels,
+	}
+	backupCr.Spec.InstanceSelector = &labelSelect
+	backupCr.Spec.StorageClassName = cr.Spec.StorageClassName
+	return model.KeycloakMigrationOneTimeBackup(backupCr)
+}
+
 func oneTimeLocalBackup(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
 	keycloakBackup := currentState.KeycloakBackup
 	switch {
 	case keycloakBackup == nil:
-		backupCr := &v1alpha1.KeycloakBackup{}
-		backupCr.Namespace = cr.Namespace
-		backupCr.Name = model.MigrateBackupName + "-" + common.BackupTime
-		labelSelect := metav1.LabelSelector{
-			MatchLabels: cr.Labels,
-		}
-		backupCr.Spec.InstanceSelector = &labelSelect
-		backupCr.Spec.StorageClassName = cr.Spec.StorageClassName
-
 		migrationBackupCR := common.GenericCreateAction{
-			Ref: model.KeycloakMigrationOneTimeBackup(backupCr),
+			Ref: migrationBackup(cr),
 			Msg: "Create Local Backup CR",
 		}
 
@@ -181,3 +457,27 @@ func oneTimeLocalBackup(cr *v1alpha1.Keycloak, currentState *common.ClusterState
 		return emptyDesiredState, nil
 	}
 }
+
+func migrationBackupCreated(currentState *common.ClusterState) bool {
+	return currentState.KeycloakBackup != nil && currentState.KeycloakBackup.Status.Phase == v1alpha1.BackupPhaseCreated
+}
+
+// The backup is taken while the old image keeps serving, the new image is rolled out once the backup was created
+func rollingBackup(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState, deployment *v13.StatefulSet) (common.DesiredClusterState, error) {
+	keycloakBackup := currentState.KeycloakBackup
+	if keycloakBackup != nil && keycloakBackup.Status.Phase == v1alpha1.BackupPhaseFailing {
+		return nil, errBackup
+	}
+
+	if deployment != nil {
+		deployment.Spec.Template.Spec.Containers[0].Image = currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
+	}
+	if keycloakBackup == nil {
+		return desiredState.AddAction(common.GenericCreateAction{
+			Ref: migrationBackup(cr),
+			Msg: "Create Local Backup CR",
+		}), nil
+	}
+	log.Info("wait for migrate backup's creating")
+	return desiredState, nil
+}
@@ -1,7 +1,10 @@
 package keycloak
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
@@ -9,6 +12,7 @@ import (
 	kcAssert "github.com/jaconi-io/keycloak-operator/test/assert"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const extraLabelName = "extra"
@@ -300,6 +304,511 @@ func TestKeycloakMigration_Test_Stateful_Set_Recreated_After_Downscale(t *testin
 	assert.NotEqual(t, "old_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
 }
 
+func TestKeycloakMigration_Test_Backup_Before_Rolling_Migration(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.MigrationStrategy = v1alpha1.StrategyRolling
+	cr.Spec.Migration.Backups.Enabled = true
+	migrator, _ := GetMigrator(cr)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakCurrentDeployment, 5, "old_image")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 5, "")
+
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, migratedActions, 2)
+	backup := migratedActions[1].(common.GenericCreateAction).Ref.(*v1alpha1.KeycloakBackup)
+	assert.True(t, strings.HasPrefix(backup.Name, model.MigrateBackupName(cr)+"-"), backup.Name)
+	kcAssert.ReplicasCount(t, migratedActions, 5)
+	assert.Equal(t, "old_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, &v1alpha1.MigrationPlan{
+		Strategy:  v1alpha1.StrategyRolling,
+		FromImage: "old_image",
+		ToImage:   model.Profiles.GetKeycloakOrRHSSOImage(cr),
+		Backup:    backup.Name,
+		Phase:     v1alpha1.MigrationPhaseBackingUp,
+	}, cr.Status.Migration)
+}
+
+func TestKeycloakMigration_Test_Second_Migration_Gets_New_Backup(t *testing.T) {
+	// given
+	cr, currentState, desiredState, keycloakDesiredDeployment := migrationInProgress(time.Hour, true)
+	cr.Status.Migration.Phase = v1alpha1.MigrationPhaseSucceeded
+	currentState.KeycloakBackup.Status.Phase = v1alpha1.BackupPhaseRestored
+	currentState.KeycloakDeployment.Status.Replicas = 0
+	keycloakDesiredDeployment.Status.Replicas = 0
+	cr.Spec.Image = "new_image"
+
+	// the backup of the first migration isn't read for the migration to the new image
+	assert.Empty(t, common.MigrationBackupName(cr))
+	currentState.KeycloakBackup = nil
+
+	// when
+	migratedActions, err := (&RecreateMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, migratedActions, 1)
+	backup := migratedActions[0].(common.GenericCreateAction).Ref.(*v1alpha1.KeycloakBackup)
+	assert.NotEqual(t, "migrate-backup", backup.Name)
+	assert.Equal(t, backup.Name, common.MigrationBackupName(cr))
+	assert.Equal(t, v1alpha1.MigrationPhaseBackingUp, cr.Status.Migration.Phase)
+	assert.Equal(t, "new_image", cr.Status.Migration.ToImage)
+	assert.NotEqual(t, "new_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+
+	// when
+	currentState.KeycloakBackup = backup
+	_, err = (&RecreateMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, backup.Name, cr.Status.Migration.Backup)
+}
+
+func TestKeycloakMigration_Test_Rolling_Migration_After_Backup(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.MigrationStrategy = v1alpha1.StrategyRolling
+	cr.Spec.Migration.Backups.Enabled = true
+	migrator, _ := GetMigrator(cr)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakCurrentDeployment, 5, "old_image")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 5, "")
+
+	backup := &v1alpha1.KeycloakBackup{}
+	backup.Status.Phase = v1alpha1.BackupPhaseCreated
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+		KeycloakBackup:     backup,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	kcAssert.ReplicasCount(t, migratedActions, 5)
+	assert.Equal(t, model.Profiles.GetKeycloakOrRHSSOImage(cr), keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestKeycloakMigration_Test_Rolling_Migration_Across_Major_Versions(t *testing.T) {
+	TMajorVersionMigration(t, false)
+}
+
+func TestKeycloakMigration_Test_Rolling_Migration_Across_Major_Versions_Falls_Back_To_Recreate(t *testing.T) {
+	TMajorVersionMigration(t, true)
+}
+
+func TMajorVersionMigration(t *testing.T, fallbackToRecreate bool) {
+	// given
+	image := model.Images.Images[model.KeycloakImage]
+	model.Images.Images[model.KeycloakImage] = "quay.io/keycloak/keycloak:16.1.0"
+	defer func() { model.Images.Images[model.KeycloakImage] = image }()
+
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.MigrationStrategy = v1alpha1.StrategyRolling
+	cr.Spec.Migration.FallbackToRecreate = fallbackToRecreate
+	migrator, _ := GetMigrator(cr)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakCurrentDeployment, 5, "quay.io/keycloak/keycloak:15.0.2")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 5, "")
+
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Equal(t, "quay.io/keycloak/keycloak:15.0.2", cr.Status.Migration.FromImage)
+	assert.Equal(t, "quay.io/keycloak/keycloak:16.1.0", cr.Status.Migration.ToImage)
+	if fallbackToRecreate {
+		assert.Nil(t, err)
+		kcAssert.ReplicasCount(t, migratedActions, 0)
+		assert.Equal(t, v1alpha1.StrategyRecreate, cr.Status.Migration.Strategy)
+		assert.NotEmpty(t, cr.Status.Migration.Message)
+	} else {
+		assert.EqualError(t, err, "migration across major versions changes the database schema; please use Recreate migration strategy or enable fallbackToRecreate")
+		assert.Nil(t, migratedActions)
+		assert.Equal(t, v1alpha1.StrategyRolling, cr.Status.Migration.Strategy)
+	}
+}
+
+func TestKeycloakMigration_Test_Image_Major_Version(t *testing.T) {
+	for image, expected := range map[string]int{
+		"quay.io/keycloak/keycloak:15.0.2":             15,
+		"registry:5000/keycloak:v16":                   16,
+		"quay.io/keycloak/keycloak:17.0.0@sha256:abcd": 17,
+	} {
+		version, ok := imageMajorVersion(image)
+		assert.True(t, ok, image)
+		assert.Equal(t, expected, version, image)
+	}
+	for _, image := range []string{"quay.io/keycloak/keycloak:legacy", "registry:5000/keycloak", "old_image"} {
+		_, ok := imageMajorVersion(image)
+		assert.False(t, ok, image)
+	}
+}
+
+func TestKeycloakMigration_Test_Upgrade_Started_After_Backup(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.Backups.Enabled = true
+	migrator, _ := GetMigrator(cr)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakCurrentDeployment, 0, "old_image")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakDesiredDeployment, 0, "")
+
+	backup := &v1alpha1.KeycloakBackup{}
+	backup.Status.Phase = v1alpha1.BackupPhaseCreated
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+		KeycloakBackup:     backup,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	assert.Equal(t, v1alpha1.MigrationPhaseUpgrading, cr.Status.Migration.Phase)
+	assert.Equal(t, "old_image", cr.Status.Migration.FromImage)
+	assert.NotNil(t, cr.Status.Migration.UpgradeStarted)
+}
+
+func TestKeycloakMigration_Test_Migration_Verifying_Rolled_Out_Image(t *testing.T) {
+	// given
+	cr, currentState, desiredState, _ := migrationInProgress(0, false)
+	currentState.KeycloakDeployment.Status.ReadyReplicas = 1
+
+	// when
+	migratedActions, err := (&RecreateMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	assert.Equal(t, v1alpha1.MigrationPhaseVerifying, cr.Status.Migration.Phase)
+}
+
+func TestKeycloakMigration_Test_Migration_Succeeded_When_Ready(t *testing.T) {
+	// given
+	cr, currentState, desiredState, _ := migrationInProgress(0, false)
+	currentState.KeycloakDeployment.Status.ReadyReplicas = 3
+
+	// when
+	migratedActions, err := (&RollingMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	assert.Equal(t, v1alpha1.MigrationPhaseSucceeded, cr.Status.Migration.Phase)
+}
+
+func TestKeycloakMigration_Test_Migration_Fails_After_Readiness_Timeout(t *testing.T) {
+	// given
+	cr, currentState, desiredState, keycloakDesiredDeployment := migrationInProgress(time.Hour, false)
+
+	// when
+	migratedActions, err := (&RecreateMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	assert.Equal(t, v1alpha1.MigrationPhaseFailed, cr.Status.Migration.Phase)
+	assert.Equal(t, "Keycloak didn't become ready within 10m0s after deploying 'quay.io/keycloak/keycloak:legacy'", cr.Status.Migration.Message)
+	kcAssert.ReplicasCount(t, migratedActions, 3)
+	assert.Equal(t, "quay.io/keycloak/keycloak:legacy", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestKeycloakMigration_Test_Rollback_Scales_Down(t *testing.T) {
+	// given
+	cr, currentState, desiredState, keycloakDesiredDeployment := migrationInProgress(time.Hour, true)
+
+	// when
+	migratedActions, err := (&RecreateMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	assert.Equal(t, v1alpha1.MigrationPhaseFailed, cr.Status.Migration.Phase)
+	kcAssert.ReplicasCount(t, migratedActions, 0)
+	assert.Equal(t, "old_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestKeycloakMigration_Test_Rollback_Restores_Backup(t *testing.T) {
+	// given
+	cr, currentState, desiredState, _ := migrationInProgress(time.Hour, true)
+	cr.Status.Migration.Phase = v1alpha1.MigrationPhaseFailed
+	currentState.KeycloakDeployment.Status.Replicas = 0
+
+	// when
+	migratedActions, err := (&RecreateMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, migratedActions, 2)
+	restore := migratedActions[1].(common.GenericUpdateAction).Ref.(*v1alpha1.KeycloakBackup)
+	assert.Equal(t, int64(1), restore.Spec.RestoreGeneration)
+	assert.Zero(t, currentState.KeycloakBackup.Spec.RestoreGeneration)
+	kcAssert.ReplicasCount(t, migratedActions, 0)
+	assert.False(t, cr.Status.Migration.RolledBack)
+}
+
+func TestKeycloakMigration_Test_Rollback_Deploys_Previous_Image_After_Restore(t *testing.T) {
+	// given
+	cr, currentState, desiredState, keycloakDesiredDeployment := migrationInProgress(time.Hour, true)
+	cr.Status.Migration.Phase = v1alpha1.MigrationPhaseFailed
+	currentState.KeycloakDeployment.Status.Replicas = 0
+	currentState.KeycloakBackup.Spec.RestoreGeneration = 1
+	currentState.KeycloakBackup.Status.Phase = v1alpha1.BackupPhaseRestored
+
+	// when
+	migratedActions, err := (&RecreateMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	assert.True(t, cr.Status.Migration.RolledBack)
+	kcAssert.ReplicasCount(t, migratedActions, 3)
+	assert.Equal(t, "old_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestKeycloakMigration_Test_Rollback_Without_Backup(t *testing.T) {
+	// given
+	cr, currentState, desiredState, _ := migrationInProgress(time.Hour, true)
+	currentState.KeycloakBackup = nil
+
+	// when
+	migratedActions, err := (&RecreateMigrator{}).Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.EqualError(t, err, "migrate backup to roll back to not found")
+	assert.Nil(t, migratedActions)
+}
+
+// Returns the state of a migration whose new image was deployed the given time ago. All 3 pods run the new image,
+// none is ready.
+func migrationInProgress(started time.Duration, rollbackOnFailure bool) (*v1alpha1.Keycloak, *common.ClusterState, common.DesiredClusterState, *v1.StatefulSet) {
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Instances = 3
+	cr.Spec.Migration.Backups.Enabled = true
+	cr.Spec.Migration.RollbackOnFailure = rollbackOnFailure
+	upgradeStarted := metav1.NewTime(time.Now().Add(-started))
+	cr.Status.Migration = &v1alpha1.MigrationPlan{
+		Strategy:       v1alpha1.StrategyRecreate,
+		FromImage:      "old_image",
+		ToImage:        model.Profiles.GetKeycloakOrRHSSOImage(cr),
+		Backup:         "migrate-backup",
+		Phase:          v1alpha1.MigrationPhaseUpgrading,
+		UpgradeStarted: &upgradeStarted,
+	}
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakCurrentDeployment, 3, "")
+	keycloakCurrentDeployment.Status.UpdatedReplicas = 3
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+
+	backup := &v1alpha1.KeycloakBackup{}
+	backup.Name = "migrate-backup"
+	backup.Status.Phase = v1alpha1.BackupPhaseCreated
+	currentState := &common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+		KeycloakBackup:     backup,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+	return cr, currentState, desiredState, keycloakDesiredDeployment
+}
+
+func TestKeycloakMigration_Test_Migration_Held_Until_Maintenance_Window(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.MaintenanceWindow = closedMaintenanceWindow()
+	migrator, _ := GetMigrator(cr)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakCurrentDeployment, 3, "old_image")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+	keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Env = nil
+
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	kcAssert.ReplicasCount(t, migratedActions, 3)
+	assert.Equal(t, "old_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+	// non-disruptive changes are applied
+	assert.Nil(t, keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Env)
+	assert.Nil(t, cr.Status.Migration)
+	assert.Equal(t, []string{"migration from 'old_image' to '" + model.Profiles.GetKeycloakOrRHSSOImage(cr) + "'"}, cr.Status.PendingMaintenance.Changes)
+	assert.True(t, cr.Status.PendingMaintenance.NextWindow.After(time.Now()))
+}
+
+func TestKeycloakMigration_Test_Stateful_Set_Recreation_Held_Until_Maintenance_Window(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Instances = 3
+	cr.Spec.Migration.MaintenanceWindow = closedMaintenanceWindow()
+	migrator, _ := GetMigrator(cr)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	keycloakCurrentDeployment.Spec.Selector.MatchLabels[extraLabelName] = extraLabelValue
+	SetDeployment(keycloakCurrentDeployment, 3, "")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	kcAssert.ReplicasCount(t, migratedActions, 3)
+	kcAssert.KcDeploymentUpdated(t, migratedActions, keycloakDesiredDeployment, true)
+	kcAssert.KcDeploymentRecreated(t, migratedActions, keycloakDesiredDeployment, false)
+	assert.Equal(t, extraLabelValue, keycloakDesiredDeployment.Spec.Selector.MatchLabels[extraLabelName])
+	assert.Equal(t, []string{"recreation of the StatefulSet with a new selector"}, cr.Status.PendingMaintenance.Changes)
+}
+
+func TestKeycloakMigration_Test_Migration_In_Open_Maintenance_Window(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.MaintenanceWindow = &v1alpha1.MaintenanceWindow{Schedule: "* * * * *"}
+	cr.Status.PendingMaintenance = &v1alpha1.PendingMaintenance{Changes: []string{"migration"}}
+	migrator, _ := GetMigrator(cr)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakCurrentDeployment, 3, "old_image")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	kcAssert.ReplicasCount(t, migratedActions, 0)
+	assert.Nil(t, cr.Status.PendingMaintenance)
+	assert.Equal(t, "old_image", cr.Status.Migration.FromImage)
+}
+
+func TestKeycloakMigration_Test_Started_Migration_Continues_After_Maintenance_Window(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.MaintenanceWindow = closedMaintenanceWindow()
+	migrator, _ := GetMigrator(cr)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakCurrentDeployment, 0, "old_image")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 0, "")
+
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, migratedActions)
+	assert.Nil(t, cr.Status.PendingMaintenance)
+	assert.Equal(t, model.Profiles.GetKeycloakOrRHSSOImage(cr), keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, v1alpha1.MigrationPhaseUpgrading, cr.Status.Migration.Phase)
+}
+
+// A window opening in 12 hours, closed for the next 11 hours
+func closedMaintenanceWindow() *v1alpha1.MaintenanceWindow {
+	opens := time.Now().UTC().Add(12 * time.Hour)
+	return &v1alpha1.MaintenanceWindow{Schedule: fmt.Sprintf("%d %d * * *", opens.Minute(), opens.Hour())}
+}
+
 func SetDeployment(deployment *v1.StatefulSet, replicasCount int32, image string) {
 	deployment.Spec.Replicas = &[]int32{replicasCount}[0]
 	deployment.Status.Replicas = replicasCount
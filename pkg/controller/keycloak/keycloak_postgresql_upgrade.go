@@ -0,0 +1,331 @@
+package keycloak
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	v13 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Attempts to restore the dump into the new version, the restore runs in a single transaction
+const postgresqlUpgradeRestoreAttempts = 3
+
+var errPostgresqlUpgradeBackup = errors.New("backup before the upgrade of PostgreSQL fails")
+var errPostgresqlUpgradeJob = errors.New("moving the data directory for the upgrade of PostgreSQL fails; the volume needs the free space for a second copy of the data, see postgresDeploymentSpec.storageSize")
+var errNoPostgresqlUpgradeBackup = errors.New("backup to restore into the new version of PostgreSQL not found")
+var errPostgresqlUpgradeRestore = errors.New("restore into the new version of PostgreSQL fails; the data of the previous version is kept on the volume")
+
+// Upgrades the embedded PostgreSQL to a new major version. Keycloak is scaled down and the database is dumped into a
+// backup, the data directory of the previous version is moved aside, and the dump is restored into the new version.
+// A failed upgrade keeps running the previous version, it's retried after postgresVersion was reset.
+func upgradePostgresql(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
+	status, err := postgresqlStatus(cr, currentState)
+	if err != nil {
+		return nil, err
+	}
+	desiredVersion := model.PostgresqlVersion(cr)
+	plan := status.Upgrade
+
+	if postgresqlUpgradeActive(cr) {
+		// Keycloak isn't migrated meanwhile
+		cr.Status.PendingMaintenance = nil
+	}
+	if plan != nil {
+		switch plan.Phase {
+		case v1alpha1.PostgresUpgradePhaseBackingUp:
+			if desiredVersion == status.Version {
+				return clearPostgresqlUpgrade(cr, currentState, desiredState), nil
+			}
+			return backupBeforePostgresqlUpgrade(cr, plan, currentState, desiredState)
+		case v1alpha1.PostgresUpgradePhaseUpgrading:
+			return upgradePostgresqlDataDirectory(cr, plan, currentState, desiredState)
+		case v1alpha1.PostgresUpgradePhaseRestoring:
+			return restorePostgresqlUpgradeBackup(cr, plan, currentState, desiredState)
+		case v1alpha1.PostgresUpgradePhaseFailed:
+			if desiredVersion == plan.ToVersion {
+				setPostgresqlDeployment(cr, desiredState, plan.FromImage, 1)
+				return desiredState, nil
+			}
+			return clearPostgresqlUpgrade(cr, currentState, desiredState), nil
+		}
+	}
+
+	switch {
+	case desiredVersion == status.Version, currentState.PostgresqlDeployment == nil:
+		return desiredState, nil
+	case model.ComparePostgresqlVersions(desiredVersion, status.Version) < 0:
+		return nil, fmt.Errorf("postgresVersion %s is older than the version %s of the database; downgrades are not supported", desiredVersion, status.Version)
+	}
+
+	deployedImage := currentState.PostgresqlDeployment.Spec.Template.Spec.Containers[0].Image
+	if held, err := holdPostgresqlUpgrade(cr, status, currentState); held || err != nil {
+		setPostgresqlDeployment(cr, desiredState, deployedImage, 1)
+		return desiredState, err
+	}
+
+	log.Info(fmt.Sprintf("Upgrading PostgreSQL from %s to %s", status.Version, desiredVersion))
+	status.Upgrade = &v1alpha1.PostgresUpgrade{
+		FromVersion: status.Version,
+		ToVersion:   desiredVersion,
+		FromImage:   deployedImage,
+		ToImage:     model.Profiles.GetPostgresqlImage(cr),
+		Backup:      model.PostgresqlUpgradeBackupName(cr, status.Version),
+		Phase:       v1alpha1.PostgresUpgradePhaseBackingUp,
+	}
+	return backupBeforePostgresqlUpgrade(cr, status.Upgrade, currentState, desiredState)
+}
+
+// Returns true if an upgrade of PostgreSQL is in progress, Keycloak isn't migrated meanwhile
+func postgresqlUpgradeActive(cr *v1alpha1.Keycloak) bool {
+	if cr.Status.Postgres == nil || cr.Status.Postgres.Upgrade == nil {
+		return false
+	}
+	phase := cr.Status.Postgres.Upgrade.Phase
+	return phase != v1alpha1.PostgresUpgradePhaseSucceeded && phase != v1alpha1.PostgresUpgradePhaseFailed
+}
+
+// Returns the status of PostgreSQL with the version of the data directory. New databases are created with the
+// desired version, the version of existing databases is the one of the deployed image.
+func postgresqlStatus(cr *v1alpha1.Keycloak, currentState *common.ClusterState) (*v1alpha1.PostgresStatus, error) {
+	if cr.Status.Postgres == nil {
+		cr.Status.Postgres = &v1alpha1.PostgresStatus{}
+	}
+	status := cr.Status.Postgres
+	if status.Version != "" {
+		return status, nil
+	}
+
+	if currentState.PostgresqlPersistentVolumeClaim == nil || currentState.PostgresqlDeployment == nil {
+		status.Version = model.PostgresqlVersion(cr)
+		return status, nil
+	}
+	deployedImage := currentState.PostgresqlDeployment.Spec.Template.Spec.Containers[0].Image
+	status.Version = model.PostgresqlImageVersion(cr, deployedImage)
+	if status.Version == "" {
+		return nil, fmt.Errorf("the major version of PostgreSQL run by the image %q is unknown; set postgresImage to this image and postgresVersion to its major version", deployedImage)
+	}
+	return status, nil
+}
+
+// Keeps the deployed version while the maintenance window is closed or Keycloak is migrated
+func holdPostgresqlUpgrade(cr *v1alpha1.Keycloak, status *v1alpha1.PostgresStatus, currentState *common.ClusterState) (bool, error) {
+	if migration := cr.Status.Migration; migration != nil && migration.Phase != v1alpha1.MigrationPhaseSucceeded && migration.Phase != v1alpha1.MigrationPhaseFailed {
+		log.Info("The upgrade of PostgreSQL waits for the migration of Keycloak")
+		return true, nil
+	}
+
+	window := cr.Spec.Migration.MaintenanceWindow
+	if window == nil {
+		return false, nil
+	}
+	open, next, err := common.IsMaintenanceWindowOpen(window, time.Now())
+	if err != nil || open {
+		return false, err
+	}
+
+	change := fmt.Sprintf("upgrade of PostgreSQL from %s to %s", status.Version, model.PostgresqlVersion(cr))
+	log.Info(fmt.Sprintf("Holding %s until the maintenance window opens", change))
+	if cr.Status.PendingMaintenance == nil {
+		cr.Status.PendingMaintenance = &v1alpha1.PendingMaintenance{}
+	}
+	cr.Status.PendingMaintenance.Changes = append(cr.Status.PendingMaintenance.Changes, change)
+	if !next.IsZero() {
+		cr.Status.PendingMaintenance.NextWindow = &metav1.Time{Time: next}
+	}
+	return true, nil
+}
+
+// Dumps the database of the previous version while Keycloak is scaled down
+func backupBeforePostgresqlUpgrade(cr *v1alpha1.Keycloak, plan *v1alpha1.PostgresUpgrade, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
+	setPostgresqlDeployment(cr, desiredState, plan.FromImage, 1)
+	if keycloakRunning(cr, currentState, desiredState) {
+		return desiredState, nil
+	}
+
+	backup := currentState.PostgresqlUpgradeBackup
+	switch {
+	case backup == nil:
+		return desiredState.AddAction(common.GenericCreateAction{
+			Ref: postgresqlUpgradeBackup(cr, plan),
+			Msg: "Create Postgresql upgrade backup",
+		}), nil
+	case backup.Status.Phase == v1alpha1.BackupPhaseCreated:
+		log.Info(fmt.Sprintf("Backup before the upgrade of PostgreSQL to %s succeeds", plan.ToVersion))
+		plan.Phase = v1alpha1.PostgresUpgradePhaseUpgrading
+	case backup.Status.Phase == v1alpha1.BackupPhaseFailing:
+		failPostgresqlUpgrade(plan, errPostgresqlUpgradeBackup)
+		return nil, errPostgresqlUpgradeBackup
+	default:
+		log.Info("wait for the backup before the upgrade of PostgreSQL")
+	}
+	return desiredState, nil
+}
+
+// Moves the data directory of the previous version aside while PostgreSQL is scaled down and starts the new version
+func upgradePostgresqlDataDirectory(cr *v1alpha1.Keycloak, plan *v1alpha1.PostgresUpgrade, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
+	keycloakRunning(cr, currentState, desiredState)
+
+	job := currentState.PostgresqlUpgradeJob
+	switch {
+	case job == nil:
+		setPostgresqlDeployment(cr, desiredState, plan.FromImage, 0)
+		if currentState.PostgresqlDeployment != nil && currentState.PostgresqlDeployment.Status.Replicas > 0 {
+			log.Info("PostgreSQL scaled down for the upgrade")
+			return desiredState, nil
+		}
+		return desiredState.AddAction(common.GenericCreateAction{
+			Ref: model.PostgresqlUpgrade(cr),
+			Msg: "Create Postgresql upgrade job",
+		}), nil
+	case jobFailed(job):
+		failPostgresqlUpgrade(plan, errPostgresqlUpgradeJob)
+		return nil, errPostgresqlUpgradeJob
+	case job.Status.Succeeded == 0:
+		setPostgresqlDeployment(cr, desiredState, plan.FromImage, 0)
+		log.Info("wait for the data directory to be moved for the upgrade of PostgreSQL")
+		return desiredState, nil
+	}
+
+	// The new version initializes an empty database
+	setPostgresqlDeployment(cr, desiredState, plan.ToImage, 1)
+	if postgresqlReady(currentState.PostgresqlDeployment, plan.ToImage) {
+		log.Info(fmt.Sprintf("PostgreSQL %s started", plan.ToVersion))
+		plan.Phase = v1alpha1.PostgresUpgradePhaseRestoring
+	}
+	return desiredState, nil
+}
+
+// Restores the dump into the new version, Keycloak is started once the dump was restored
+func restorePostgresqlUpgradeBackup(cr *v1alpha1.Keycloak, plan *v1alpha1.PostgresUpgrade, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
+	setPostgresqlDeployment(cr, desiredState, plan.ToImage, 1)
+
+	backup := currentState.PostgresqlUpgradeBackup
+	switch {
+	case backup == nil:
+		keycloakRunning(cr, currentState, desiredState)
+		return nil, errNoPostgresqlUpgradeBackup
+	case backup.Status.Phase == v1alpha1.BackupPhaseRestored:
+		log.Info(fmt.Sprintf("Upgraded PostgreSQL from %s to %s", plan.FromVersion, plan.ToVersion))
+		plan.Phase = v1alpha1.PostgresUpgradePhaseSucceeded
+		plan.Message = fmt.Sprintf("the data of PostgreSQL %s is kept in %s/userdata-%s, remove it to free the space", plan.FromVersion, model.PostgresqlPersistentVolumeMountPath, plan.FromVersion)
+		cr.Status.Postgres.Version = plan.ToVersion
+		return desiredState, nil
+	}
+
+	keycloakRunning(cr, currentState, desiredState)
+	restoreFailed := backup.Status.FailedRestoreGeneration == backup.Spec.RestoreGeneration
+	switch {
+	case backup.Spec.RestoreGeneration == 0, restoreFailed && backup.Spec.RestoreGeneration < postgresqlUpgradeRestoreAttempts:
+		// Every restoreGeneration is restored by a new Job
+		restore := backup.DeepCopy()
+		restore.Spec.RestoreGeneration++
+		desiredState = desiredState.AddAction(common.GenericUpdateAction{
+			Ref: restore,
+			Msg: fmt.Sprintf("Restore Postgresql upgrade backup, attempt %d", restore.Spec.RestoreGeneration),
+		})
+	case restoreFailed:
+		// The previous version can't run on the moved data directory, the upgrade stays in the restoring phase
+		plan.Message = fmt.Sprintf("%v; the data of PostgreSQL %s is kept in userdata-%s, increase restoreGeneration of backup %s to retry", backup.Status.Message, plan.FromVersion, plan.FromVersion, backup.Name)
+		return nil, errPostgresqlUpgradeRestore
+	default:
+		log.Info("wait for the restore into the new version of PostgreSQL")
+	}
+	return desiredState, nil
+}
+
+// Removes an upgrade that failed or was abandoned before the data directory was moved, after postgresVersion was
+// reset. Its backup would be outdated when the upgrade is retried.
+func clearPostgresqlUpgrade(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) common.DesiredClusterState {
+	cr.Status.Postgres.Upgrade = nil
+	if backup := currentState.PostgresqlUpgradeBackup; backup != nil {
+		desiredState = desiredState.AddAction(common.GenericDeleteAction{
+			Ref: backup,
+			Msg: "Delete Postgresql upgrade backup",
+		})
+	}
+	if job := currentState.PostgresqlUpgradeJob; job != nil {
+		desiredState = desiredState.AddAction(common.GenericDeleteAction{
+			Ref: job,
+			Msg: "Delete Postgresql upgrade job",
+		})
+	}
+	return desiredState
+}
+
+func failPostgresqlUpgrade(plan *v1alpha1.PostgresUpgrade, err error) {
+	plan.Phase = v1alpha1.PostgresUpgradePhaseFailed
+	plan.Message = fmt.Sprintf("%v; reset postgresVersion to %s to retry", err, plan.FromVersion)
+	log.Info(plan.Message)
+}
+
+// The backup dumping the database before an upgrade
+func postgresqlUpgradeBackup(cr *v1alpha1.Keycloak, plan *v1alpha1.PostgresUpgrade) *v1alpha1.KeycloakBackup {
+	backupCr := &v1alpha1.KeycloakBackup{}
+	backupCr.Namespace = cr.Namespace
+	backupCr.Name = plan.Backup
+	backupCr.Spec.InstanceSelector = &metav1.LabelSelector{
+		MatchLabels: cr.Labels,
+	}
+	backupCr.Spec.StorageClassName = cr.Spec.StorageClassName
+	return model.KeycloakMigrationOneTimeBackup(backupCr)
+}
+
+// Scales Keycloak down and keeps its image, returns true until all pods are gone
+func keycloakRunning(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) bool {
+	deployment, _ := findDeployment(cr, &desiredState)
+	if deployment == nil || currentState.KeycloakDeployment == nil {
+		return false
+	}
+	scaleDownAndDontUpgradeImage(deployment, currentState)
+	return currentState.KeycloakDeployment.Status.Replicas > 0
+}
+
+// Runs the image of PostgreSQL with one or no replica
+func setPostgresqlDeployment(cr *v1alpha1.Keycloak, desiredState common.DesiredClusterState, image string, replicas int32) {
+	deployment := findPostgresqlDeployment(cr, desiredState)
+	if deployment == nil {
+		return
+	}
+	deployment.Spec.Replicas = &replicas
+	for i := range deployment.Spec.Template.Spec.InitContainers {
+		deployment.Spec.Template.Spec.InitContainers[i].Image = image
+	}
+	deployment.Spec.Template.Spec.Containers[0].Image = image
+}
+
+func findPostgresqlDeployment(cr *v1alpha1.Keycloak, desiredState common.DesiredClusterState) *v13.Deployment {
+	for _, action := range desiredState {
+		if updateAction, ok := action.(common.GenericUpdateAction); ok && reflect.TypeOf(updateAction.Ref) == reflect.TypeOf(&v13.Deployment{}) {
+			deployment := updateAction.Ref.(*v13.Deployment)
+			if deployment.Name == model.PostgresqlDeploymentName(cr) {
+				return deployment
+			}
+		}
+	}
+	return nil
+}
+
+// Returns true if the pod of PostgreSQL runs the image and is ready
+func postgresqlReady(deployment *v13.Deployment, image string) bool {
+	if deployment == nil || deployment.Spec.Template.Spec.Containers[0].Image != image || deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	return deployment.Status.Replicas == 1 && deployment.Status.UpdatedReplicas == 1 && deployment.Status.ReadyReplicas == 1
+}
+
+func jobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,424 @@
+package keycloak
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type postgresqlUpgradeTest struct {
+	cr                 *v1alpha1.Keycloak
+	currentState       *common.ClusterState
+	desiredState       common.DesiredClusterState
+	postgresql         *v1.Deployment
+	keycloak           *v1.StatefulSet
+	deployedPostgresql *v1.Deployment
+	deployedKeycloak   *v1.StatefulSet
+	postgresqlOldImage string
+	postgresqlNewImage string
+}
+
+// An instance running PostgreSQL 10 with one Keycloak pod, upgraded to 13
+func newPostgresqlUpgradeTest() *postgresqlUpgradeTest {
+	cr := &v1alpha1.Keycloak{}
+	cr.Name = "keycloak"
+	cr.Spec.PostgresVersion = "13"
+	cr.Status.Postgres = &v1alpha1.PostgresStatus{Version: "10"}
+
+	deployedPostgresql := model.PostgresqlDeployment(cr, false)
+	deployedPostgresql.Spec.Template.Spec.Containers[0].Image = model.DefaultPostgresqlImage
+	deployedPostgresql.Status.Replicas = 1
+	deployedKeycloak := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(deployedKeycloak, 1, "")
+
+	postgresql := model.PostgresqlDeploymentReconciled(cr, deployedPostgresql)
+	keycloak := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloak, 1, "")
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = desiredState.AddAction(common.GenericUpdateAction{Ref: keycloak})
+	desiredState = desiredState.AddAction(common.GenericUpdateAction{Ref: postgresql})
+
+	return &postgresqlUpgradeTest{
+		cr: cr,
+		currentState: &common.ClusterState{
+			PostgresqlPersistentVolumeClaim: model.PostgresqlPersistentVolumeClaim(cr),
+			PostgresqlDeployment:            deployedPostgresql,
+			KeycloakDeployment:              deployedKeycloak,
+		},
+		desiredState:       desiredState,
+		postgresql:         postgresql,
+		keycloak:           keycloak,
+		deployedPostgresql: deployedPostgresql,
+		deployedKeycloak:   deployedKeycloak,
+		postgresqlOldImage: model.DefaultPostgresqlImage,
+		postgresqlNewImage: model.DefaultPostgresqlImage13,
+	}
+}
+
+// Continues the upgrade in the phase, Keycloak is scaled down and PostgreSQL runs the image of the phase
+func (u *postgresqlUpgradeTest) inPhase(phase v1alpha1.PostgresUpgradePhase) *postgresqlUpgradeTest {
+	u.cr.Status.Postgres.Upgrade = &v1alpha1.PostgresUpgrade{
+		FromVersion: "10",
+		ToVersion:   "13",
+		FromImage:   u.postgresqlOldImage,
+		ToImage:     u.postgresqlNewImage,
+		Backup:      model.PostgresqlUpgradeBackupName(u.cr, "10"),
+		Phase:       phase,
+	}
+	u.deployedKeycloak.Status.Replicas = 0
+	u.currentState.PostgresqlUpgradeBackup = postgresqlUpgradeBackup(u.cr, u.cr.Status.Postgres.Upgrade)
+	u.currentState.PostgresqlUpgradeBackup.Status.Phase = v1alpha1.BackupPhaseCreated
+	if phase == v1alpha1.PostgresUpgradePhaseRestoring {
+		u.deployedPostgresql.Spec.Template.Spec.Containers[0].Image = u.postgresqlNewImage
+	}
+	return u
+}
+
+func TestPostgresqlUpgrade_Test_Fresh_Database_Uses_Desired_Version(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresVersion = "13"
+	desiredState := common.DesiredClusterState{}
+
+	// when
+	upgradedActions, err := upgradePostgresql(cr, &common.ClusterState{}, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, desiredState, upgradedActions)
+	assert.Equal(t, "13", cr.Status.Postgres.Version)
+	assert.Nil(t, cr.Status.Postgres.Upgrade)
+}
+
+func TestPostgresqlUpgrade_Test_Existing_Database_Runs_Default_Version(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest()
+	u.cr.Spec.PostgresVersion = ""
+	u.cr.Status.Postgres = nil
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, u.desiredState, upgradedActions)
+	assert.Equal(t, model.DefaultPostgresqlVersion, u.cr.Status.Postgres.Version)
+	assert.Nil(t, u.cr.Status.Postgres.Upgrade)
+}
+
+func TestPostgresqlUpgrade_Test_Existing_Database_Runs_Version_Of_Deployed_Image(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest()
+	u.cr.Status.Postgres = nil
+	u.deployedPostgresql.Spec.Template.Spec.Containers[0].Image = "registry.example.com/postgresql-13-custom:1"
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, u.desiredState, upgradedActions)
+	assert.Equal(t, "13", u.cr.Status.Postgres.Version)
+	assert.Nil(t, u.cr.Status.Postgres.Upgrade)
+}
+
+func TestPostgresqlUpgrade_Test_Existing_Database_With_Unknown_Image(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest()
+	u.cr.Status.Postgres = nil
+	u.deployedPostgresql.Spec.Template.Spec.Containers[0].Image = "registry.example.com/database:1"
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, upgradedActions)
+	assert.Empty(t, u.cr.Status.Postgres.Version)
+}
+
+func TestPostgresqlUpgrade_Test_Downgrade_Is_Refused(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest()
+	u.cr.Status.Postgres.Version = "15"
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, upgradedActions)
+}
+
+func TestPostgresqlUpgrade_Test_Keycloak_Scaled_Down_Before_Backup(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest()
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, u.desiredState, upgradedActions)
+	assert.Equal(t, int32(0), *u.keycloak.Spec.Replicas)
+	assert.Equal(t, u.postgresqlOldImage, u.postgresql.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, &v1alpha1.PostgresUpgrade{
+		FromVersion: "10",
+		ToVersion:   "13",
+		FromImage:   u.postgresqlOldImage,
+		ToImage:     u.postgresqlNewImage,
+		Backup:      "keycloak-migrate-backup-postgresql-10",
+		Phase:       v1alpha1.PostgresUpgradePhaseBackingUp,
+	}, u.cr.Status.Postgres.Upgrade)
+}
+
+func TestPostgresqlUpgrade_Test_Backup_Once_Keycloak_Is_Down(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseBackingUp)
+	u.currentState.PostgresqlUpgradeBackup = nil
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, upgradedActions, 3)
+	backup := upgradedActions[2].(common.GenericCreateAction).Ref.(*v1alpha1.KeycloakBackup)
+	assert.Equal(t, "keycloak-migrate-backup-postgresql-10", backup.Name)
+	assert.Equal(t, int32(0), *u.keycloak.Spec.Replicas)
+	assert.Equal(t, u.postgresqlOldImage, u.postgresql.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestPostgresqlUpgrade_Test_Failed_Backup_Keeps_Previous_Version(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseBackingUp)
+	u.currentState.PostgresqlUpgradeBackup.Status.Phase = v1alpha1.BackupPhaseFailing
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Equal(t, errPostgresqlUpgradeBackup, err)
+	assert.Nil(t, upgradedActions)
+	assert.Equal(t, v1alpha1.PostgresUpgradePhaseFailed, u.cr.Status.Postgres.Upgrade.Phase)
+	assert.Contains(t, u.cr.Status.Postgres.Upgrade.Message, "reset postgresVersion to 10 to retry")
+
+	// when
+	u = newPostgresqlUpgradeTest()
+	u.cr.Status.Postgres.Upgrade = &v1alpha1.PostgresUpgrade{ToVersion: "13", FromImage: u.postgresqlOldImage, Phase: v1alpha1.PostgresUpgradePhaseFailed}
+	upgradedActions, err = upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, u.desiredState, upgradedActions)
+	assert.Equal(t, int32(1), *u.keycloak.Spec.Replicas)
+	assert.Equal(t, int32(1), *u.postgresql.Spec.Replicas)
+	assert.Equal(t, u.postgresqlOldImage, u.postgresql.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestPostgresqlUpgrade_Test_Failed_Upgrade_Cleared_After_Reset(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseFailed)
+	u.cr.Spec.PostgresVersion = "10"
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Nil(t, u.cr.Status.Postgres.Upgrade)
+	assert.Len(t, upgradedActions, 3)
+	assert.Equal(t, u.currentState.PostgresqlUpgradeBackup, upgradedActions[2].(common.GenericDeleteAction).Ref)
+}
+
+func TestPostgresqlUpgrade_Test_Data_Directory_Moved_After_Scale_Down(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseUpgrading)
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, u.desiredState, upgradedActions)
+	assert.Equal(t, int32(0), *u.postgresql.Spec.Replicas)
+
+	// when
+	u.deployedPostgresql.Status.Replicas = 0
+	upgradedActions, err = upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, upgradedActions, 3)
+	job := upgradedActions[2].(common.GenericCreateAction).Ref.(*batchv1.Job)
+	assert.Equal(t, "keycloak-postgresql-upgrade-10", job.Name)
+	assert.Equal(t, u.postgresqlOldImage, job.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, model.PostgresqlPersistentVolumeName(u.cr), job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args[0], `[ "$FREE" -lt "$USED" ]`)
+}
+
+func TestPostgresqlUpgrade_Test_New_Version_Started_After_Data_Directory_Was_Moved(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseUpgrading)
+	u.currentState.PostgresqlUpgradeJob = model.PostgresqlUpgrade(u.cr)
+	u.currentState.PostgresqlUpgradeJob.Status.Succeeded = 1
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, u.desiredState, upgradedActions)
+	assert.Equal(t, int32(0), *u.keycloak.Spec.Replicas)
+	assert.Equal(t, int32(1), *u.postgresql.Spec.Replicas)
+	assert.Equal(t, u.postgresqlNewImage, u.postgresql.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, v1alpha1.PostgresUpgradePhaseUpgrading, u.cr.Status.Postgres.Upgrade.Phase)
+
+	// when
+	u.deployedPostgresql.Spec.Template.Spec.Containers[0].Image = u.postgresqlNewImage
+	u.deployedPostgresql.Status.UpdatedReplicas = 1
+	u.deployedPostgresql.Status.ReadyReplicas = 1
+	_, err = upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.PostgresUpgradePhaseRestoring, u.cr.Status.Postgres.Upgrade.Phase)
+}
+
+func TestPostgresqlUpgrade_Test_Failed_Job_Keeps_Previous_Version(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseUpgrading)
+	u.currentState.PostgresqlUpgradeJob = model.PostgresqlUpgrade(u.cr)
+	u.currentState.PostgresqlUpgradeJob.Status.Conditions = []batchv1.JobCondition{{
+		Type:   batchv1.JobFailed,
+		Status: corev1.ConditionTrue,
+	}}
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Equal(t, errPostgresqlUpgradeJob, err)
+	assert.Nil(t, upgradedActions)
+	assert.Equal(t, v1alpha1.PostgresUpgradePhaseFailed, u.cr.Status.Postgres.Upgrade.Phase)
+}
+
+func TestPostgresqlUpgrade_Test_Dump_Restored_Into_New_Version(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseRestoring)
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, upgradedActions, 3)
+	restore := upgradedActions[2].(common.GenericUpdateAction).Ref.(*v1alpha1.KeycloakBackup)
+	assert.Equal(t, int64(1), restore.Spec.RestoreGeneration)
+	assert.Equal(t, int32(0), *u.keycloak.Spec.Replicas)
+	assert.Equal(t, u.postgresqlNewImage, u.postgresql.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestPostgresqlUpgrade_Test_Failed_Restore_Is_Retried(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseRestoring)
+	u.currentState.PostgresqlUpgradeBackup.Spec.RestoreGeneration = 1
+	u.currentState.PostgresqlUpgradeBackup.Status.FailedRestoreGeneration = 1
+	u.currentState.PostgresqlUpgradeBackup.Status.Phase = v1alpha1.BackupPhaseFailing
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	restore := upgradedActions[len(upgradedActions)-1].(common.GenericUpdateAction).Ref.(*v1alpha1.KeycloakBackup)
+	assert.Equal(t, int64(2), restore.Spec.RestoreGeneration)
+	assert.Equal(t, v1alpha1.PostgresUpgradePhaseRestoring, u.cr.Status.Postgres.Upgrade.Phase)
+
+	// when the backup wasn't reconciled since
+	u.currentState.PostgresqlUpgradeBackup.Spec.RestoreGeneration = 2
+	upgradedActions, err = upgradePostgresql(u.cr, u.currentState, newPostgresqlUpgradeTest().desiredState)
+
+	// then
+	assert.Nil(t, err)
+	for _, action := range upgradedActions {
+		_, isBackup := action.(common.GenericUpdateAction).Ref.(*v1alpha1.KeycloakBackup)
+		assert.False(t, isBackup)
+	}
+}
+
+func TestPostgresqlUpgrade_Test_Failed_Restore_Is_Reported(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseRestoring)
+	u.currentState.PostgresqlUpgradeBackup.Spec.RestoreGeneration = postgresqlUpgradeRestoreAttempts
+	u.currentState.PostgresqlUpgradeBackup.Status.FailedRestoreGeneration = postgresqlUpgradeRestoreAttempts
+	u.currentState.PostgresqlUpgradeBackup.Status.Phase = v1alpha1.BackupPhaseFailing
+	u.currentState.PostgresqlUpgradeBackup.Status.Message = "restore job failed"
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Equal(t, errPostgresqlUpgradeRestore, err)
+	assert.Nil(t, upgradedActions)
+	assert.Equal(t, v1alpha1.PostgresUpgradePhaseRestoring, u.cr.Status.Postgres.Upgrade.Phase)
+	assert.Equal(t, "restore job failed; the data of PostgreSQL 10 is kept in userdata-10, increase restoreGeneration of backup "+u.currentState.PostgresqlUpgradeBackup.Name+" to retry", u.cr.Status.Postgres.Upgrade.Message)
+}
+
+func TestPostgresqlUpgrade_Test_Keycloak_Started_After_Restore(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest().inPhase(v1alpha1.PostgresUpgradePhaseRestoring)
+	u.currentState.PostgresqlUpgradeBackup.Spec.RestoreGeneration = 1
+	u.currentState.PostgresqlUpgradeBackup.Status.Phase = v1alpha1.BackupPhaseRestored
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, u.desiredState, upgradedActions)
+	assert.Equal(t, int32(1), *u.keycloak.Spec.Replicas)
+	assert.Equal(t, u.postgresqlNewImage, u.postgresql.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, v1alpha1.PostgresUpgradePhaseSucceeded, u.cr.Status.Postgres.Upgrade.Phase)
+	assert.Equal(t, "13", u.cr.Status.Postgres.Version)
+	assert.Equal(t, "the data of PostgreSQL 10 is kept in /var/lib/pgsql/data/userdata-10, remove it to free the space", u.cr.Status.Postgres.Upgrade.Message)
+	assert.False(t, postgresqlUpgradeActive(u.cr))
+}
+
+func TestPostgresqlUpgrade_Test_Upgrade_Held_Until_Maintenance_Window(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest()
+	u.cr.Spec.Migration.MaintenanceWindow = closedMaintenanceWindow()
+
+	// when
+	upgradedActions, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, u.desiredState, upgradedActions)
+	assert.Nil(t, u.cr.Status.Postgres.Upgrade)
+	assert.Equal(t, int32(1), *u.keycloak.Spec.Replicas)
+	assert.Equal(t, u.postgresqlOldImage, u.postgresql.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, []string{"upgrade of PostgreSQL from 10 to 13"}, u.cr.Status.PendingMaintenance.Changes)
+}
+
+func TestPostgresqlUpgrade_Test_Upgrade_Waits_For_Keycloak_Migration(t *testing.T) {
+	// given
+	u := newPostgresqlUpgradeTest()
+	u.cr.Status.Migration = &v1alpha1.MigrationPlan{Phase: v1alpha1.MigrationPhaseVerifying}
+
+	// when
+	_, err := upgradePostgresql(u.cr, u.currentState, u.desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Nil(t, u.cr.Status.Postgres.Upgrade)
+	assert.Equal(t, u.postgresqlOldImage, u.postgresql.Spec.Template.Spec.Containers[0].Image)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type Reconciler interface {
@@ -36,16 +37,21 @@ func (i *KeycloakReconciler) Reconcile(clusterState *common.ClusterState, cr *kc
 		desired = desired.AddAction(i.getPostgresqlPersistentVolumeClaimDesiredState(clusterState, cr))
 		desired = desired.AddAction(i.getPostgresqlDeploymentDesiredState(clusterState, cr))
 		desired = desired.AddAction(i.getPostgresqlServiceDesiredState(clusterState, cr, false))
+		desired = desired.AddAction(i.getPostgresqlPodDisruptionBudgetDesiredState(clusterState, cr))
 	} else {
 		i.reconcileExternalDatabase(&desired, clusterState, cr)
 	}
 
 	desired = desired.AddAction(i.getKeycloakServiceDesiredState(clusterState, cr))
+	if cr.Spec.CertManager.Enabled {
+		desired = desired.AddAction(i.getKeycloakCertificateDesiredState(clusterState, cr))
+	}
 	desired = desired.AddAction(i.getKeycloakDiscoveryServiceDesiredState(clusterState, cr))
 	desired = desired.AddAction(i.getKeycloakMonitoringServiceDesiredState(clusterState, cr))
 	desired = desired.AddAction(i.GetKeycloakProbesDesiredState(clusterState, cr))
 	desired = desired.AddAction(i.getKeycloakDeploymentOrRHSSODesiredState(clusterState, cr))
 	i.reconcileExternalAccess(&desired, clusterState, cr)
+	desired = desired.AddAction(i.getKeycloakHorizontalPodAutoscalerDesiredState(clusterState, cr))
 	desired = desired.AddAction(i.getPodDisruptionBudgetDesiredState(clusterState, cr))
 
 	if cr.Spec.Migration.Backups.Enabled {
@@ -73,17 +79,73 @@ func (i *KeycloakReconciler) reconcileExternalAccess(desired *common.DesiredClus
 		return
 	}
 
-	// Find out if we're on OpenShift or Kubernetes and create either a Route or
-	// an Ingress
-	stateManager := common.GetStateManager()
-	openshift, keyExists := stateManager.GetState(common.OpenShiftAPIServerKind).(bool)
-
-	if keyExists && openshift {
+	// Unless configured, find out if we're on OpenShift or Kubernetes and create
+	// either a Route or an Ingress
+	mode := common.GetExternalAccessMode(cr)
+	switch mode {
+	case kc.RouteExternalAccessMode:
 		desired.AddAction(i.getKeycloakRouteDesiredState(clusterState, cr))
 		desired.AddAction(i.getKeycloakMetricsRouteDesiredState(clusterState, cr))
-	} else {
+	case kc.GatewayExternalAccessMode:
+		i.reconcileGatewayRoutes(desired, clusterState, cr)
+	default:
+		mode = kc.IngressExternalAccessMode
 		desired.AddAction(i.getKeycloakIngressDesiredState(clusterState, cr))
 	}
+
+	i.removeInactiveExternalAccess(desired, clusterState, cr, mode)
+}
+
+// Removes the resources the operator created for an external access mode that is no longer used
+func (i *KeycloakReconciler) removeInactiveExternalAccess(desired *common.DesiredClusterState, clusterState *common.ClusterState, cr *kc.Keycloak, mode kc.ExternalAccessMode) {
+	if mode != kc.IngressExternalAccessMode && clusterState.KeycloakIngress != nil && model.IsInstanceResource(cr, clusterState.KeycloakIngress) {
+		desired.AddAction(common.GenericDeleteAction{
+			Ref: clusterState.KeycloakIngress,
+			Msg: "Delete Keycloak Ingress",
+		})
+	}
+
+	if mode != kc.RouteExternalAccessMode {
+		if clusterState.KeycloakMetricsRoute != nil && model.IsInstanceResource(cr, clusterState.KeycloakMetricsRoute) {
+			desired.AddAction(common.GenericDeleteAction{
+				Ref: clusterState.KeycloakMetricsRoute,
+				Msg: "Delete Keycloak Metrics Route",
+			})
+		}
+		if clusterState.KeycloakRoute != nil && model.IsInstanceResource(cr, clusterState.KeycloakRoute) {
+			desired.AddAction(common.GenericDeleteAction{
+				Ref: clusterState.KeycloakRoute,
+				Msg: "Delete Keycloak Route",
+			})
+		}
+	}
+
+	if mode != kc.GatewayExternalAccessMode {
+		i.removeGatewayRoute(desired, cr, clusterState.KeycloakHTTPRoute, "Delete Keycloak HTTPRoute")
+		i.removeGatewayRoute(desired, cr, clusterState.KeycloakTLSRoute, "Delete Keycloak TLSRoute")
+	}
+}
+
+func (i *KeycloakReconciler) removeGatewayRoute(desired *common.DesiredClusterState, cr *kc.Keycloak, route *unstructured.Unstructured, msg string) {
+	if route != nil && model.IsInstanceResource(cr, route) {
+		desired.AddAction(common.GenericDeleteAction{
+			Ref: route,
+			Msg: msg,
+		})
+	}
+}
+
+// An HTTPRoute is used if TLS is terminated on the Gateway and a TLSRoute for passthrough. The route of the other
+// kind is removed when the TLS termination changes.
+func (i *KeycloakReconciler) reconcileGatewayRoutes(desired *common.DesiredClusterState, clusterState *common.ClusterState, cr *kc.Keycloak) {
+	if cr.Spec.ExternalAccess.TLSTermination == kc.PassthroughTLSTerminationType {
+		i.removeGatewayRoute(desired, cr, clusterState.KeycloakHTTPRoute, "Delete Keycloak HTTPRoute")
+		desired.AddAction(i.getKeycloakTLSRouteDesiredState(clusterState, cr))
+		return
+	}
+
+	i.removeGatewayRoute(desired, cr, clusterState.KeycloakTLSRoute, "Delete Keycloak TLSRoute")
+	desired.AddAction(i.getKeycloakHTTPRouteDesiredState(clusterState, cr))
 }
 
 func (i *KeycloakReconciler) GetKeycloakAdminSecretDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
@@ -136,7 +198,7 @@ func (i *KeycloakReconciler) getPostgresqlServiceDesiredState(clusterState *comm
 		}
 	}
 	return common.GenericUpdateAction{
-		Ref: model.PostgresqlServiceReconciled(clusterState.PostgresqlService, clusterState.DatabaseSecret, isExternal),
+		Ref: model.PostgresqlServiceReconciled(cr, clusterState.PostgresqlService, clusterState.DatabaseSecret, isExternal),
 		Msg: "Update Postgresql KeycloakService",
 	}
 }
@@ -283,6 +345,14 @@ func (i *KeycloakReconciler) GetKeycloakGrafanaDashboardDesiredState(clusterStat
 	}
 }
 
+// Returns true if the admin Secret and, with the embedded database, the database Secret exist
+func operatorSecretsExist(clusterState *common.ClusterState, cr *kc.Keycloak) bool {
+	if clusterState.KeycloakAdminSecret == nil {
+		return false
+	}
+	return cr.Spec.ExternalDatabase.Enabled || clusterState.DatabaseSecret != nil
+}
+
 func (i *KeycloakReconciler) getDatabaseSecretDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
 	databaseSecret := model.DatabaseSecret(cr)
 	if clusterState.DatabaseSecret == nil {
@@ -298,6 +368,12 @@ func (i *KeycloakReconciler) getDatabaseSecretDesiredState(clusterState *common.
 }
 
 func (i *KeycloakReconciler) getKeycloakDeploymentOrRHSSODesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	// The StatefulSet is only created once the Secrets created by the operator exist. Otherwise the config hash would
+	// change, and Keycloak roll out a second time, as soon as they are created.
+	if clusterState.KeycloakDeployment == nil && !operatorSecretsExist(clusterState, cr) {
+		return nil
+	}
+
 	isRHSSO := model.Profiles.IsRHSSO(cr)
 
 	deployment := model.KeycloakDeployment(cr, clusterState.DatabaseSecret, clusterState.DatabaseSSLCert)
@@ -308,6 +384,11 @@ func (i *KeycloakReconciler) getKeycloakDeploymentOrRHSSODesiredState(clusterSta
 		deploymentName = model.RHSSOProfile
 	}
 
+	if cr.Spec.CertManager.Enabled {
+		model.AddServingCertHash(deployment, clusterState.KeycloakServingCert)
+	}
+	model.AddConfigHash(deployment, clusterState.KeycloakReferencedSecrets, clusterState.KeycloakReferencedConfigMaps)
+
 	if clusterState.KeycloakDeployment == nil {
 		return common.GenericCreateAction{
 			Ref: deployment,
@@ -320,12 +401,61 @@ func (i *KeycloakReconciler) getKeycloakDeploymentOrRHSSODesiredState(clusterSta
 		deploymentReconciled = model.RHSSODeploymentReconciled(cr, clusterState.KeycloakDeployment, clusterState.DatabaseSecret, clusterState.DatabaseSSLCert)
 	}
 
+	if cr.Spec.CertManager.Enabled {
+		model.AddServingCertHash(deploymentReconciled, clusterState.KeycloakServingCert)
+	}
+	model.AddConfigHash(deploymentReconciled, clusterState.KeycloakReferencedSecrets, clusterState.KeycloakReferencedConfigMaps)
+
 	return common.GenericUpdateAction{
 		Ref: deploymentReconciled,
 		Msg: "Update " + deploymentName + " Deployment (StatefulSet)",
 	}
 }
 
+func (i *KeycloakReconciler) getKeycloakCertificateDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakCertificate == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakCertificate(cr),
+			Msg: "Create Keycloak Certificate",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakCertificateReconciled(cr, clusterState.KeycloakCertificate),
+		Msg: "Update Keycloak Certificate",
+	}
+}
+
+func (i *KeycloakReconciler) getKeycloakHorizontalPodAutoscalerDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if !cr.Spec.Autoscaling.Enabled {
+		// A HorizontalPodAutoscaler with the same name created by someone else is left alone
+		if clusterState.KeycloakHorizontalPodAutoscaler != nil && model.IsInstanceResource(cr, clusterState.KeycloakHorizontalPodAutoscaler) {
+			return common.GenericDeleteAction{
+				Ref: clusterState.KeycloakHorizontalPodAutoscaler,
+				Msg: "Delete Keycloak HorizontalPodAutoscaler",
+			}
+		}
+		return nil
+	}
+
+	if clusterState.KeycloakHorizontalPodAutoscaler == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakHorizontalPodAutoscaler(cr),
+			Msg: "Create Keycloak HorizontalPodAutoscaler",
+		}
+	}
+
+	if !model.IsInstanceResource(cr, clusterState.KeycloakHorizontalPodAutoscaler) {
+		log.Info("autoscaling is enabled in the CR but a HorizontalPodAutoscaler with the same name was not created by the operator; please remove it", "Namespace", cr.Namespace, "Name", clusterState.KeycloakHorizontalPodAutoscaler.GetName())
+		return nil
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakHorizontalPodAutoscalerReconciled(cr, clusterState.KeycloakHorizontalPodAutoscaler),
+		Msg: "Update Keycloak HorizontalPodAutoscaler",
+	}
+}
+
 func (i *KeycloakReconciler) getKeycloakRouteDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
 	if clusterState.KeycloakRoute == nil {
 		return common.GenericCreateAction{
@@ -358,6 +488,34 @@ func (i *KeycloakReconciler) getKeycloakMetricsRouteDesiredState(clusterState *c
 	}
 }
 
+func (i *KeycloakReconciler) getKeycloakHTTPRouteDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakHTTPRoute == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakHTTPRoute(cr),
+			Msg: "Create Keycloak HTTPRoute",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakHTTPRouteReconciled(cr, clusterState.KeycloakHTTPRoute),
+		Msg: "Update Keycloak HTTPRoute",
+	}
+}
+
+func (i *KeycloakReconciler) getKeycloakTLSRouteDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakTLSRoute == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakTLSRoute(cr),
+			Msg: "Create Keycloak TLSRoute",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakTLSRouteReconciled(cr, clusterState.KeycloakTLSRoute),
+		Msg: "Update Keycloak TLSRoute",
+	}
+}
+
 func (i *KeycloakReconciler) getKeycloakIngressDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
 	if clusterState.KeycloakIngress == nil {
 		return common.GenericCreateAction{
@@ -388,15 +546,13 @@ func (i *KeycloakReconciler) getPodDisruptionBudgetDesiredState(clusterState *co
 		stateManager := common.GetStateManager()
 		podDisruptionBudgetKind, keyExists := stateManager.GetState(common.PodDisruptionBudgetKind).(bool)
 		if !keyExists || !podDisruptionBudgetKind {
-			log.Info("podDisruptionBudget is enabled in the CR but policy/v1beta1 PodDisruptionBudget API was not found; please create podDisruptionBudget manually")
+			log.Info("podDisruptionBudget is enabled in the CR but the PodDisruptionBudget API was not found; please create podDisruptionBudget manually")
 			return nil
 		}
 
-		log.Info("using deprecated podDisruptionBudget field")
-
 		if clusterState.PodDisruptionBudget == nil {
 			return common.GenericCreateAction{
-				Ref: model.PodDisruptionBudget(cr),
+				Ref: model.PodDisruptionBudget(cr, common.GetPodDisruptionBudgetGroupVersionKind()),
 				Msg: "Create PodDisruptionBudget",
 			}
 		}
@@ -405,26 +561,64 @@ func (i *KeycloakReconciler) getPodDisruptionBudgetDesiredState(clusterState *co
 			Msg: "Update PodDisruptionBudget",
 		}
 	}
+
+	// A PodDisruptionBudget with the same name created by someone else is left alone
+	if clusterState.PodDisruptionBudget != nil && model.IsInstanceResource(cr, clusterState.PodDisruptionBudget) {
+		return common.GenericDeleteAction{
+			Ref: clusterState.PodDisruptionBudget,
+			Msg: "Delete PodDisruptionBudget",
+		}
+	}
+	return nil
+}
+
+func (i *KeycloakReconciler) getPostgresqlPodDisruptionBudgetDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if cr.Spec.PostgresDeploymentSpec.PodDisruptionBudget.Enabled {
+		if podDisruptionBudgetKind, _ := common.GetStateManager().GetState(common.PodDisruptionBudgetKind).(bool); !podDisruptionBudgetKind {
+			log.Info("postgresDeploymentSpec.podDisruptionBudget is enabled in the CR but the PodDisruptionBudget API was not found")
+			return nil
+		}
+
+		if clusterState.PostgresqlPodDisruptionBudget == nil {
+			return common.GenericCreateAction{
+				Ref: model.PostgresqlPodDisruptionBudget(cr, common.GetPodDisruptionBudgetGroupVersionKind()),
+				Msg: "Create Postgresql PodDisruptionBudget",
+			}
+		}
+		return common.GenericUpdateAction{
+			Ref: model.PostgresqlPodDisruptionBudgetReconciled(cr, clusterState.PostgresqlPodDisruptionBudget),
+			Msg: "Update Postgresql PodDisruptionBudget",
+		}
+	}
+
+	if clusterState.PostgresqlPodDisruptionBudget != nil && model.IsInstanceResource(cr, clusterState.PostgresqlPodDisruptionBudget) {
+		return common.GenericDeleteAction{
+			Ref: clusterState.PostgresqlPodDisruptionBudget,
+			Msg: "Delete Postgresql PodDisruptionBudget",
+		}
+	}
 	return nil
 }
 
 func (i *KeycloakReconciler) getKeycloakBackupDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakBackup == nil {
+		// This happens before migration
+		return nil
+	}
+
 	backupCr := &kc.KeycloakBackup{}
 	backupCr.Namespace = cr.Namespace
-	backupCr.Name = model.MigrateBackupName + "-" + common.BackupTime
+	backupCr.Name = clusterState.KeycloakBackup.Name
 	labelSelect := metav1.LabelSelector{
 		MatchLabels: cr.Labels,
 	}
 	backupCr.Spec.InstanceSelector = &labelSelect
 	backupCr.Spec.StorageClassName = cr.Spec.StorageClassName
 
-	if clusterState.KeycloakBackup == nil {
-		// This happens before migration
-		return nil
-	}
-
 	keycloakbackup := model.KeycloakMigrationOneTimeBackup(backupCr)
 	keycloakbackup.ResourceVersion = clusterState.KeycloakBackup.ResourceVersion
+	// A restore requested by the rollback of the migration is kept
+	keycloakbackup.Spec.RestoreGeneration = clusterState.KeycloakBackup.Spec.RestoreGeneration
 	return common.GenericUpdateAction{
 		Ref: keycloakbackup,
 		Msg: "Update Postgresql Backup for Keycloak Migration",
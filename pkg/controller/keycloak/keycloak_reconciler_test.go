@@ -11,12 +11,14 @@ import (
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	grafanav1alpha1 "github.com/integr8ly/grafana-operator/v3/pkg/apis/integreatly/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	routev1 "github.com/openshift/api/route/v1"
 	"github.com/stretchr/testify/assert"
 	v13 "k8s.io/api/apps/v1"
 )
@@ -57,10 +59,10 @@ func TestKeycloakReconciler_Test_Creating_All(t *testing.T) {
 	//    9) Keycloak Discovery Service
 	//    10) Keycloak Monitoring Service
 	//    11) Keycloak Probe ConfigMap
-	//    12) Keycloak StatefulSets
+	//        Note, that's no StatefulSet as it waits for the Secrets created by the operator
 	//        Note, that's no MetricsRoute as it needs an established hostname from the root route
-	//    13) Keycloak Route
-	assert.Equal(t, len(desiredState), 14)
+	//    12) Keycloak Route
+	assert.Equal(t, len(desiredState), 13)
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[0])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[1])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[2])
@@ -74,7 +76,6 @@ func TestKeycloakReconciler_Test_Creating_All(t *testing.T) {
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[10])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[11])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[12])
-	assert.IsType(t, common.GenericCreateAction{}, desiredState[13])
 	assert.IsType(t, model.KeycloakAdminSecret(cr), desiredState[0].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.PrometheusRule(cr), desiredState[1].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.ServiceMonitor(cr), desiredState[2].(common.GenericCreateAction).Ref)
@@ -87,8 +88,38 @@ func TestKeycloakReconciler_Test_Creating_All(t *testing.T) {
 	assert.IsType(t, model.KeycloakDiscoveryService(cr), desiredState[9].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.KeycloakMonitoringService(cr), desiredState[10].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.KeycloakProbes(cr), desiredState[11].(common.GenericCreateAction).Ref)
-	assert.IsType(t, model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil), desiredState[12].(common.GenericCreateAction).Ref)
-	assert.IsType(t, model.KeycloakRoute(cr), desiredState[13].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.KeycloakRoute(cr), desiredState[12].(common.GenericCreateAction).Ref)
+}
+
+func TestKeycloakReconciler_Test_Creating_StatefulSet_Once_Secrets_Exist(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
+	currentState.KeycloakReferencedSecrets = []v1.Secret{*currentState.KeycloakAdminSecret}
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	withoutDatabaseSecret := reconciler.Reconcile(currentState, cr)
+	currentState.DatabaseSecret = model.DatabaseSecret(cr)
+	currentState.KeycloakReferencedSecrets = append(currentState.KeycloakReferencedSecrets, *currentState.DatabaseSecret)
+	withSecrets := reconciler.Reconcile(currentState, cr)
+
+	// then
+	findStatefulSet := func(desiredState common.DesiredClusterState) *v13.StatefulSet {
+		for _, v := range desiredState {
+			if action, ok := v.(common.GenericCreateAction); ok {
+				if statefulSet, ok := action.Ref.(*v13.StatefulSet); ok {
+					return statefulSet
+				}
+			}
+		}
+		return nil
+	}
+	assert.Nil(t, findStatefulSet(withoutDatabaseSecret))
+	statefulSet := findStatefulSet(withSecrets)
+	assert.NotNil(t, statefulSet)
+	assert.NotEmpty(t, statefulSet.Spec.Template.Annotations[model.KeycloakConfigHashAnnotation])
 }
 
 func TestKeycloakReconciler_Test_Creating_RHSSO(t *testing.T) {
@@ -102,6 +133,8 @@ func TestKeycloakReconciler_Test_Creating_RHSSO(t *testing.T) {
 		},
 	}
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
+	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 
 	// when
 	reconciler := NewKeycloakReconciler()
@@ -112,6 +145,10 @@ func TestKeycloakReconciler_Test_Creating_RHSSO(t *testing.T) {
 	var deployment *v13.StatefulSet
 	var ingress *networkingv1.Ingress
 	for _, v := range desiredState {
+		if _, ok := v.(common.GenericUpdateAction); ok {
+			// The existing Secrets
+			continue
+		}
 		if reflect.TypeOf(v) != reflect.TypeOf(common.GenericCreateAction{}) {
 			allCreateActions = false
 		}
@@ -317,19 +354,20 @@ func TestKeycloakReconciler_Test_Creating_All_With_External_Database(t *testing.
 
 	currentState := common.NewClusterState()
 	currentState.DatabaseSecret = model.DatabaseSecret(cr)
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
 
 	// when
 	reconciler := NewKeycloakReconciler()
 	desiredState := reconciler.Reconcile(currentState, cr)
 
 	// then
-	assert.IsType(t, common.GenericCreateAction{}, desiredState[0])
+	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[1])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[2])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[3])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[4])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[5])
-	assert.IsType(t, model.DatabaseSecret(cr), desiredState[0].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.KeycloakAdminSecret(cr), desiredState[0].(common.GenericUpdateAction).Ref)
 	assert.IsType(t, model.PostgresqlService(cr, model.DatabaseSecret(cr), false), desiredState[1].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.KeycloakService(cr), desiredState[2].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.KeycloakDiscoveryService(cr), desiredState[3].(common.GenericCreateAction).Ref)
@@ -365,7 +403,7 @@ func TestKeycloakReconciler_Test_Updating_External_Database_WithIPAddress(t *tes
 			}
 		} else if reflect.TypeOf(v) == reflect.TypeOf(common.GenericCreateAction{}) &&
 			reflect.TypeOf(v.(common.GenericCreateAction).Ref) == reflect.TypeOf(model.PostgresqlService(cr, currentState.DatabaseSecret, true)) &&
-			v.(common.GenericCreateAction).Ref.(*v1.Service).Name == model.PostgresqlServiceName {
+			v.(common.GenericCreateAction).Ref.(*v1.Service).Name == model.PostgresqlServiceName(cr) {
 			service = v.(common.GenericCreateAction).Ref.(*v1.Service)
 		}
 	}
@@ -398,7 +436,7 @@ func TestKeycloakReconciler_Test_Updating_External_Database_URI(t *testing.T) {
 		if reflect.TypeOf(v) == reflect.TypeOf(common.GenericCreateAction{}) {
 			if reflect.TypeOf(v.(common.GenericCreateAction).Ref) == reflect.TypeOf(model.PostgresqlService(cr, currentState.DatabaseSecret, true)) {
 				s := v.(common.GenericCreateAction).Ref.(*v1.Service)
-				if s.Name == model.PostgresqlServiceName {
+				if s.Name == model.PostgresqlServiceName(cr) {
 					service = s
 				}
 			}
@@ -416,6 +454,7 @@ func TestKeycloakReconciler_Test_Given_SSLMODE_When_Reconcile_Then_NewEnvVarsAnd
 	cr.Spec.ExternalDatabase.Enabled = true
 
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
 	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 	currentState.DatabaseSecret.Data[model.DatabaseSecretSslModeProperty] = []byte("required")
 	currentState.DatabaseSSLCert = model.DatabaseSecret(cr)
@@ -437,7 +476,7 @@ func TestKeycloakReconciler_Test_Given_SSLMODE_When_Reconcile_Then_NewEnvVarsAnd
 
 	sslVolumeExists := false
 	for _, volume := range keycloakSpec.Volumes {
-		if strings.Contains(volume.Name, model.DatabaseSecretSslCert+"-vol") {
+		if strings.Contains(volume.Name, model.DatabaseSecretSslCert(cr)+"-vol") {
 			sslVolumeExists = true
 		}
 	}
@@ -451,6 +490,7 @@ func TestKeycloakReconciler_Test_Given_SSLMODE_And_RHSSO_When_Reconcile_Then_New
 	cr.Spec.Profile = "RHSSO"
 
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
 	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 	currentState.DatabaseSecret.Data[model.DatabaseSecretSslModeProperty] = []byte("required")
 	currentState.DatabaseSSLCert = model.DatabaseSecret(cr)
@@ -474,7 +514,7 @@ func TestKeycloakReconciler_Test_Given_SSLMODE_And_RHSSO_When_Reconcile_Then_New
 
 	sslVolumeExists := false
 	for _, volume := range keycloakSpec.Volumes {
-		if strings.Contains(volume.Name, model.DatabaseSecretSslCert+"-vol") {
+		if strings.Contains(volume.Name, model.DatabaseSecretSslCert(cr)+"-vol") {
 			sslVolumeExists = true
 		}
 	}
@@ -487,6 +527,7 @@ func TestKeycloakReconciler_Test_Given_NoSSLMODE_When_Reconcile_Then_NoNewEnvVar
 	cr.Spec.ExternalDatabase.Enabled = true
 
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
 	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 
 	// when
@@ -498,7 +539,7 @@ func TestKeycloakReconciler_Test_Given_NoSSLMODE_When_Reconcile_Then_NoNewEnvVar
 	// then
 	sslVolumeExists := false
 	for _, volume := range keycloakSpec.Volumes {
-		if strings.Contains(volume.Name, model.DatabaseSecretSslCert+"-vol") {
+		if strings.Contains(volume.Name, model.DatabaseSecretSslCert(cr)+"-vol") {
 			sslVolumeExists = true
 		}
 	}
@@ -550,7 +591,7 @@ func TestKeycloakReconciler_Test_Updating_External_Database_URI_From_IP_To_Exter
 		if reflect.TypeOf(v) == reflect.TypeOf(common.GenericUpdateAction{}) {
 			if reflect.TypeOf(v.(common.GenericUpdateAction).Ref) == reflect.TypeOf(model.PostgresqlService(cr, currentState.DatabaseSecret, true)) {
 				s := v.(common.GenericUpdateAction).Ref.(*v1.Service)
-				if s.Name == model.PostgresqlServiceName {
+				if s.Name == model.PostgresqlServiceName(cr) {
 					service = s
 				}
 			}
@@ -601,7 +642,7 @@ func TestKeycloakReconciler_Test_Updating_External_Database_From_ExternalName_To
 		if reflect.TypeOf(v) == reflect.TypeOf(common.GenericUpdateAction{}) {
 			if reflect.TypeOf(v.(common.GenericUpdateAction).Ref) == reflect.TypeOf(model.PostgresqlService(cr, currentState.DatabaseSecret, true)) {
 				s := v.(common.GenericUpdateAction).Ref.(*v1.Service)
-				if s.Name == model.PostgresqlServiceName {
+				if s.Name == model.PostgresqlServiceName(cr) {
 					service = s
 				}
 			}
@@ -657,6 +698,8 @@ func TestKeycloakReconciler_Test_Should_Create_PDB(t *testing.T) {
 	cr.Spec.PodDisruptionBudget.Enabled = true
 
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
+	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 
 	stateManager := common.GetStateManager()
 	stateManager.SetState(common.PodDisruptionBudgetKind, true)
@@ -669,7 +712,7 @@ func TestKeycloakReconciler_Test_Should_Create_PDB(t *testing.T) {
 	// then
 	assert.Equal(t, len(desiredState), 10)
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[9])
-	assert.IsType(t, model.PodDisruptionBudget(cr), desiredState[9].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PodDisruptionBudget(cr, model.PodDisruptionBudgetGroupVersionKind), desiredState[9].(common.GenericCreateAction).Ref)
 }
 
 func TestKeycloakReconciler_Test_Should_Update_PDB(t *testing.T) {
@@ -678,7 +721,9 @@ func TestKeycloakReconciler_Test_Should_Update_PDB(t *testing.T) {
 	cr.Spec.PodDisruptionBudget.Enabled = true
 
 	currentState := &common.ClusterState{
-		PodDisruptionBudget: model.PodDisruptionBudget(cr),
+		KeycloakAdminSecret: model.KeycloakAdminSecret(cr),
+		DatabaseSecret:      model.DatabaseSecret(cr),
+		PodDisruptionBudget: model.PodDisruptionBudget(cr, model.PodDisruptionBudgetGroupVersionKind),
 	}
 
 	stateManager := common.GetStateManager()
@@ -692,7 +737,7 @@ func TestKeycloakReconciler_Test_Should_Update_PDB(t *testing.T) {
 	// then
 	assert.Equal(t, len(desiredState), 10)
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[9])
-	assert.IsType(t, model.PodDisruptionBudget(cr), desiredState[9].(common.GenericUpdateAction).Ref)
+	assert.IsType(t, model.PodDisruptionBudget(cr, model.PodDisruptionBudgetGroupVersionKind), desiredState[9].(common.GenericUpdateAction).Ref)
 }
 
 func TestKeycloakReconciler_Test_Should_Skip_PDB_if_missing(t *testing.T) {
@@ -713,7 +758,7 @@ func TestKeycloakReconciler_Test_Should_Skip_PDB_if_missing(t *testing.T) {
 	// then
 	for _, element := range desiredState {
 		assert.IsType(t, common.GenericCreateAction{}, element)
-		assert.NotEqual(t, reflect.TypeOf(model.PodDisruptionBudget(cr)), reflect.TypeOf(element.(common.GenericCreateAction).Ref))
+		assert.NotEqual(t, reflect.TypeOf(model.PodDisruptionBudget(cr, model.PodDisruptionBudgetGroupVersionKind)), reflect.TypeOf(element.(common.GenericCreateAction).Ref))
 	}
 }
 
@@ -755,6 +800,8 @@ func TestKeycloakReconciler_Test_Setting_Resources(t *testing.T) {
 	}
 
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
+	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 
 	//Set monitoring resources exist to true
 	stateManager := common.GetStateManager()
@@ -793,6 +840,8 @@ func TestKeycloakReconciler_Test_No_Resources_Specified(t *testing.T) {
 		Enabled: true,
 	}
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
+	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 
 	//Set monitoring resources exist to true
 	stateManager := common.GetStateManager()
@@ -826,6 +875,8 @@ func TestKeycloakReconciler_Test_Proxy_Settings(t *testing.T) {
 	cr := &v1alpha1.Keycloak{}
 
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
+	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 	reconciler := NewKeycloakReconciler()
 
 	// when
@@ -854,9 +905,11 @@ func TestKeycloakReconciler_Test_Should_Create_Backup(t *testing.T) {
 	}
 
 	currentState := common.NewClusterState()
+	currentState.KeycloakAdminSecret = model.KeycloakAdminSecret(cr)
+	currentState.DatabaseSecret = model.DatabaseSecret(cr)
 	currentState.KeycloakBackup = &v1alpha1.KeycloakBackup{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      model.MigrateBackupName + "-" + common.BackupTime,
+			Name:      model.MigrateBackupName(cr) + "-20261019-020000",
 			Namespace: cr.Namespace,
 			Labels:    cr.Labels,
 		},
@@ -874,3 +927,282 @@ func TestKeycloakReconciler_Test_Should_Create_Backup(t *testing.T) {
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[9])
 	assert.IsType(t, model.KeycloakMigrationOneTimeBackup(backupCr), desiredState[9].(common.GenericUpdateAction).Ref)
 }
+
+func TestKeycloakReconciler_Test_Gateway_Switches_To_TLSRoute(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.ExternalAccess = v1alpha1.KeycloakExternalAccess{
+		Enabled:        true,
+		Mode:           v1alpha1.GatewayExternalAccessMode,
+		TLSTermination: v1alpha1.PassthroughTLSTerminationType,
+		Gateway:        v1alpha1.KeycloakGatewayAccess{Name: "public"},
+	}
+	currentState := common.NewClusterState()
+	currentState.KeycloakHTTPRoute = model.KeycloakHTTPRoute(cr)
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	var deletedHTTPRoute, createdTLSRoute, createdIngress bool
+	for _, v := range desiredState {
+		switch action := v.(type) {
+		case common.GenericDeleteAction:
+			deletedHTTPRoute = action.Ref == currentState.KeycloakHTTPRoute
+		case common.GenericCreateAction:
+			if route, ok := action.Ref.(interface{ GetKind() string }); ok && route.GetKind() == model.TLSRouteKind {
+				createdTLSRoute = true
+			}
+			if _, ok := action.Ref.(*networkingv1.Ingress); ok {
+				createdIngress = true
+			}
+		}
+	}
+	assert.True(t, deletedHTTPRoute)
+	assert.True(t, createdTLSRoute)
+	assert.False(t, createdIngress)
+}
+
+func TestKeycloakReconciler_Test_Switch_To_Ingress_Removes_Owned_Resources(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{ObjectMeta: metav1.ObjectMeta{Name: "sso", Namespace: "keycloak"}}
+	cr.Spec.ExternalAccess = v1alpha1.KeycloakExternalAccess{
+		Enabled: true,
+		Mode:    v1alpha1.IngressExternalAccessMode,
+	}
+	currentState := common.NewClusterState()
+	currentState.KeycloakRoute = model.KeycloakRoute(cr)
+	currentState.KeycloakMetricsRoute = model.KeycloakMetricsRoute(cr, currentState.KeycloakRoute)
+	// An HTTPRoute with the name of the instance, created by someone else
+	currentState.KeycloakHTTPRoute = model.KeycloakHTTPRoute(cr)
+	currentState.KeycloakHTTPRoute.SetLabels(nil)
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	var deleted []runtime.Object
+	var createdIngress bool
+	for _, v := range desiredState {
+		switch action := v.(type) {
+		case common.GenericDeleteAction:
+			deleted = append(deleted, action.Ref)
+		case common.GenericCreateAction:
+			if _, ok := action.Ref.(*networkingv1.Ingress); ok {
+				createdIngress = true
+			}
+		}
+	}
+	assert.True(t, createdIngress)
+	assert.Contains(t, deleted, runtime.Object(currentState.KeycloakRoute))
+	assert.Contains(t, deleted, runtime.Object(currentState.KeycloakMetricsRoute))
+	assert.NotContains(t, deleted, runtime.Object(currentState.KeycloakHTTPRoute))
+}
+
+func TestKeycloakReconciler_Test_Switch_To_Gateway_Removes_Owned_Ingress(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{ObjectMeta: metav1.ObjectMeta{Name: "sso", Namespace: "keycloak", UID: "uid"}}
+	cr.Spec.ExternalAccess = v1alpha1.KeycloakExternalAccess{
+		Enabled: true,
+		Mode:    v1alpha1.GatewayExternalAccessMode,
+		Gateway: v1alpha1.KeycloakGatewayAccess{Name: "public"},
+	}
+	currentState := common.NewClusterState()
+	// An Ingress created by an operator version not labelling it with the instance
+	currentState.KeycloakIngress = model.KeycloakIngress(cr)
+	currentState.KeycloakIngress.Labels = nil
+	controller := true
+	currentState.KeycloakIngress.OwnerReferences = []metav1.OwnerReference{{Name: "sso", UID: "uid", Controller: &controller}}
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	var deletedIngress bool
+	for _, v := range desiredState {
+		if action, ok := v.(common.GenericDeleteAction); ok && action.Ref == currentState.KeycloakIngress {
+			deletedIngress = true
+		}
+	}
+	assert.True(t, deletedIngress)
+}
+
+func TestKeycloakReconciler_Test_Legacy_Ingress_Kept_On_OpenShift(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{ObjectMeta: metav1.ObjectMeta{Name: "sso", Namespace: "keycloak", UID: "uid"}}
+	cr.Spec.ExternalAccess = v1alpha1.KeycloakExternalAccess{Enabled: true}
+	cr.Status.LegacyIngress = true
+	currentState := common.NewClusterState()
+	currentState.KeycloakIngress = model.KeycloakIngress(cr)
+
+	stateManager := common.GetStateManager()
+	stateManager.SetState(common.RouteKind, true)
+	stateManager.SetState(common.OpenShiftAPIServerKind, true)
+	defer stateManager.Clear()
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Equal(t, v1alpha1.IngressExternalAccessMode, common.GetExternalAccessMode(cr))
+	var updatedIngress bool
+	for _, v := range desiredState {
+		switch action := v.(type) {
+		case common.GenericUpdateAction:
+			if _, ok := action.Ref.(*networkingv1.Ingress); ok {
+				updatedIngress = true
+			}
+		case common.GenericDeleteAction:
+			assert.NotEqual(t, runtime.Object(currentState.KeycloakIngress), action.Ref)
+		case common.GenericCreateAction:
+			assert.IsNotType(t, &routev1.Route{}, action.Ref)
+		}
+	}
+	assert.True(t, updatedIngress)
+
+	// when
+	cr.Status.LegacyIngress = false
+
+	// then
+	assert.Equal(t, v1alpha1.RouteExternalAccessMode, common.GetExternalAccessMode(cr))
+}
+
+func TestKeycloakReconciler_Test_Autoscaling_Disabled_Removes_HPA(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Instances = 2
+	currentState := common.NewClusterState()
+	currentState.KeycloakHorizontalPodAutoscaler = model.KeycloakHorizontalPodAutoscaler(cr)
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	deletedHPA := false
+	for _, v := range desiredState {
+		if action, ok := v.(common.GenericDeleteAction); ok && action.Ref == currentState.KeycloakHorizontalPodAutoscaler {
+			deletedHPA = true
+		}
+	}
+	assert.True(t, deletedHPA)
+
+	// when
+	cr.Spec.Autoscaling = v1alpha1.KeycloakAutoscaling{Enabled: true, MaxReplicas: 5}
+	desiredState = reconciler.Reconcile(currentState, cr)
+
+	// then
+	updatedHPA := false
+	for _, v := range desiredState {
+		if action, ok := v.(common.GenericUpdateAction); ok {
+			if hpa, ok := action.Ref.(interface{ GetKind() string }); ok && hpa.GetKind() == model.HorizontalPodAutoscalerKind {
+				updatedHPA = true
+			}
+		}
+	}
+	assert.True(t, updatedHPA)
+}
+
+func TestKeycloakReconciler_Test_Should_Keep_Foreign_HPA(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{ObjectMeta: metav1.ObjectMeta{Name: "sso", Namespace: "keycloak", UID: "uid"}}
+	currentState := common.NewClusterState()
+	// A HorizontalPodAutoscaler with the name of the StatefulSet, created by someone else
+	currentState.KeycloakHorizontalPodAutoscaler = model.KeycloakHorizontalPodAutoscaler(cr)
+	currentState.KeycloakHorizontalPodAutoscaler.SetLabels(map[string]string{"app": "other"})
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	for _, v := range desiredState {
+		if action, ok := v.(common.GenericDeleteAction); ok {
+			assert.NotEqual(t, currentState.KeycloakHorizontalPodAutoscaler, action.Ref)
+		}
+	}
+
+	// when
+	cr.Spec.Autoscaling = v1alpha1.KeycloakAutoscaling{Enabled: true, MaxReplicas: 5}
+	desiredState = reconciler.Reconcile(currentState, cr)
+
+	// then
+	for _, v := range desiredState {
+		if action, ok := v.(common.GenericUpdateAction); ok {
+			if hpa, ok := action.Ref.(interface{ GetKind() string }); ok {
+				assert.NotEqual(t, model.HorizontalPodAutoscalerKind, hpa.GetKind())
+			}
+		}
+	}
+}
+
+func TestKeycloakReconciler_Test_Should_Create_Postgresql_PDB_With_V1beta1_Fallback(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.PodDisruptionBudget.Enabled = true
+
+	currentState := common.NewClusterState()
+
+	stateManager := common.GetStateManager()
+	stateManager.SetState(common.PodDisruptionBudgetKind, true)
+	stateManager.SetState(common.PodDisruptionBudgetV1beta1Only, true)
+	defer stateManager.Clear()
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	var postgresqlPDB interface{ GetAPIVersion() string }
+	for _, v := range desiredState {
+		if action, ok := v.(common.GenericCreateAction); ok && action.Msg == "Create Postgresql PodDisruptionBudget" {
+			postgresqlPDB = action.Ref.(interface{ GetAPIVersion() string })
+		}
+	}
+	assert.NotNil(t, postgresqlPDB)
+	assert.Equal(t, "policy/v1beta1", postgresqlPDB.GetAPIVersion())
+}
+
+func TestKeycloakReconciler_Test_Should_Delete_Disabled_PDB(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	currentState := common.NewClusterState()
+	currentState.PodDisruptionBudget = model.PodDisruptionBudget(cr, model.PodDisruptionBudgetV1beta1GroupVersionKind)
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	deletedPDB := false
+	for _, v := range desiredState {
+		if action, ok := v.(common.GenericDeleteAction); ok && action.Ref == currentState.PodDisruptionBudget {
+			deletedPDB = true
+		}
+	}
+	assert.True(t, deletedPDB)
+}
+
+func TestKeycloakReconciler_Test_Should_Keep_Foreign_PDB(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{ObjectMeta: metav1.ObjectMeta{Name: "sso", Namespace: "keycloak", UID: "uid"}}
+	currentState := common.NewClusterState()
+	// PodDisruptionBudgets with the names of the instance, created by someone else
+	currentState.PodDisruptionBudget = model.PodDisruptionBudget(cr, model.PodDisruptionBudgetGroupVersionKind)
+	currentState.PodDisruptionBudget.SetLabels(map[string]string{model.InstanceLabel: "other"})
+	currentState.PostgresqlPodDisruptionBudget = model.PostgresqlPodDisruptionBudget(cr, model.PodDisruptionBudgetGroupVersionKind)
+	currentState.PostgresqlPodDisruptionBudget.SetLabels(nil)
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	for _, v := range desiredState {
+		assert.IsType(t, common.GenericCreateAction{}, v)
+	}
+}
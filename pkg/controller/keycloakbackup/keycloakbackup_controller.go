@@ -7,6 +7,8 @@ import (
 
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/model"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
@@ -50,7 +52,10 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("keycloakbackup-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("keycloakbackup-controller", mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: common.KeycloakBackupMaxConcurrentReconciles,
+	})
 	if err != nil {
 		return err
 	}
@@ -94,6 +99,12 @@ type ReconcileKeycloakBackup struct {
 func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KeycloakBackup")
+	defer common.ObserveReconcileDuration(ControllerName, request.NamespacedName, time.Now())
+
+	ctx, span := tracing.StartSpan(r.context, "KeycloakBackup.Reconcile", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("k8s.namespace.name", request.Namespace)
+	span.SetAttribute("k8s.resource.name", request.Name)
 
 	// Fetch the KeycloakBackup instance
 	instance := &kc.KeycloakBackup{}
@@ -103,6 +114,7 @@ func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcil
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			common.ForgetReconciledResource(ControllerName, request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -116,6 +128,26 @@ func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcil
 		return reconcile.Result{Requeue: false}, nil
 	}
 
+	if err := model.ValidateBackupDestination(instance); err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	if err := model.ValidateBackupEncryption(instance); err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	if err := model.ValidateBackupVerification(instance); err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	if err := model.ValidateRealmBackup(instance); err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	if err := model.ValidateBackupRestore(instance); err != nil {
+		return r.ManageError(instance, err)
+	}
+
 	keycloaks, err := common.GetMatchingKeycloaks(r.context, r.client, instance.Spec.InstanceSelector)
 	if err != nil {
 		return r.ManageError(instance, err)
@@ -134,14 +166,18 @@ func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcil
 			return r.ManageError(instance, errors.Errorf("backups cannot be created for unmanaged keycloak instances"))
 		}
 
+		if err := model.ValidateBackupEncryptor(instance, &keycloak); err != nil {
+			return r.ManageError(instance, err)
+		}
+
 		currentState = common.NewBackupState(keycloak)
-		err = currentState.Read(r.context, instance, r.client)
+		err = currentState.Read(ctx, instance, r.client)
 		if err != nil {
 			return r.ManageError(instance, err)
 		}
 		reconciler := NewKeycloakBackupReconciler(keycloak)
 		desiredState := reconciler.Reconcile(currentState, instance)
-		actionRunner := common.NewClusterActionRunner(r.context, r.client, r.scheme, instance)
+		actionRunner := common.NewClusterActionRunner(ctx, r.client, r.scheme, instance)
 		err = actionRunner.RunAll(desiredState)
 		if err != nil {
 			return r.ManageError(instance, err)
@@ -153,6 +189,7 @@ func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcil
 
 func (r *ReconcileKeycloakBackup) ManageError(instance *kc.KeycloakBackup, issue error) (reconcile.Result, error) {
 	r.recorder.Event(instance, "Warning", "ProcessingError", issue.Error())
+	common.RecordReconcileResult(ControllerName, instance.Namespace, instance.Name, issue)
 
 	instance.Status.Message = issue.Error()
 	instance.Status.Ready = false
@@ -176,10 +213,30 @@ func (r *ReconcileKeycloakBackup) ManageSuccess(instance *kc.KeycloakBackup, cur
 	}
 	instance.Status.Ready = resourcesReady
 	instance.Status.Message = ""
+	instance.Status.History = currentState.History(instance)
+	common.RecordReconcileResult(ControllerName, instance.Namespace, instance.Name, nil)
+
+	restored, err := currentState.IsRestored()
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+	if restored {
+		instance.Status.RestoredGeneration = instance.Spec.RestoreGeneration
+	}
+	if currentState.IsRestoreFailed() {
+		instance.Status.FailedRestoreGeneration = instance.Spec.RestoreGeneration
+		return r.ManageError(instance, errors.Errorf("restore job %v failed, increase restoreGeneration to retry", currentState.RestoreJob.Name))
+	}
+	if instance.IsRestoreRequested() && currentState.RestoreJob == nil {
+		instance.Status.Message = currentState.RestoreWaitReason(instance)
+	}
 
-	if resourcesReady {
+	switch {
+	case instance.Spec.RestoreGeneration != 0 && !instance.IsRestoreRequested():
+		instance.Status.Phase = kc.BackupPhaseRestored
+	case resourcesReady:
 		instance.Status.Phase = kc.BackupPhaseCreated
-	} else {
+	default:
 		instance.Status.Phase = kc.BackupPhaseReconciling
 	}
 
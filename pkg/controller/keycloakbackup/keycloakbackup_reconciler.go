@@ -30,8 +30,21 @@ func (i *KeycloakBackupReconciler) Reconcile(currentState *common.BackupState, c
 			desired = desired.AddAction(i.GetAwsPeriodicBackupDesiredState(currentState, cr))
 		}
 	} else {
-		desired = desired.AddAction(i.GetLocalBackupPersistentVolumeDesiredState(currentState, cr))
-		desired = desired.AddAction(i.GetLocalBackupDesiredState(currentState, cr))
+		// Backups to a destination don't need the Persistent Volume
+		if cr.Spec.Destination == nil {
+			desired = desired.AddAction(i.GetLocalBackupPersistentVolumeDesiredState(currentState, cr))
+		}
+		if cr.Spec.Schedule == "" {
+			desired = desired.AddAction(i.GetLocalBackupDesiredState(currentState, cr))
+		} else {
+			desired = desired.AddAction(i.GetLocalPeriodicBackupDesiredState(currentState, cr))
+		}
+		if cr.IsRestoreRequested() {
+			desired = desired.AddAction(i.GetRestoreDesiredState(currentState, cr))
+		}
+		if cr.Spec.Verify {
+			desired = desired.AddAction(i.GetVerificationDesiredState(currentState, cr))
+		}
 	}
 
 	return desired
@@ -40,13 +53,13 @@ func (i *KeycloakBackupReconciler) Reconcile(currentState *common.BackupState, c
 func (i *KeycloakBackupReconciler) GetAwsPeriodicBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
 	if currentState.AwsPeriodicJob == nil {
 		return common.GenericCreateAction{
-			Ref: model.PostgresqlAWSPeriodicBackup(cr),
+			Ref: model.PostgresqlAWSPeriodicBackup(cr, &i.Keycloak),
 			Msg: "Create AWS Periodic Backup job",
 		}
 	}
 
 	return common.GenericUpdateAction{
-		Ref: model.PostgresqlAWSPeriodicBackupReconciled(cr, currentState.AwsPeriodicJob),
+		Ref: model.PostgresqlAWSPeriodicBackupReconciled(cr, &i.Keycloak, currentState.AwsPeriodicJob),
 		Msg: "Update AWS Periodic Backup job",
 	}
 }
@@ -54,13 +67,13 @@ func (i *KeycloakBackupReconciler) GetAwsPeriodicBackupDesiredState(currentState
 func (i *KeycloakBackupReconciler) GetAwsBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
 	if currentState.AwsJob == nil {
 		return common.GenericCreateAction{
-			Ref: model.PostgresqlAWSBackup(cr),
+			Ref: model.PostgresqlAWSBackup(cr, &i.Keycloak),
 			Msg: "Create AWS Backup job",
 		}
 	}
 
 	return common.GenericUpdateAction{
-		Ref: model.PostgresqlAWSBackupReconciled(cr, currentState.AwsJob),
+		Ref: model.PostgresqlAWSBackupReconciled(cr, &i.Keycloak, currentState.AwsJob),
 		Msg: "Update AWS Backup job",
 	}
 }
@@ -68,19 +81,33 @@ func (i *KeycloakBackupReconciler) GetAwsBackupDesiredState(currentState *common
 func (i *KeycloakBackupReconciler) GetLocalBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
 	if currentState.LocalPersistentVolumeJob == nil {
 		return common.GenericCreateAction{
-			Ref: model.PostgresqlBackup(cr),
+			Ref: model.PostgresqlBackup(cr, &i.Keycloak),
 			Msg: "Create Local Backup job",
 		}
 	}
 
 	return common.GenericUpdateAction{
-		Ref: model.PostgresqlBackupReconciled(cr, currentState.LocalPersistentVolumeJob),
+		Ref: model.PostgresqlBackupReconciled(cr, &i.Keycloak, currentState.LocalPersistentVolumeJob),
 		Msg: "Update Local Backup job",
 	}
 }
 
+func (i *KeycloakBackupReconciler) GetLocalPeriodicBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	if currentState.LocalPeriodicJob == nil {
+		return common.GenericCreateAction{
+			Ref: model.PostgresqlPeriodicBackup(cr, &i.Keycloak),
+			Msg: "Create Local Periodic Backup job",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.PostgresqlPeriodicBackupReconciled(cr, &i.Keycloak, currentState.LocalPeriodicJob),
+		Msg: "Update Local Periodic Backup job",
+	}
+}
+
 func (i *KeycloakBackupReconciler) GetLocalBackupPersistentVolumeDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
-	if currentState.LocalPersistentVolumeJob == nil {
+	if currentState.LocalPersistentVolumeClaim == nil {
 		return common.GenericCreateAction{
 			Ref: model.PostgresqlBackupPersistentVolumeClaim(cr),
 			Msg: "Create Local Backup Persistent Volume Claim",
@@ -92,3 +119,26 @@ func (i *KeycloakBackupReconciler) GetLocalBackupPersistentVolumeDesiredState(cu
 		Msg: "Update Local Backup Persistent Volume Claim",
 	}
 }
+
+// Every restoreGeneration is restored once, the Job is neither updated nor recreated
+func (i *KeycloakBackupReconciler) GetRestoreDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	if currentState.RestoreJob != nil || currentState.RestoreWaitReason(cr) != "" {
+		return nil
+	}
+	return common.GenericCreateAction{
+		Ref: model.PostgresqlRestore(cr, &i.Keycloak, currentState.RestoreFile(cr)),
+		Msg: "Create Restore job",
+	}
+}
+
+// Every backup is verified once, finished verifications are removed by their TTL
+func (i *KeycloakBackupReconciler) GetVerificationDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	entry := model.PostgresqlUnverifiedBackup(currentState.History(cr))
+	if entry == nil || currentState.HasVerification(entry) {
+		return nil
+	}
+	return common.GenericCreateAction{
+		Ref: model.PostgresqlVerification(cr, &i.Keycloak, entry),
+		Msg: "Create Verification job",
+	}
+}
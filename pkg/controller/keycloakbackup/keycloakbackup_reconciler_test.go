@@ -7,6 +7,7 @@ import (
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
 	"github.com/jaconi-io/keycloak-operator/pkg/model"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	v12 "k8s.io/api/core/v1"
@@ -27,7 +28,7 @@ func TestKeycloakBackupReconciler_Test_Creating_Local_Backup_Job(t *testing.T) {
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[0])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[1])
 	assert.IsType(t, model.PostgresqlBackupPersistentVolumeClaim(cr), desiredState[0].(common.GenericCreateAction).Ref)
-	assert.IsType(t, model.PostgresqlBackup(cr), desiredState[1].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlBackup(cr, &keycloak), desiredState[1].(common.GenericCreateAction).Ref)
 }
 
 func TestKeycloakBackupReconciler_Test_Updating_Local_Backup_Job(t *testing.T) {
@@ -48,7 +49,7 @@ func TestKeycloakBackupReconciler_Test_Updating_Local_Backup_Job(t *testing.T) {
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[1])
 	assert.IsType(t, model.PostgresqlBackupPersistentVolumeClaim(cr), desiredState[0].(common.GenericUpdateAction).Ref)
-	assert.IsType(t, model.PostgresqlBackup(cr), desiredState[1].(common.GenericUpdateAction).Ref)
+	assert.IsType(t, model.PostgresqlBackup(cr, &keycloak), desiredState[1].(common.GenericUpdateAction).Ref)
 }
 
 func TestKeycloakBackupReconciler_Test_Creating_AWS_Job(t *testing.T) {
@@ -72,7 +73,7 @@ func TestKeycloakBackupReconciler_Test_Creating_AWS_Job(t *testing.T) {
 
 	// then
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
-	assert.IsType(t, model.PostgresqlAWSBackup(cr), desiredState[0].(common.GenericUpdateAction).Ref)
+	assert.IsType(t, model.PostgresqlAWSBackup(cr, &keycloak), desiredState[0].(common.GenericUpdateAction).Ref)
 }
 
 func TestKeycloakBackupReconciler_Test_Updating_AWS_Job(t *testing.T) {
@@ -94,7 +95,7 @@ func TestKeycloakBackupReconciler_Test_Updating_AWS_Job(t *testing.T) {
 
 	// then
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[0])
-	assert.IsType(t, model.PostgresqlAWSBackup(cr), desiredState[0].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlAWSBackup(cr, &keycloak), desiredState[0].(common.GenericCreateAction).Ref)
 }
 
 func TestKeycloakBackupReconciler_Test_Creating_AWS_Periodic_Job(t *testing.T) {
@@ -117,7 +118,7 @@ func TestKeycloakBackupReconciler_Test_Creating_AWS_Periodic_Job(t *testing.T) {
 
 	// then
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[0])
-	assert.IsType(t, model.PostgresqlAWSPeriodicBackup(cr), desiredState[0].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlAWSPeriodicBackup(cr, &keycloak), desiredState[0].(common.GenericCreateAction).Ref)
 }
 
 func TestKeycloakBackupReconciler_Test_Updating_AWS_Periodic_Job(t *testing.T) {
@@ -142,5 +143,258 @@ func TestKeycloakBackupReconciler_Test_Updating_AWS_Periodic_Job(t *testing.T) {
 
 	// then
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
-	assert.IsType(t, model.PostgresqlAWSPeriodicBackup(cr), desiredState[0].(common.GenericUpdateAction).Ref)
+	assert.IsType(t, model.PostgresqlAWSPeriodicBackup(cr, &keycloak), desiredState[0].(common.GenericUpdateAction).Ref)
+}
+
+func TestKeycloakBackupReconciler_Test_Creating_Local_Periodic_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Schedule: "0 3 * * *",
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	assert.IsType(t, common.GenericCreateAction{}, desiredState[0])
+	assert.IsType(t, common.GenericCreateAction{}, desiredState[1])
+	assert.IsType(t, model.PostgresqlBackupPersistentVolumeClaim(cr), desiredState[0].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlPeriodicBackup(cr, &keycloak), desiredState[1].(common.GenericCreateAction).Ref)
+}
+
+func TestKeycloakBackupReconciler_Test_Updating_Local_Periodic_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Schedule: "0 3 * * *",
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := &common.BackupState{
+		LocalPersistentVolumeClaim: &v12.PersistentVolumeClaim{},
+		LocalPeriodicJob:           &v1beta1.CronJob{},
+	}
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
+	assert.IsType(t, common.GenericUpdateAction{}, desiredState[1])
+	assert.IsType(t, model.PostgresqlBackupPersistentVolumeClaim(cr), desiredState[0].(common.GenericUpdateAction).Ref)
+	assert.Equal(t, "0 3 * * *", desiredState[1].(common.GenericUpdateAction).Ref.(*v1beta1.CronJob).Spec.Schedule)
+}
+
+func TestKeycloakBackupReconciler_Test_Destination_Backup_Has_No_Persistent_Volume(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				S3: &v1alpha1.KeycloakBackupS3Destination{Bucket: "backups", CredentialsSecretName: "s3-credentials"},
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 1)
+	assert.IsType(t, model.PostgresqlBackup(cr, &keycloak), desiredState[0].(common.GenericCreateAction).Ref)
+}
+
+func TestKeycloakBackupReconciler_Test_Creating_Restore_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			RestoreGeneration: 1,
+			RestoreFile:       "backup-20261018030000.sql",
+			Schedule:          "0 3 * * *",
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 3)
+	assert.IsType(t, common.GenericCreateAction{}, desiredState[2])
+	assert.Equal(t, model.PostgresqlRestoreSelector(cr).Name, desiredState[2].(common.GenericCreateAction).Ref.(*v1.Job).Name)
+}
+
+func TestKeycloakBackupReconciler_Test_Restore_Job_Is_Not_Updated(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			RestoreGeneration: 1,
+			Schedule:          "0 3 * * *",
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := &common.BackupState{
+		LocalPeriodicJob:           &v1beta1.CronJob{},
+		LocalPersistentVolumeClaim: &v12.PersistentVolumeClaim{},
+		RestoreJob:                 &v1.Job{},
+	}
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
+	assert.IsType(t, common.GenericUpdateAction{}, desiredState[1])
+}
+
+func TestKeycloakBackupReconciler_Test_Restore_Waits_For_Backup(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			RestoreGeneration: 1,
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	assert.Equal(t, model.PostgresqlBackupSelector(cr).Name, desiredState[1].(common.GenericCreateAction).Ref.(*v1.Job).Name)
+	assert.Equal(t, "restore is waiting for the backup to succeed", currentState.RestoreWaitReason(cr))
+
+	// when the backup is written
+	backupJob := model.PostgresqlBackup(cr, &keycloak)
+	currentState.LocalPersistentVolumeJob = backupJob
+	currentState.LocalBackupJobs = []v1.Job{*backupJob}
+
+	// then
+	assert.Len(t, reconciler.Reconcile(currentState, cr), 2)
+	assert.Equal(t, "restore is waiting for the backup to succeed", currentState.RestoreWaitReason(cr))
+}
+
+func TestKeycloakBackupReconciler_Test_Restore_Waits_For_Keycloak_Scale_Down(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			RestoreGeneration: 2,
+			Schedule:          "0 3 * * *",
+		},
+		Status: v1alpha1.KeycloakBackupStatus{
+			RestoredGeneration: 1,
+			History: []v1alpha1.KeycloakBackupHistoryEntry{
+				{Job: "nightly-1", File: "backup-20261018030000.sql", Result: v1alpha1.BackupResultSucceeded},
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	replicas := int32(1)
+	currentState := &common.BackupState{
+		LocalPeriodicJob:           &v1beta1.CronJob{},
+		LocalPersistentVolumeClaim: &v12.PersistentVolumeClaim{},
+		KeycloakDeployment:         &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Replicas: &replicas}},
+	}
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	assert.Equal(t, "restore is waiting for Keycloak to be scaled down to 0 instances", currentState.RestoreWaitReason(cr))
+
+	// when Keycloak is scaled down
+	replicas = 0
+	desiredState = reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 3)
+	restore := desiredState[2].(common.GenericCreateAction).Ref.(*v1.Job)
+	assert.Equal(t, "-restore-2", restore.Name)
+	assert.Contains(t, restore.Spec.Template.Spec.Containers[0].Env, v12.EnvVar{Name: "RESTORE_FILE", Value: "backup-20261018030000.sql"})
+}
+
+func TestKeycloakBackupReconciler_Test_Restored_Generation_Is_Not_Restored_Again(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			RestoreGeneration: 1,
+			RestoreFile:       "backup-20261018030000.sql",
+			Schedule:          "0 3 * * *",
+		},
+		Status: v1alpha1.KeycloakBackupStatus{
+			RestoredGeneration: 1,
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+}
+
+func TestKeycloakBackupReconciler_Test_Creating_Verification_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Schedule: "0 3 * * *",
+			Verify:   true,
+		},
+		Status: v1alpha1.KeycloakBackupStatus{
+			History: []v1alpha1.KeycloakBackupHistoryEntry{
+				{Job: "nightly-1", File: "backup-1.sql", Result: v1alpha1.BackupResultSucceeded},
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := &common.BackupState{
+		LocalPeriodicJob:           &v1beta1.CronJob{},
+		LocalPersistentVolumeClaim: &v12.PersistentVolumeClaim{},
+	}
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 3)
+	assert.Equal(t, "nightly-1-verify", desiredState[2].(common.GenericCreateAction).Ref.(*v1.Job).Name)
+
+	// given
+	currentState.VerificationJobs = []v1.Job{*desiredState[2].(common.GenericCreateAction).Ref.(*v1.Job)}
+
+	// when
+	desiredState = reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
 }
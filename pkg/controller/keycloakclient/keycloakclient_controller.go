@@ -7,6 +7,7 @@ import (
 
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -51,7 +52,10 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(ControllerName, mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: common.KeycloakClientMaxConcurrentReconciles,
+	})
 	if err != nil {
 		return err
 	}
@@ -93,6 +97,12 @@ type ReconcileKeycloakClient struct {
 func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KeycloakClient")
+	defer common.ObserveReconcileDuration(ControllerName, request.NamespacedName, time.Now())
+
+	ctx, span := tracing.StartSpan(r.context, "KeycloakClient.Reconcile", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("k8s.namespace.name", request.Namespace)
+	span.SetAttribute("k8s.resource.name", request.Name)
 
 	// Fetch the KeycloakClient instance
 	instance := &kc.KeycloakClient{}
@@ -102,6 +112,7 @@ func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcil
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			common.ForgetReconciledResource(ControllerName, request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -109,6 +120,7 @@ func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcil
 	}
 
 	r.adjustCrDefaults(instance)
+	span.SetAttribute("keycloak.client_id", instance.Spec.Client.ClientID)
 
 	// The client may be applicable to multiple keycloak instances,
 	// process all of them
@@ -125,45 +137,55 @@ func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcil
 		log.Info(fmt.Sprintf("found %v matching keycloak(s) for realm %v/%v", len(keycloaks.Items), realm.Namespace, realm.Name))
 
 		for _, keycloak := range keycloaks.Items {
-			// Get an authenticated keycloak api client for the instance
-			keycloakFactory := common.LocalConfigKeycloakFactory{}
-			authenticated, err := keycloakFactory.AuthenticatedClient(keycloak, false)
+			err = r.reconcileKeycloakClient(ctx, instance, realm, keycloak)
 			if err != nil {
+				span.RecordError(err)
 				return r.ManageError(instance, err)
 			}
+		}
+	}
 
-			// Compute the current state of the realm
-			log.Info(fmt.Sprintf("got authenticated client for keycloak at %v", authenticated.Endpoint()))
-			clientState := common.NewClientState(r.context, realm.DeepCopy(), keycloak)
+	return reconcile.Result{Requeue: false}, r.manageSuccess(instance, instance.DeletionTimestamp != nil)
+}
 
-			log.Info(fmt.Sprintf("read client state for keycloak %v/%v, realm %v/%v, client %v/%v",
-				keycloak.Namespace,
-				keycloak.Name,
-				realm.Namespace,
-				realm.Name,
-				instance.Namespace,
-				instance.Name))
+// Reconcile the client in a single realm of a keycloak instance. Work on the same realm is serialized across all
+// controllers.
+func (r *ReconcileKeycloakClient) reconcileKeycloakClient(ctx context.Context, instance *kc.KeycloakClient, realm kc.KeycloakRealm, keycloak kc.Keycloak) error {
+	unlock := common.LockKeycloakRealm(keycloak, realm.Spec.Realm.Realm)
+	defer unlock()
 
-			err = clientState.Read(r.context, instance, authenticated, r.client)
-			if err != nil {
-				return r.ManageError(instance, err)
-			}
+	// Get an authenticated keycloak api client for the instance
+	keycloakFactory := common.LocalConfigKeycloakFactory{}
+	authenticated, err := keycloakFactory.AuthenticatedClientWithContext(ctx, keycloak, false)
+	if err != nil {
+		return err
+	}
 
-			// Figure out the actions to keep the realms up to date with
-			// the desired state
-			reconciler := NewKeycloakClientReconciler(keycloak)
-			desiredState := reconciler.Reconcile(clientState, instance)
-			actionRunner := common.NewClusterAndKeycloakActionRunner(r.context, r.client, r.scheme, instance, authenticated)
+	// Compute the current state of the realm
+	log.Info(fmt.Sprintf("got authenticated client for keycloak at %v", authenticated.Endpoint()))
+	clientState := common.NewClientState(ctx, realm.DeepCopy(), keycloak)
 
-			// Run all actions to keep the realms updated
-			err = actionRunner.RunAll(desiredState)
-			if err != nil {
-				return r.ManageError(instance, err)
-			}
-		}
+	log.Info(fmt.Sprintf("read client state for keycloak %v/%v, realm %v/%v, client %v/%v",
+		keycloak.Namespace,
+		keycloak.Name,
+		realm.Namespace,
+		realm.Name,
+		instance.Namespace,
+		instance.Name))
+
+	err = clientState.Read(ctx, instance, authenticated, r.client)
+	if err != nil {
+		return err
 	}
 
-	return reconcile.Result{Requeue: false}, r.manageSuccess(instance, instance.DeletionTimestamp != nil)
+	// Figure out the actions to keep the realms up to date with
+	// the desired state
+	reconciler := NewKeycloakClientReconciler(keycloak)
+	desiredState := reconciler.Reconcile(clientState, instance)
+	actionRunner := common.NewClusterAndKeycloakActionRunner(ctx, r.client, r.scheme, instance, authenticated)
+
+	// Run all actions to keep the realms updated
+	return actionRunner.RunAll(desiredState)
 }
 
 // Fills the CR with default values. Nils are not acceptable for Kubernetes.
@@ -180,6 +202,8 @@ func (r *ReconcileKeycloakClient) adjustCrDefaults(cr *kc.KeycloakClient) {
 }
 
 func (r *ReconcileKeycloakClient) manageSuccess(client *kc.KeycloakClient, deleted bool) error {
+	common.RecordReconcileResult(ControllerName, client.Namespace, client.Name, nil)
+
 	client.Status.Ready = true
 	client.Status.Message = ""
 	client.Status.Phase = kc.PhaseReconciling
@@ -232,6 +256,7 @@ func (r *ReconcileKeycloakClient) manageSuccess(client *kc.KeycloakClient, delet
 
 func (r *ReconcileKeycloakClient) ManageError(realm *kc.KeycloakClient, issue error) (reconcile.Result, error) {
 	r.recorder.Event(realm, "Warning", "ProcessingError", issue.Error())
+	common.RecordReconcileResult(ControllerName, realm.Namespace, realm.Name, issue)
 
 	realm.Status.Message = issue.Error()
 	realm.Status.Ready = false
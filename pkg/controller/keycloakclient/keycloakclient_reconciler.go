@@ -326,6 +326,13 @@ func (i *KeycloakClientReconciler) getUpdatedClientSecretState(state *common.Cli
 }
 
 func (i *KeycloakClientReconciler) getUpdatedClientState(state *common.ClientState, cr *kc.KeycloakClient) common.ClusterAction {
+	// Avoid writing the client if Keycloak already has the desired representation
+	if !model.ClientRepresentationChanged(cr.Spec.Client, state.Client) {
+		log.Info(fmt.Sprintf("client %v/%v is up to date, skipping update", cr.Namespace, cr.Spec.Client.ClientID))
+		common.RecordKeycloakWrite(common.WriteResourceClient, common.WriteResultSkipped)
+		return nil
+	}
+
 	return common.UpdateClientAction{
 		Ref:   cr,
 		Realm: state.Realm.Spec.Realm.Realm,
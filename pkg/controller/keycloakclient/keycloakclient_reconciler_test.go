@@ -416,3 +416,60 @@ func TestKeycloakClientReconciler_Test_Remove_Client_ID_Client_Secret(t *testing
 	assert.IsType(t, model.DeprecatedClientSecret(cr), desiredState[3].(common.GenericDeleteAction).Ref)
 	assert.Equal(t, oldSecretName, desiredState[3].(common.GenericDeleteAction).Ref.(*v1.Secret).Name)
 }
+
+func TestKeycloakClientReconciler_Test_Unchanged_Client(t *testing.T) {
+	// given
+	keycloakCr := v1alpha1.Keycloak{}
+	cr := &v1alpha1.KeycloakClient{
+		ObjectMeta: v13.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: v1alpha1.KeycloakClientSpec{
+			RealmSelector: &v13.LabelSelector{
+				MatchLabels: map[string]string{"application": "sso"},
+			},
+			Client: &v1alpha1.KeycloakAPIClient{
+				ClientID:     "test",
+				Secret:       "test",
+				RedirectUris: []string{"https://b.example.com/*", "https://a.example.com/*"},
+			},
+		},
+	}
+
+	currentState := &common.ClientState{
+		Realm: &v1alpha1.KeycloakRealm{
+			Spec: v1alpha1.KeycloakRealmSpec{
+				Realm: &v1alpha1.KeycloakAPIRealm{
+					Realm: "test",
+				},
+			},
+		},
+		// Keycloak returns the client with additional defaults and a different order
+		Client: &v1alpha1.KeycloakAPIClient{
+			ID:                      "12345",
+			ClientID:                "test",
+			Secret:                  "test",
+			Enabled:                 true,
+			Protocol:                "openid-connect",
+			ClientAuthenticatorType: "client-secret",
+			RedirectUris:            []string{"https://a.example.com/*", "https://b.example.com/*"},
+			Attributes:              map[string]string{"saml.assertion.signature": "false"},
+		},
+	}
+
+	// when
+	reconciler := NewKeycloakClientReconciler(keycloakCr)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.IsType(t, common.PingAction{}, desiredState[0])
+	for _, action := range desiredState {
+		assert.False(t, isUpdateClientAction(action))
+	}
+}
+
+func isUpdateClientAction(action common.ClusterAction) bool {
+	_, ok := action.(common.UpdateClientAction)
+	return ok
+}
@@ -9,6 +9,7 @@ import (
 
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 	corev1 "k8s.io/api/core/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -58,7 +59,10 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(ControllerName, mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: common.KeycloakRealmMaxConcurrentReconciles,
+	})
 	if err != nil {
 		return err
 	}
@@ -100,6 +104,12 @@ type ReconcileKeycloakRealm struct {
 func (r *ReconcileKeycloakRealm) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KeycloakRealm")
+	defer common.ObserveReconcileDuration(ControllerName, request.NamespacedName, time.Now())
+
+	ctx, span := tracing.StartSpan(r.context, "KeycloakRealm.Reconcile", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("k8s.namespace.name", request.Namespace)
+	span.SetAttribute("k8s.resource.name", request.Name)
 
 	// Fetch the KeycloakRealm instance
 	instance := &kc.KeycloakRealm{}
@@ -109,6 +119,7 @@ func (r *ReconcileKeycloakRealm) Reconcile(request reconcile.Request) (reconcile
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			common.ForgetReconciledResource(ControllerName, request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -136,50 +147,59 @@ func (r *ReconcileKeycloakRealm) Reconcile(request reconcile.Request) (reconcile
 	// The realm may be applicable to multiple keycloak instances,
 	// process all of them
 	for _, keycloak := range keycloaks.Items {
-		// Get an authenticated keycloak api client for the instance
-		keycloakFactory := common.LocalConfigKeycloakFactory{}
-
 		if keycloak.Spec.Unmanaged {
 			return r.ManageError(instance, errors.Errorf("realms cannot be created for unmanaged keycloak instances"))
 		}
 
-		authenticated, err := keycloakFactory.AuthenticatedClient(keycloak, false)
-
+		err = r.reconcileKeycloakRealm(ctx, instance, keycloak)
 		if err != nil {
+			span.RecordError(err)
 			return r.ManageError(instance, err)
 		}
+	}
 
-		// Compute the current state of the realm
-		realmState := common.NewRealmState(r.context, keycloak)
+	return reconcile.Result{Requeue: false}, r.manageSuccess(instance, instance.DeletionTimestamp != nil)
+}
 
-		log.Info(fmt.Sprintf("read state for keycloak %v/%v, realm %v/%v",
-			keycloak.Namespace,
-			keycloak.Name,
-			instance.Namespace,
-			instance.Spec.Realm.Realm))
+// Reconcile the realm in a single keycloak instance. Work on the same realm is serialized across all controllers.
+func (r *ReconcileKeycloakRealm) reconcileKeycloakRealm(ctx context.Context, instance *kc.KeycloakRealm, keycloak kc.Keycloak) error {
+	unlock := common.LockKeycloakRealm(keycloak, instance.Spec.Realm.Realm)
+	defer unlock()
 
-		err = realmState.Read(instance, authenticated, r.client)
-		if err != nil {
-			return r.ManageError(instance, err)
-		}
+	// Get an authenticated keycloak api client for the instance
+	keycloakFactory := common.LocalConfigKeycloakFactory{}
+	authenticated, err := keycloakFactory.AuthenticatedClientWithContext(ctx, keycloak, false)
+	if err != nil {
+		return err
+	}
 
-		// Figure out the actions to keep the realms up to date with
-		// the desired state
-		reconciler := NewKeycloakRealmReconciler(keycloak)
-		desiredState := reconciler.Reconcile(realmState, instance)
-		actionRunner := common.NewClusterAndKeycloakActionRunner(r.context, r.client, r.scheme, instance, authenticated)
+	// Compute the current state of the realm
+	realmState := common.NewRealmState(ctx, keycloak)
 
-		// Run all actions to keep the realms updated
-		err = actionRunner.RunAll(desiredState)
-		if err != nil {
-			return r.ManageError(instance, err)
-		}
+	log.Info(fmt.Sprintf("read state for keycloak %v/%v, realm %v/%v",
+		keycloak.Namespace,
+		keycloak.Name,
+		instance.Namespace,
+		instance.Spec.Realm.Realm))
+
+	err = realmState.Read(instance, authenticated, r.client)
+	if err != nil {
+		return err
 	}
 
-	return reconcile.Result{Requeue: false}, r.manageSuccess(instance, instance.DeletionTimestamp != nil)
+	// Figure out the actions to keep the realms up to date with
+	// the desired state
+	reconciler := NewKeycloakRealmReconciler(keycloak)
+	desiredState := reconciler.Reconcile(realmState, instance)
+	actionRunner := common.NewClusterAndKeycloakActionRunner(ctx, r.client, r.scheme, instance, authenticated)
+
+	// Run all actions to keep the realms updated
+	return actionRunner.RunAll(desiredState)
 }
 
 func (r *ReconcileKeycloakRealm) manageSuccess(realm *kc.KeycloakRealm, deleted bool) error {
+	common.RecordReconcileResult(ControllerName, realm.Namespace, realm.Name, nil)
+
 	realm.Status.Ready = true
 	realm.Status.Message = ""
 	realm.Status.Phase = kc.PhaseReconciling
@@ -232,6 +252,7 @@ func (r *ReconcileKeycloakRealm) manageSuccess(realm *kc.KeycloakRealm, deleted
 
 func (r *ReconcileKeycloakRealm) ManageError(realm *kc.KeycloakRealm, issue error) (reconcile.Result, error) {
 	r.recorder.Event(realm, "Warning", "ProcessingError", issue.Error())
+	common.RecordReconcileResult(ControllerName, realm.Namespace, realm.Name, issue)
 
 	realm.Status.Message = issue.Error()
 	realm.Status.Ready = false
@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/tracing"
 
 	"k8s.io/client-go/tools/record"
 
@@ -54,7 +55,10 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("keycloakuser-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("keycloakuser-controller", mgr, controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: common.KeycloakUserMaxConcurrentReconciles,
+	})
 	if err != nil {
 		return err
 	}
@@ -110,6 +114,12 @@ type ReconcileKeycloakUser struct {
 func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KeycloakUser")
+	defer common.ObserveReconcileDuration(ControllerName, request.NamespacedName, time.Now())
+
+	ctx, span := tracing.StartSpan(r.context, "KeycloakUser.Reconcile", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("k8s.namespace.name", request.Namespace)
+	span.SetAttribute("k8s.resource.name", request.Name)
 
 	// Fetch the KeycloakUser instance
 	instance := &kc.KeycloakUser{}
@@ -119,12 +129,15 @@ func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			common.ForgetReconciledResource(ControllerName, request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
 
+	span.SetAttribute("keycloak.username", instance.Spec.User.UserName)
+
 	// If no selector is set we can't figure out which realm instance this user should
 	// be added to. Skip reconcile until a selector has been set.
 	if instance.Spec.RealmSelector == nil {
@@ -155,41 +168,54 @@ func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.
 				return r.ManageError(instance, errors.Errorf("users cannot be created for unmanaged keycloak instances"))
 			}
 
-			// Get an authenticated keycloak api client for the instance
-			keycloakFactory := common.LocalConfigKeycloakFactory{}
-			authenticated, err := keycloakFactory.AuthenticatedClient(keycloak, false)
+			err = r.reconcileKeycloakUser(ctx, instance, realm, keycloak)
 			if err != nil {
+				span.RecordError(err)
 				return r.ManageError(instance, err)
 			}
+		}
+	}
 
-			// Compute the current state of the realm
-			userState := common.NewUserState(keycloak)
+	return reconcile.Result{Requeue: false}, r.manageSuccess(instance, instance.DeletionTimestamp != nil)
+}
 
-			log.Info(fmt.Sprintf("read state for keycloak %v/%v, realm %v/%v",
-				keycloak.Namespace,
-				keycloak.Name,
-				instance.Namespace,
-				realm.Spec.Realm.Realm))
+// Reconcile the user in a single realm of a keycloak instance. Work on the same realm is serialized across all
+// controllers.
+func (r *ReconcileKeycloakUser) reconcileKeycloakUser(ctx context.Context, instance *kc.KeycloakUser, realm kc.KeycloakRealm, keycloak kc.Keycloak) error {
+	unlock := common.LockKeycloakRealm(keycloak, realm.Spec.Realm.Realm)
+	defer unlock()
 
-			err = userState.Read(authenticated, r.client, instance, realm)
-			if err != nil {
-				return r.ManageError(instance, err)
-			}
-			reconciler := NewKeycloakuserReconciler(keycloak, realm)
-			desiredState := reconciler.Reconcile(userState, instance)
+	// Get an authenticated keycloak api client for the instance
+	keycloakFactory := common.LocalConfigKeycloakFactory{}
+	authenticated, err := keycloakFactory.AuthenticatedClientWithContext(ctx, keycloak, false)
+	if err != nil {
+		return err
+	}
 
-			actionRunner := common.NewClusterAndKeycloakActionRunner(r.context, r.client, r.scheme, instance, authenticated)
-			err = actionRunner.RunAll(desiredState)
-			if err != nil {
-				return r.ManageError(instance, err)
-			}
-		}
+	// Compute the current state of the realm
+	userState := common.NewUserState(keycloak)
+	userState.Context = ctx
+
+	log.Info(fmt.Sprintf("read state for keycloak %v/%v, realm %v/%v",
+		keycloak.Namespace,
+		keycloak.Name,
+		instance.Namespace,
+		realm.Spec.Realm.Realm))
+
+	err = userState.Read(authenticated, r.client, instance, realm)
+	if err != nil {
+		return err
 	}
+	reconciler := NewKeycloakuserReconciler(keycloak, realm)
+	desiredState := reconciler.Reconcile(userState, instance)
 
-	return reconcile.Result{Requeue: false}, r.manageSuccess(instance, instance.DeletionTimestamp != nil)
+	actionRunner := common.NewClusterAndKeycloakActionRunner(ctx, r.client, r.scheme, instance, authenticated)
+	return actionRunner.RunAll(desiredState)
 }
 
 func (r *ReconcileKeycloakUser) manageSuccess(user *kc.KeycloakUser, deleted bool) error {
+	common.RecordReconcileResult(ControllerName, user.Namespace, user.Name, nil)
+
 	user.Status.Phase = kc.UserPhaseReconciled
 	user.Status.Message = ""
 
@@ -235,6 +261,7 @@ func (r *ReconcileKeycloakUser) manageSuccess(user *kc.KeycloakUser, deleted boo
 
 func (r *ReconcileKeycloakUser) ManageError(user *kc.KeycloakUser, issue error) (reconcile.Result, error) {
 	r.recorder.Event(user, "Warning", "ProcessingError", issue.Error())
+	common.RecordReconcileResult(ControllerName, user.Namespace, user.Name, issue)
 
 	user.Status.Phase = kc.UserPhaseFailing
 	user.Status.Message = issue.Error()
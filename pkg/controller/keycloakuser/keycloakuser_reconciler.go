@@ -72,18 +72,32 @@ func (i *KeycloakuserReconciler) getKeycloakDesiredState() common.ClusterAction
 func (i *KeycloakuserReconciler) getKeycloakUserDesiredState(state *common.UserState, cr *v1alpha1.KeycloakUser) []common.ClusterAction {
 	var actions []common.ClusterAction
 
+	// A user can be applied to several instances and realms, each remembers its own credentials
+	credentialsTarget := model.UserCredentialsTarget(&i.Keycloak, i.Realm.Spec.Realm.Realm)
+	credentialsHash := model.UserCredentialsHash(&cr.Spec.User, state.CredentialsKey)
+
 	if state.User == nil {
 		actions = append(actions, &common.CreateUserAction{
-			Ref:   cr,
-			Realm: i.Realm.Spec.Realm.Realm,
-			Msg:   fmt.Sprintf("create user %v", cr.Spec.User.UserName),
+			Ref:               cr,
+			Realm:             i.Realm.Spec.Realm.Realm,
+			CredentialsTarget: credentialsTarget,
+			CredentialsHash:   credentialsHash,
+			Msg:               fmt.Sprintf("create user %v", cr.Spec.User.UserName),
 		})
 	} else {
-		actions = append(actions, &common.UpdateUserAction{
-			Ref:   cr,
-			Realm: i.Realm.Spec.Realm.Realm,
-			Msg:   fmt.Sprintf("update user %v", cr.Spec.User.UserName),
-		})
+		// Avoid writing the user if Keycloak already has the desired representation and credentials
+		if model.UserRepresentationChanged(&cr.Spec.User, state.User) || credentialsHash != cr.Status.CredentialsHashes[credentialsTarget] {
+			actions = append(actions, &common.UpdateUserAction{
+				Ref:               cr,
+				Realm:             i.Realm.Spec.Realm.Realm,
+				CredentialsTarget: credentialsTarget,
+				CredentialsHash:   credentialsHash,
+				Msg:               fmt.Sprintf("update user %v", cr.Spec.User.UserName),
+			})
+		} else {
+			log.Info(fmt.Sprintf("user %v is up to date, skipping update", cr.Spec.User.UserName))
+			common.RecordKeycloakWrite(common.WriteResourceUser, common.WriteResultSkipped)
+		}
 
 		// Sync the requested roles
 		actions = append(actions, i.getUserRealmRolesDesiredState(state, cr)...)
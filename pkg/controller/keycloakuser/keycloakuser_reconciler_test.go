@@ -7,6 +7,7 @@ import (
 
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/jaconi-io/keycloak-operator/pkg/common"
+	"github.com/jaconi-io/keycloak-operator/pkg/model"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -117,3 +118,67 @@ func TestKeycloakRealmReconciler_Reconcile(t *testing.T) {
 	assert.IsType(t, &common.UpdateUserAction{}, desiredState[1])
 	assert.IsType(t, &common.AssignRealmRoleAction{}, desiredState[2])
 }
+
+func TestKeycloakUserReconciler_Unchanged_User(t *testing.T) {
+	// given
+	keycloak := v1alpha1.Keycloak{}
+	realm := getDummyRealm()
+	reconciler := NewKeycloakuserReconciler(keycloak, realm)
+	state := getDummyState(keycloak)
+	user := getDummyUser()
+	user.Spec.User.UserName = "Dummy"
+	state.CredentialsKey = []byte("admin password")
+	user.Status.CredentialsHashes = map[string]string{
+		model.UserCredentialsTarget(&keycloak, realm.Spec.Realm.Realm): model.UserCredentialsHash(&user.Spec.User, state.CredentialsKey),
+	}
+
+	state.User = &v1alpha1.KeycloakAPIUser{
+		ID:       "dummy",
+		UserName: "dummy",
+		Enabled:  true,
+	}
+	state.Secret = &v12.Secret{}
+
+	// when
+	desiredState := reconciler.Reconcile(state, user)
+
+	// then
+	// 0 - check keycloak available
+	assert.Len(t, desiredState, 1)
+	assert.IsType(t, &common.PingAction{}, desiredState[0])
+
+	// Changed credentials have to be applied
+	user.Spec.User.Credentials[0].Value = "54321"
+	desiredState = reconciler.Reconcile(state, user)
+	assert.IsType(t, &common.UpdateUserAction{}, desiredState[1])
+}
+
+func TestKeycloakUserReconciler_Credentials_Applied_Per_Instance(t *testing.T) {
+	// given
+	applied := v1alpha1.Keycloak{ObjectMeta: v1.ObjectMeta{Name: "applied", Namespace: "dummy"}}
+	other := v1alpha1.Keycloak{ObjectMeta: v1.ObjectMeta{Name: "other", Namespace: "dummy"}}
+	realm := getDummyRealm()
+	user := getDummyUser()
+	user.Spec.User.UserName = "dummy"
+
+	state := getDummyState(other)
+	state.CredentialsKey = []byte("admin password")
+	state.User = &v1alpha1.KeycloakAPIUser{
+		ID:       "dummy",
+		UserName: "dummy",
+		Enabled:  true,
+	}
+	state.Secret = &v12.Secret{}
+	user.Status.CredentialsHashes = map[string]string{
+		model.UserCredentialsTarget(&applied, realm.Spec.Realm.Realm): model.UserCredentialsHash(&user.Spec.User, state.CredentialsKey),
+	}
+
+	// when
+	desiredState := NewKeycloakuserReconciler(other, realm).Reconcile(state, user)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	update := desiredState[1].(*common.UpdateUserAction)
+	assert.Equal(t, "dummy/other/"+realm.Spec.Realm.Realm, update.CredentialsTarget)
+	assert.Equal(t, model.UserCredentialsHash(&user.Spec.User, state.CredentialsKey), update.CredentialsHash)
+}
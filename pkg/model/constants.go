@@ -4,21 +4,18 @@ package model
 const (
 	ApplicationName                      = "keycloak"
 	MonitoringKey                        = "middleware"
-	DatabaseSecretName                   = ApplicationName + "-db-secret"
-	PostgresqlPersistentVolumeName       = ApplicationName + "-postgresql-claim"
 	PostgresqlBackupPersistentVolumeName = ApplicationName + "-backup"
-	PostgresqlDeploymentName             = ApplicationName + "-postgresql"
-	KeycloakProbesName                   = ApplicationName + "-probes"
-	KeycloakMetricsRouteName             = ApplicationName + "-metrics-rewrite"
+	KeycloakMonitoringPortName           = ApplicationName + "-monitoring"
 	KeycloakMetricsRoutePath             = "/auth/realms/master/metrics"
 	KeycloakMetricsRouteRewritePath      = "/auth/realms/master"
 	PostgresqlDeploymentComponent        = "database"
-	PostgresqlServiceName                = ApplicationName + "-postgresql"
-	KeycloakDiscoveryServiceName         = ApplicationName + "-discovery"
-	KeycloakMonitoringServiceName        = ApplicationName + "-monitoring"
-	KeycloakDeploymentName               = ApplicationName
 	KeycloakDeploymentComponent          = "keycloak"
+	KeycloakContainerName                = ApplicationName
+	PostgresqlContainerName              = ApplicationName + "-postgresql"
 	PostgresqlBackupComponent            = "database-backup"
+	PostgresqlRestoreComponent           = "database-restore"
+	PostgresqlVerificationComponent      = "database-verification"
+	PostgresqlUpgradeComponent           = "database-upgrade"
 	PostgresqlDatabase                   = "root"
 	PostgresqlUsername                   = ApplicationName
 	PostgresqlPasswordLength             = 32
@@ -35,10 +32,11 @@ const (
 	DatabaseSecretExternalAddressProperty      = "POSTGRES_EXTERNAL_ADDRESS"
 	DatabaseSecretExternalPortProperty         = "POSTGRES_EXTERNAL_PORT"
 	KeycloakServicePort                        = 8443
+	KeycloakServiceHTTPPort                    = 8080
+	KeycloakServiceHTTPPortName                = "http"
 	PostgresDefaultPort                        = 5432
 	AdminUsernameProperty                      = "ADMIN_USERNAME"
 	AdminPasswordProperty                      = "ADMIN_PASSWORD"
-	ServingCertSecretName                      = "sso-x509-https-secret"
 	LivenessProbeProperty                      = "liveness_probe.sh"
 	ReadinessProbeProperty                     = "readiness_probe.sh"
 	RouteLoadBalancingStrategy                 = "source"
@@ -48,14 +46,15 @@ const (
 	KeycloakExtensionPath                      = "/opt/jboss/keycloak/standalone/deployments"
 	KeycloakExtensionsInitContainerPath        = "/opt/extensions"
 	RhssoExtensionPath                         = "/opt/eap/standalone/deployments"
+	KeycloakThemesPath                         = "/opt/jboss/keycloak/themes"
+	KeycloakThemesInitContainerPath            = "/opt/themes"
+	RhssoThemesPath                            = "/opt/eap/themes"
 	ClientSecretName                           = ApplicationName + "-client-secret"
 	ClientSecretClientIDProperty               = "CLIENT_ID"
 	ClientSecretClientSecretProperty           = "CLIENT_SECRET"
 	MaxUnavailableNumberOfPods                 = 1
-	ServiceMonitorName                         = ApplicationName + "-service-monitor"
-	MigrateBackupName                          = "migrate-backup"
+	PostgresqlMinAvailableNumberOfPods         = 1
 	DatabaseSecretSslModeProperty              = "SSLMODE"
-	DatabaseSecretSslCert                      = ApplicationName + "-db-ssl-cert-secret"
 	RhssoDatabaseXAConnectionParamsProperty    = "DB_XA_CONNECTION_PROPERTY"
 	RhssoDatabaseNONXAConnectionParamsProperty = "DB_CONNECTION_PROPERTY"
 	KeycloakDatabaseConnectionParamsProperty   = "JDBC_PARAMS"
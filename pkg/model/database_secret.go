@@ -10,18 +10,18 @@ import (
 func DatabaseSecret(cr *v1alpha1.Keycloak) *v1.Secret {
 	return &v1.Secret{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      DatabaseSecretName,
+			Name:      DatabaseSecretName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 		},
 		Data: map[string][]byte{
 			DatabaseSecretUsernameProperty: []byte(PostgresqlUsername),
 			DatabaseSecretPasswordProperty: []byte(cr.ObjectMeta.Name + "-" + GenerateRandomString(PostgresqlPasswordLength)),
 			// The 3 entries below are not used by the Operator itself but rather by the Backup container
 			DatabaseSecretDatabaseProperty: []byte(PostgresqlDatabase),
-			DatabaseSecretHostProperty:     []byte(PostgresqlServiceName),
+			DatabaseSecretHostProperty:     []byte(PostgresqlServiceName(cr)),
 			DatabaseSecretVersionProperty:  []byte("10"),
 			DatabaseSecretSslModeProperty:  []byte(nil),
 		},
@@ -30,7 +30,7 @@ func DatabaseSecret(cr *v1alpha1.Keycloak) *v1.Secret {
 
 func DatabaseSecretSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      DatabaseSecretName,
+		Name:      DatabaseSecretName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -51,7 +51,7 @@ func DatabaseSecretReconciled(cr *v1alpha1.Keycloak, currentState *v1.Secret) *v
 		reconciled.Data[DatabaseSecretDatabaseProperty] = []byte(PostgresqlDatabase)
 	}
 	if _, ok := reconciled.Data[DatabaseSecretHostProperty]; !ok {
-		reconciled.Data[DatabaseSecretHostProperty] = []byte(PostgresqlServiceName)
+		reconciled.Data[DatabaseSecretHostProperty] = []byte(PostgresqlServiceName(cr))
 	}
 	if _, ok := reconciled.Data[DatabaseSecretVersionProperty]; !ok {
 		reconciled.Data[DatabaseSecretVersionProperty] = []byte("10")
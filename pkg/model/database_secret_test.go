@@ -20,6 +20,6 @@ func TestDatabaseSecret_test_nil_map(t *testing.T) {
 	assert.Equal(t, string(reconciledSecret.Data[DatabaseSecretUsernameProperty]), PostgresqlUsername)
 	assert.True(t, len(string(reconciledSecret.Data[DatabaseSecretPasswordProperty])) > 0)
 	assert.Equal(t, string(reconciledSecret.Data[DatabaseSecretDatabaseProperty]), PostgresqlDatabase)
-	assert.Equal(t, string(reconciledSecret.Data[DatabaseSecretHostProperty]), PostgresqlServiceName)
+	assert.Equal(t, string(reconciledSecret.Data[DatabaseSecretHostProperty]), PostgresqlServiceName(cr))
 	assert.Equal(t, string(reconciledSecret.Data[DatabaseSecretVersionProperty]), "10")
 }
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// applyDeploymentSpec applies the scheduling and security settings of a deployment spec to a pod spec. The container
+// security context is only set on containers that do not define their own one.
+func applyDeploymentSpec(podSpec *v1.PodSpec, deploymentSpec v1alpha1.DeploymentSpec) {
+	podSpec.ImagePullSecrets = deploymentSpec.ImagePullSecrets
+	podSpec.Tolerations = deploymentSpec.Tolerations
+	podSpec.NodeSelector = deploymentSpec.NodeSelector
+	podSpec.TopologySpreadConstraints = deploymentSpec.TopologySpreadConstraints
+	podSpec.PriorityClassName = deploymentSpec.PriorityClassName
+	podSpec.SecurityContext = deploymentSpec.PodSecurityContext
+	podSpec.TerminationGracePeriodSeconds = deploymentSpec.TerminationGracePeriodSeconds
+	podSpec.HostAliases = deploymentSpec.HostAliases
+
+	if deploymentSpec.Affinity != nil {
+		podSpec.Affinity = deploymentSpec.Affinity
+	}
+	if deploymentSpec.ServiceAccountName != "" {
+		podSpec.ServiceAccountName = deploymentSpec.ServiceAccountName
+	}
+
+	if deploymentSpec.SecurityContext != nil {
+		for i := range podSpec.InitContainers {
+			if podSpec.InitContainers[i].SecurityContext == nil {
+				podSpec.InitContainers[i].SecurityContext = deploymentSpec.SecurityContext.DeepCopy()
+			}
+		}
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].SecurityContext == nil {
+				podSpec.Containers[i].SecurityContext = deploymentSpec.SecurityContext.DeepCopy()
+			}
+		}
+	}
+}
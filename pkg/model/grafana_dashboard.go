@@ -10,7 +10,7 @@ import (
 func GrafanaDashboard(cr *v1alpha1.Keycloak) *grafanav1alpha1.GrafanaDashboard {
 	return &grafanav1alpha1.GrafanaDashboard{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      ApplicationName,
+			Name:      KeycloakServiceName(cr),
 			Namespace: cr.Namespace,
 			Labels: map[string]string{
 				"monitoring-key": MonitoringKey,
@@ -54,7 +54,7 @@ func GrafanaDashboardReconciled(cr *v1alpha1.Keycloak, currentState *grafanav1al
 
 func GrafanaDashboardSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      ApplicationName,
+		Name:      KeycloakServiceName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -14,6 +14,16 @@ const (
 	RHSSOInitContainer    = "RELATED_IMAGE_RHSSO_INIT_CONTAINER"
 	RHMIBackupContainer   = "RELATED_IMAGE_RHMI_BACKUP_CONTAINER"
 	PostgresqlImage       = "RELATED_IMAGE_POSTGRESQL"
+	PostgresqlImage12     = "RELATED_IMAGE_POSTGRESQL_12"
+	PostgresqlImage13     = "RELATED_IMAGE_POSTGRESQL_13"
+	PostgresqlImage15     = "RELATED_IMAGE_POSTGRESQL_15"
+	ExtensionsDownloader  = "RELATED_IMAGE_EXTENSIONS_DOWNLOADER"
+	BackupUploader        = "RELATED_IMAGE_BACKUP_UPLOADER"
+	BackupEncryptor       = "RELATED_IMAGE_BACKUP_ENCRYPTOR"
+	BackupEncryptor12     = "RELATED_IMAGE_BACKUP_ENCRYPTOR_12"
+	BackupEncryptor13     = "RELATED_IMAGE_BACKUP_ENCRYPTOR_13"
+	BackupEncryptor15     = "RELATED_IMAGE_BACKUP_ENCRYPTOR_15"
+	RealmExporter         = "RELATED_IMAGE_REALM_EXPORTER"
 
 	DefaultKeycloakImage         = "quay.io/keycloak/keycloak:legacy"
 	DefaultRHSSOImageOpenJ9      = "registry.redhat.io/rh-sso-7/sso75-openj9-openshift-rhel8:7.5"
@@ -22,6 +32,16 @@ const (
 	DefaultRHSSOInitContainer    = "registry.redhat.io/rh-sso-7/sso7-rhel8-init-container:7.5"
 	DefaultRHMIBackupContainer   = "quay.io/integreatly/backup-container:1.0.16"
 	DefaultPostgresqlImage       = "registry.access.redhat.com/rhscl/postgresql-10-rhel7:1"
+	DefaultPostgresqlImage12     = "registry.access.redhat.com/rhel8/postgresql-12:1"
+	DefaultPostgresqlImage13     = "registry.access.redhat.com/rhel8/postgresql-13:1"
+	DefaultPostgresqlImage15     = "registry.access.redhat.com/rhel8/postgresql-15:1"
+	DefaultExtensionsDownloader  = "registry.access.redhat.com/ubi8/ubi-minimal:8.10"
+	DefaultBackupUploader        = "docker.io/rclone/rclone:1.68"
+	// None of the default images provides age, the images with pg_dump, psql and age of the PostgreSQL version have
+	// to be set for age encryption
+	DefaultBackupEncryptor = ""
+	// Provides curl and jq, built from build/realm-exporter/Dockerfile
+	DefaultRealmExporter = "ghcr.io/jaconi-io/keycloak-realm-exporter:main"
 )
 
 var Images = NewImageManager()
@@ -41,6 +61,16 @@ func NewImageManager() ImageManager {
 		RHSSOInitContainer:    ret.getImage(RHSSOInitContainer, DefaultRHSSOInitContainer),
 		RHMIBackupContainer:   ret.getImage(RHMIBackupContainer, DefaultRHMIBackupContainer),
 		PostgresqlImage:       ret.getImage(PostgresqlImage, DefaultPostgresqlImage),
+		PostgresqlImage12:     ret.getImage(PostgresqlImage12, DefaultPostgresqlImage12),
+		PostgresqlImage13:     ret.getImage(PostgresqlImage13, DefaultPostgresqlImage13),
+		PostgresqlImage15:     ret.getImage(PostgresqlImage15, DefaultPostgresqlImage15),
+		ExtensionsDownloader:  ret.getImage(ExtensionsDownloader, DefaultExtensionsDownloader),
+		BackupUploader:        ret.getImage(BackupUploader, DefaultBackupUploader),
+		BackupEncryptor:       ret.getImage(BackupEncryptor, DefaultBackupEncryptor),
+		BackupEncryptor12:     ret.getImage(BackupEncryptor12, DefaultBackupEncryptor),
+		BackupEncryptor13:     ret.getImage(BackupEncryptor13, DefaultBackupEncryptor),
+		BackupEncryptor15:     ret.getImage(BackupEncryptor15, DefaultBackupEncryptor),
+		RealmExporter:         ret.getImage(RealmExporter, DefaultRealmExporter),
 	}
 	return ret
 }
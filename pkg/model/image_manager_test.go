@@ -19,6 +19,8 @@ func TestImageManager_test_default_images(t *testing.T) {
 	assert.Equal(t, DefaultKeycloakInitContainer, imageChooser.Images[KeycloakInitContainer])
 	assert.Equal(t, DefaultRHSSOInitContainer, imageChooser.Images[RHSSOInitContainer])
 	assert.Equal(t, DefaultRHMIBackupContainer, imageChooser.Images[RHMIBackupContainer])
+	assert.Equal(t, DefaultBackupUploader, imageChooser.Images[BackupUploader])
+	assert.Equal(t, DefaultRealmExporter, imageChooser.Images[RealmExporter])
 }
 
 func TestImageManager_test_defining_image_using_environment_variable(t *testing.T) {
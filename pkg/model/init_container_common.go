@@ -8,9 +8,9 @@ import (
 )
 
 func KeycloakExtensionsInitContainers(cr *v1alpha1.Keycloak) []v1.Container {
-	return []v1.Container{
+	containers := []v1.Container{
 		{
-			Name:  "extensions-init",
+			Name:  KeycloakExtensionsInitContainerName,
 			Image: Profiles.GetInitContainerImage(cr),
 			Env: []v1.EnvVar{
 				{
@@ -20,7 +20,7 @@ func KeycloakExtensionsInitContainers(cr *v1alpha1.Keycloak) []v1.Container {
 			},
 			VolumeMounts: []v1.VolumeMount{
 				{
-					Name:      "keycloak-extensions",
+					Name:      KeycloakExtensionsVolumeName,
 					ReadOnly:  false,
 					MountPath: KeycloakExtensionsInitContainerPath,
 				},
@@ -30,4 +30,5 @@ func KeycloakExtensionsInitContainers(cr *v1alpha1.Keycloak) []v1.Container {
 			ImagePullPolicy:          cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
 		},
 	}
+	return append(containers, keycloakExtensionSourcesInitContainers(cr)...)
 }
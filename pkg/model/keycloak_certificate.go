@@ -0,0 +1,125 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// cert-manager is not vendored, its Certificate is handled as unstructured object
+const (
+	CertManagerGroup          = "cert-manager.io"
+	CertificateKind           = "Certificate"
+	IssuerKind                = "Issuer"
+	ClusterIssuerKind         = "ClusterIssuer"
+	ServingCertCAKey          = "ca.crt"
+	ServingCertKey            = "tls.crt"
+	ServingCertHashAnnotation = "keycloak.org/serving-cert-hash"
+)
+
+var CertificateGroupVersionKind = schema.GroupVersionKind{Group: CertManagerGroup, Version: "v1", Kind: CertificateKind}
+
+// KeycloakCertificate requests the serving certificate of the Keycloak service from cert-manager
+func KeycloakCertificate(cr *kc.Keycloak) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(CertificateGroupVersionKind)
+	certificate.SetName(KeycloakServiceName(cr))
+	certificate.SetNamespace(cr.Namespace)
+	certificate.SetLabels(instanceLabels(cr, map[string]string{"app": ApplicationName}))
+	certificate.Object["spec"] = keycloakCertificateSpec(cr)
+	return certificate
+}
+
+func KeycloakCertificateReconciled(cr *kc.Keycloak, currentState *unstructured.Unstructured) *unstructured.Unstructured {
+	reconciled := currentState.DeepCopy()
+	reconciled.Object["spec"] = keycloakCertificateSpec(cr)
+	return reconciled
+}
+
+func KeycloakCertificateSelector(cr *kc.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakServiceName(cr),
+		Namespace: cr.Namespace,
+	}
+}
+
+func ServingCertSecret(cr *kc.Keycloak) *v1.Secret {
+	secret := &v1.Secret{}
+	secret.Name = ServingCertSecretName(cr)
+	secret.Namespace = cr.Namespace
+	return secret
+}
+
+func ServingCertSecretSelector(cr *kc.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      ServingCertSecretName(cr),
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakCertificateDNSNames returns the DNS names of the Keycloak service and, if enabled, the external hosts
+func KeycloakCertificateDNSNames(cr *kc.Keycloak) []string {
+	service := KeycloakServiceName(cr)
+	dnsNames := []string{
+		service,
+		fmt.Sprintf("%s.%s", service, cr.Namespace),
+		fmt.Sprintf("%s.%s.svc", service, cr.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", service, cr.Namespace),
+	}
+
+	if cr.Spec.CertManager.IncludeExternalHost {
+		if cr.Spec.ExternalAccess.Host != "" {
+			dnsNames = append(dnsNames, cr.Spec.ExternalAccess.Host)
+		}
+		for _, hostname := range cr.Spec.ExternalAccess.Gateway.Hostnames {
+			if hostname != cr.Spec.ExternalAccess.Host {
+				dnsNames = append(dnsNames, hostname)
+			}
+		}
+	}
+	return dnsNames
+}
+
+// AddServingCertHash annotates the pod template with the hash of the serving certificate, so that Keycloak is
+// restarted with the new certificate once it has been rotated
+func AddServingCertHash(deployment *v13.StatefulSet, servingCert *v1.Secret) {
+	if servingCert == nil || len(servingCert.Data[ServingCertKey]) == 0 {
+		return
+	}
+
+	annotations := map[string]string{}
+	for key, value := range deployment.Spec.Template.Annotations {
+		annotations[key] = value
+	}
+	annotations[ServingCertHashAnnotation] = fmt.Sprintf("%x", sha256.Sum256(servingCert.Data[ServingCertKey]))
+	deployment.Spec.Template.Annotations = annotations
+}
+
+func keycloakCertificateSpec(cr *kc.Keycloak) map[string]interface{} {
+	issuerKind := cr.Spec.CertManager.IssuerKind
+	if issuerKind == "" {
+		issuerKind = IssuerKind
+	}
+
+	dnsNames := []interface{}{}
+	for _, dnsName := range KeycloakCertificateDNSNames(cr) {
+		dnsNames = append(dnsNames, dnsName)
+	}
+
+	return map[string]interface{}{
+		"secretName": ServingCertSecretName(cr),
+		"dnsNames":   dnsNames,
+		"usages":     []interface{}{"server auth", "digital signature", "key encipherment"},
+		"issuerRef": map[string]interface{}{
+			"group": CertManagerGroup,
+			"kind":  issuerKind,
+			"name":  cr.Spec.CertManager.IssuerName,
+		},
+	}
+}
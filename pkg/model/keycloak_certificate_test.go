@@ -0,0 +1,67 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKeycloakCertificate_testDNSNamesAndIssuer(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Host: "sso.example.com",
+			},
+			CertManager: v1alpha1.KeycloakCertManager{
+				Enabled:             true,
+				IssuerName:          "internal-ca",
+				IssuerKind:          ClusterIssuerKind,
+				IncludeExternalHost: true,
+			},
+		},
+	}
+
+	//when
+	certificate := KeycloakCertificate(cr)
+
+	//then
+	assert.Equal(t, CertificateGroupVersionKind, certificate.GroupVersionKind())
+	secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+	assert.Equal(t, ServingCertSecretName(cr), secretName)
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	assert.Equal(t, []string{"sso", "sso.keycloak", "sso.keycloak.svc", "sso.keycloak.svc.cluster.local", "sso.example.com"}, dnsNames)
+	issuerKind, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "kind")
+	assert.Equal(t, ClusterIssuerKind, issuerKind)
+	_, hasServingCertAnnotation := KeycloakService(cr).Annotations[ServingCertAnnotation]
+	assert.False(t, hasServingCertAnnotation)
+}
+
+func TestKeycloakCertificate_testServingCertHash(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			KeycloakDeploymentSpec: v1alpha1.KeycloakDeploymentSpec{
+				PodAnnotations: map[string]string{"custom": "value"},
+			},
+		},
+	}
+	deployment := KeycloakDeployment(cr, nil, nil)
+	rotated := KeycloakDeployment(cr, nil, nil)
+
+	//when
+	AddServingCertHash(deployment, &v1.Secret{Data: map[string][]byte{ServingCertKey: []byte("first")}})
+	AddServingCertHash(rotated, &v1.Secret{Data: map[string][]byte{ServingCertKey: []byte("second")}})
+
+	//then
+	assert.NotEmpty(t, deployment.Spec.Template.Annotations[ServingCertHashAnnotation])
+	assert.NotEqual(t, deployment.Spec.Template.Annotations[ServingCertHashAnnotation], rotated.Spec.Template.Annotations[ServingCertHashAnnotation])
+	assert.Equal(t, "value", deployment.Spec.Template.Annotations["custom"])
+	assert.Len(t, cr.Spec.KeycloakDeploymentSpec.PodAnnotations, 1)
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// KeycloakConfigHashAnnotation holds the hash of the content of all Secrets and ConfigMaps used by the Keycloak pods
+const KeycloakConfigHashAnnotation = "keycloak.org/config-hash"
+
+// KeycloakReferencedSecretNames returns the sorted names of all Secrets used by the Keycloak pods
+func KeycloakReferencedSecretNames(cr *v1alpha1.Keycloak) []string {
+	names := []string{
+		KeycloakAdminSecretSelector(cr).Name,
+		DatabaseSecretName(cr),
+		DatabaseSecretSslCert(cr),
+	}
+	for _, volume := range cr.Spec.KeycloakDeploymentSpec.Experimental.Volumes.Items {
+		names = append(names, volume.Secrets...)
+	}
+	for _, source := range cr.Spec.ExtensionSources {
+		if source.CredentialsSecret != "" {
+			names = append(names, source.CredentialsSecret)
+		}
+	}
+	return sortedUnique(names)
+}
+
+// KeycloakReferencedConfigMapNames returns the sorted names of all ConfigMaps used by the Keycloak pods
+func KeycloakReferencedConfigMapNames(cr *v1alpha1.Keycloak) []string {
+	names := []string{}
+	for _, volume := range cr.Spec.KeycloakDeploymentSpec.Experimental.Volumes.Items {
+		names = append(names, volume.ConfigMaps...)
+	}
+	for _, source := range cr.Spec.ExtensionSources {
+		if source.ConfigMap != nil {
+			names = append(names, source.ConfigMap.Name)
+		}
+	}
+	return sortedUnique(names)
+}
+
+// AddConfigHash annotates the pod template with the hash of the referenced Secrets and ConfigMaps, so that Keycloak is
+// restarted once their content changes. Missing objects are not part of the hash.
+func AddConfigHash(deployment *v13.StatefulSet, secrets []v1.Secret, configMaps []v1.ConfigMap) {
+	deployment.Spec.Template.Annotations = setAnnotation(deployment.Spec.Template.Annotations, KeycloakConfigHashAnnotation, configHash(secrets, configMaps))
+}
+
+func configHash(secrets []v1.Secret, configMaps []v1.ConfigMap) string {
+	if len(secrets) == 0 && len(configMaps) == 0 {
+		return ""
+	}
+
+	hash := sha256.New()
+	write := func(values ...string) {
+		for _, value := range values {
+			// Separate the values, so that moving bytes between them changes the hash
+			hash.Write([]byte(value))
+			hash.Write([]byte{0})
+		}
+	}
+
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	for _, secret := range secrets {
+		write("Secret", secret.Name)
+		for _, key := range sortedKeys(secret.Data) {
+			write(key, string(secret.Data[key]))
+		}
+		for _, key := range sortedStringKeys(secret.StringData) {
+			write(key, secret.StringData[key])
+		}
+	}
+
+	sort.Slice(configMaps, func(i, j int) bool { return configMaps[i].Name < configMaps[j].Name })
+	for _, configMap := range configMaps {
+		write("ConfigMap", configMap.Name)
+		for _, key := range sortedStringKeys(configMap.Data) {
+			write(key, configMap.Data[key])
+		}
+		for _, key := range sortedKeys(configMap.BinaryData) {
+			write(key, string(configMap.BinaryData[key]))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func sortedKeys(data map[string][]byte) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedStringKeys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedUnique(names []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKeycloakConfigHash_testReferencedNames(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			KeycloakDeploymentSpec: v1alpha1.KeycloakDeploymentSpec{
+				Experimental: v1alpha1.ExperimentalSpec{
+					Volumes: v1alpha1.VolumesSpec{
+						Items: []v1alpha1.VolumeSpec{
+							{Name: "config", ConfigMaps: []string{"realm-config", "cache-config"}, Secrets: []string{"truststore"}},
+							{Name: "more-config", ConfigMaps: []string{"realm-config"}},
+						},
+					},
+				},
+			},
+			ExtensionSources: []v1alpha1.KeycloakExtensionSource{
+				{Name: "metrics", URL: "https://example.com/metrics.jar", CredentialsSecret: "nexus"},
+				{Name: "login", Type: v1alpha1.ThemeExtensionSourceType, ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: "login-theme"}}},
+			},
+		},
+	}
+
+	//then
+	assert.Equal(t, []string{"credential-sso", "nexus", "sso-db-secret", "sso-db-ssl-cert-secret", "truststore"}, KeycloakReferencedSecretNames(cr))
+	assert.Equal(t, []string{"cache-config", "login-theme", "realm-config"}, KeycloakReferencedConfigMapNames(cr))
+}
+
+func TestKeycloakConfigHash_testContentChangeRollsOut(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+	}
+	secrets := []v1.Secret{
+		{ObjectMeta: v12.ObjectMeta{Name: "keycloak-db-secret"}, Data: map[string][]byte{"POSTGRES_PASSWORD": []byte("first")}},
+		{ObjectMeta: v12.ObjectMeta{Name: "credential-sso"}, Data: map[string][]byte{"ADMIN_PASSWORD": []byte("admin")}},
+	}
+	configMaps := []v1.ConfigMap{
+		{ObjectMeta: v12.ObjectMeta{Name: "realm-config"}, Data: map[string]string{"a": "b", "c": "d"}},
+	}
+	deployment := KeycloakDeployment(cr, nil, nil)
+	reordered := KeycloakDeployment(cr, nil, nil)
+	rotated := KeycloakDeployment(cr, nil, nil)
+	empty := KeycloakDeployment(cr, nil, nil)
+
+	//when
+	AddConfigHash(deployment, secrets, configMaps)
+	AddConfigHash(reordered, []v1.Secret{secrets[1], secrets[0]}, configMaps)
+	AddConfigHash(rotated, []v1.Secret{
+		{ObjectMeta: v12.ObjectMeta{Name: "keycloak-db-secret"}, Data: map[string][]byte{"POSTGRES_PASSWORD": []byte("second")}},
+		secrets[1],
+	}, configMaps)
+	AddConfigHash(empty, nil, nil)
+
+	//then
+	hash := deployment.Spec.Template.Annotations[KeycloakConfigHashAnnotation]
+	assert.NotEmpty(t, hash)
+	assert.Equal(t, hash, reordered.Spec.Template.Annotations[KeycloakConfigHashAnnotation])
+	assert.NotEqual(t, hash, rotated.Spec.Template.Annotations[KeycloakConfigHashAnnotation])
+	_, hasHash := empty.Spec.Template.Annotations[KeycloakConfigHashAnnotation]
+	assert.False(t, hasHash)
+}
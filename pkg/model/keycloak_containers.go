@@ -0,0 +1,109 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	KeycloakExtensionsInitContainerName = "extensions-init"
+
+	// Names of the containers added from the Keycloak CR, used to remove them once they are removed from the CR. The
+	// init containers of extension sources are recorded as well.
+	KeycloakSidecarsAnnotation       = "keycloak.org/sidecars"
+	KeycloakInitContainersAnnotation = "keycloak.org/init-containers"
+)
+
+// mergeKeycloakContainers adds the sidecars and init containers of the Keycloak CR to a pod template holding the
+// containers of the operator. Containers are merged by name: the ones of the operator take precedence over the ones
+// of the CR, which take precedence over the ones of the current pod template. Containers added to the current pod
+// template by others are kept, unless they were added from the CR or for an extension source before.
+func mergeKeycloakContainers(cr *v1alpha1.Keycloak, template *v1.PodTemplateSpec, current *v1.PodTemplateSpec) {
+	var currentContainers, currentInitContainers []v1.Container
+	var currentAnnotations map[string]string
+	if current != nil {
+		currentContainers = current.Spec.Containers
+		currentInitContainers = current.Spec.InitContainers
+		currentAnnotations = current.Annotations
+	}
+
+	var sidecars, initContainers []string
+	template.Spec.Containers, sidecars = mergeContainers(template.Spec.Containers, cr.Spec.KeycloakDeploymentSpec.Sidecars,
+		currentContainers, splitContainerNames(currentAnnotations[KeycloakSidecarsAnnotation]))
+	template.Spec.InitContainers, initContainers = mergeContainers(template.Spec.InitContainers, cr.Spec.KeycloakDeploymentSpec.InitContainers,
+		currentInitContainers, splitContainerNames(currentAnnotations[KeycloakInitContainersAnnotation]))
+
+	template.Annotations = setAnnotation(template.Annotations, KeycloakSidecarsAnnotation, joinContainerNames(sidecars))
+	// The init containers of extension sources are owned by the operator, the ones of removed sources must not be kept
+	for _, container := range keycloakExtensionSourcesInitContainers(cr) {
+		initContainers = append(initContainers, container.Name)
+	}
+	template.Annotations = setAnnotation(template.Annotations, KeycloakInitContainersAnnotation, joinContainerNames(initContainers))
+}
+
+// Returns the merged containers and the names of the ones added from the CR
+func mergeContainers(operatorContainers []v1.Container, crContainers []v1.Container, currentContainers []v1.Container, previousCRContainers []string) ([]v1.Container, []string) {
+	merged := append([]v1.Container{}, operatorContainers...)
+	added := []string{}
+	names := map[string]bool{}
+	for _, container := range operatorContainers {
+		names[container.Name] = true
+	}
+
+	for _, container := range crContainers {
+		if names[container.Name] {
+			continue
+		}
+		names[container.Name] = true
+		merged = append(merged, *container.DeepCopy())
+		added = append(added, container.Name)
+	}
+
+	// Containers removed from the CR are not added again
+	for _, name := range previousCRContainers {
+		names[name] = true
+	}
+
+	for _, container := range currentContainers {
+		if names[container.Name] {
+			continue
+		}
+		names[container.Name] = true
+		merged = append(merged, container)
+	}
+	return merged, added
+}
+
+// Returns a copy of the annotations with the value set, or removed if empty
+func setAnnotation(annotations map[string]string, key, value string) map[string]string {
+	if value == "" && annotations[key] == "" {
+		return annotations
+	}
+
+	result := map[string]string{}
+	for k, v := range annotations {
+		result[k] = v
+	}
+	if value == "" {
+		delete(result, key)
+	} else {
+		result[key] = value
+	}
+	return result
+}
+
+func joinContainerNames(names []string) string {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func splitContainerNames(names string) []string {
+	if names == "" {
+		return nil
+	}
+	return strings.Split(names, ",")
+}
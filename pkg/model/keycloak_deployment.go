@@ -22,8 +22,8 @@ const (
 	ProbeFailureThreshold       = 10
 )
 
-func GetServiceEnvVar(suffix string) string {
-	serviceName := strings.ToUpper(PostgresqlServiceName)
+func GetServiceEnvVar(cr *v1alpha1.Keycloak, suffix string) string {
+	serviceName := strings.ToUpper(PostgresqlServiceName(cr))
 	serviceName = strings.ReplaceAll(serviceName, "-", "_")
 	return fmt.Sprintf("%v_%v", serviceName, suffix)
 }
@@ -68,7 +68,7 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		},
 		{
 			Name:  "DB_ADDR",
-			Value: PostgresqlServiceName + "." + cr.Namespace,
+			Value: PostgresqlServiceName(cr) + "." + cr.Namespace,
 		},
 		{
 			Name:  "DB_PORT",
@@ -83,7 +83,7 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
-						Name: DatabaseSecretName,
+						Name: DatabaseSecretName(cr),
 					},
 					Key: DatabaseSecretUsernameProperty,
 				},
@@ -94,7 +94,7 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
-						Name: DatabaseSecretName,
+						Name: DatabaseSecretName(cr),
 					},
 					Key: DatabaseSecretPasswordProperty,
 				},
@@ -111,7 +111,7 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		},
 		{
 			Name:  "JGROUPS_DISCOVERY_PROPERTIES",
-			Value: "dns_query=" + KeycloakDiscoveryServiceName + "." + cr.Namespace,
+			Value: "dns_query=" + KeycloakDiscoveryServiceName(cr) + "." + cr.Namespace,
 		},
 		// Cache settings
 		{
@@ -160,15 +160,23 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 
 	if cr.Spec.ExternalDatabase.Enabled {
 		env = append(env, v1.EnvVar{
-			Name:  GetServiceEnvVar("SERVICE_HOST"),
-			Value: PostgresqlServiceName + "." + cr.Namespace + ".svc.cluster.local",
+			Name:  GetServiceEnvVar(cr, "SERVICE_HOST"),
+			Value: PostgresqlServiceName(cr) + "." + cr.Namespace + ".svc.cluster.local",
 		})
 		env = append(env, v1.EnvVar{
-			Name:  GetServiceEnvVar("SERVICE_PORT"),
+			Name:  GetServiceEnvVar(cr, "SERVICE_PORT"),
 			Value: fmt.Sprintf("%v", GetExternalDatabasePort(dbSecret)),
 		})
 	}
 
+	// Redirects and links need to include the path prefix Keycloak is exposed under
+	if frontendURL := KeycloakFrontendURL(cr); frontendURL != "" {
+		env = append(env, v1.EnvVar{
+			Name:  "KEYCLOAK_FRONTEND_URL",
+			Value: frontendURL,
+		})
+	}
+
 	if len(cr.Spec.KeycloakDeploymentSpec.Experimental.Env) > 0 {
 		// We override Keycloak pre-defined envs with what user specified. Not the other way around.
 		env = MergeEnvs(cr.Spec.KeycloakDeploymentSpec.Experimental.Env, env)
@@ -203,11 +211,11 @@ func KeycloakSslEnvVariables(dbSecret *v1.Secret, env []v1.EnvVar) []v1.EnvVar {
 }
 
 func KeycloakDeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1.Secret) *v13.StatefulSet {
-	podLabels := AddPodLabels(cr, GetLabelsSelector())
+	podLabels := AddPodLabels(cr, GetLabelsSelector(cr))
 	podAnnotations := cr.Spec.KeycloakDeploymentSpec.PodAnnotations
 	keycloakStatefulset := &v13.StatefulSet{
 		ObjectMeta: v12.ObjectMeta{
-			Name:        KeycloakDeploymentName,
+			Name:        KeycloakDeploymentName(cr),
 			Namespace:   cr.Namespace,
 			Labels:      podLabels,
 			Annotations: podAnnotations,
@@ -215,11 +223,11 @@ func KeycloakDeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret
 		Spec: v13.StatefulSetSpec{
 			Replicas: SanitizeNumberOfReplicas(cr.Spec.Instances, true),
 			Selector: &v12.LabelSelector{
-				MatchLabels: GetLabelsSelector(),
+				MatchLabels: GetLabelsSelector(cr),
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: v12.ObjectMeta{
-					Name:        KeycloakDeploymentName,
+					Name:        KeycloakDeploymentName(cr),
 					Namespace:   cr.Namespace,
 					Labels:      podLabels,
 					Annotations: podAnnotations,
@@ -229,8 +237,8 @@ func KeycloakDeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret
 					Volumes:        KeycloakVolumes(cr, dbSSLSecret),
 					Containers: []v1.Container{
 						{
-							Name:  KeycloakDeploymentName,
-							Image: Images.Images[KeycloakImage],
+							Name:  KeycloakContainerName,
+							Image: Profiles.GetKeycloakOrRHSSOImage(cr),
 							Ports: []v1.ContainerPort{
 								{
 									ContainerPort: KeycloakServicePort,
@@ -247,7 +255,7 @@ func KeycloakDeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret
 							},
 
 							ImagePullPolicy: cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
-							VolumeMounts:    KeycloakVolumeMounts(cr, KeycloakExtensionPath, dbSSLSecret, KeycloakCertificatePath),
+							VolumeMounts:    KeycloakVolumeMounts(cr, KeycloakExtensionPath, KeycloakThemesPath, dbSSLSecret, KeycloakCertificatePath),
 							LivenessProbe:   livenessProbe(),
 							ReadinessProbe:  readinessProbe(),
 							Env:             getKeycloakEnv(cr, dbSecret),
@@ -267,12 +275,16 @@ func KeycloakDeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret
 	} else if cr.Spec.MultiAvailablityZones.Enabled {
 		keycloakStatefulset.Spec.Template.Spec.Affinity = KeycloakPodAffinity(cr)
 	}
+
+	mergeKeycloakContainers(cr, &keycloakStatefulset.Spec.Template, nil)
+	addKeycloakExtensionsHash(cr, &keycloakStatefulset.Spec.Template)
+	applyDeploymentSpec(&keycloakStatefulset.Spec.Template.Spec, cr.Spec.KeycloakDeploymentSpec.DeploymentSpec)
 	return keycloakStatefulset
 }
 
 func KeycloakDeploymentSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      KeycloakDeploymentName,
+		Name:      KeycloakDeploymentName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -284,18 +296,18 @@ func KeycloakDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.State
 	reconciled.ObjectMeta.Annotations = AddPodAnnotations(cr, reconciled.ObjectMeta.Annotations)
 	reconciled.Spec.Template.ObjectMeta.Labels = AddPodLabels(cr, reconciled.Spec.Template.ObjectMeta.Labels)
 	reconciled.Spec.Template.ObjectMeta.Annotations = AddPodAnnotations(cr, reconciled.Spec.Template.ObjectMeta.Annotations)
-	reconciled.Spec.Selector.MatchLabels = GetLabelsSelector()
+	reconciled.Spec.Selector.MatchLabels = GetLabelsSelector(cr)
 	reconciled.Spec.Template.Spec.ServiceAccountName = cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName
 
 	reconciled.ResourceVersion = currentState.ResourceVersion
-	if !cr.Spec.DisableReplicasSyncing {
+	if KeycloakReplicasSynced(cr) {
 		reconciled.Spec.Replicas = SanitizeNumberOfReplicas(cr.Spec.Instances, false)
 	}
 	reconciled.Spec.Template.Spec.Volumes = KeycloakVolumes(cr, dbSSLSecret)
 	reconciled.Spec.Template.Spec.Containers = []v1.Container{
 		{
-			Name:    KeycloakDeploymentName,
-			Image:   Images.Images[KeycloakImage],
+			Name:    KeycloakContainerName,
+			Image:   Profiles.GetKeycloakOrRHSSOImage(cr),
 			Args:    cr.Spec.KeycloakDeploymentSpec.Experimental.Args,
 			Command: cr.Spec.KeycloakDeploymentSpec.Experimental.Command,
 			Ports: []v1.ContainerPort{
@@ -313,7 +325,7 @@ func KeycloakDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.State
 				},
 			},
 			ImagePullPolicy: cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
-			VolumeMounts:    KeycloakVolumeMounts(cr, KeycloakExtensionPath, dbSSLSecret, KeycloakCertificatePath),
+			VolumeMounts:    KeycloakVolumeMounts(cr, KeycloakExtensionPath, KeycloakThemesPath, dbSSLSecret, KeycloakCertificatePath),
 			LivenessProbe:   livenessProbe(),
 			ReadinessProbe:  readinessProbe(),
 			Env:             getKeycloakEnv(cr, dbSecret),
@@ -325,34 +337,39 @@ func KeycloakDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.State
 		reconciled.Spec.Template.Spec.Affinity = cr.Spec.KeycloakDeploymentSpec.Experimental.Affinity
 	}
 
+	mergeKeycloakContainers(cr, &reconciled.Spec.Template, &currentState.Spec.Template)
+	addKeycloakExtensionsHash(cr, &reconciled.Spec.Template)
+	applyDeploymentSpec(&reconciled.Spec.Template.Spec, cr.Spec.KeycloakDeploymentSpec.DeploymentSpec)
+
 	return reconciled
 }
 
-func KeycloakVolumeMounts(cr *v1alpha1.Keycloak, extensionsPath string, dbSSLSecret *v1.Secret, certificatePath string) []v1.VolumeMount {
+func KeycloakVolumeMounts(cr *v1alpha1.Keycloak, extensionsPath string, themesPath string, dbSSLSecret *v1.Secret, certificatePath string) []v1.VolumeMount {
 	mountedVolumes := []v1.VolumeMount{
 		{
-			Name:      ServingCertSecretName,
+			Name:      ServingCertSecretName(cr),
 			MountPath: "/etc/x509/https",
 		},
 		{
-			Name:      "keycloak-extensions",
+			Name:      KeycloakExtensionsVolumeName,
 			ReadOnly:  false,
 			MountPath: extensionsPath,
 		},
 		{
-			Name:      KeycloakProbesName,
+			Name:      KeycloakProbesName(cr),
 			MountPath: "/probes",
 		},
 	}
 
 	if dbSSLSecret != nil {
 		mountedVolumes = append(mountedVolumes, v1.VolumeMount{
-			Name:      DatabaseSecretSslCert + "-vol",
+			Name:      DatabaseSecretSslCert(cr) + "-vol",
 			ReadOnly:  true,
 			MountPath: certificatePath,
 		})
 	}
 
+	mountedVolumes = append(mountedVolumes, keycloakThemeVolumeMounts(cr, themesPath)...)
 	mountedVolumes = addVolumeMountsFromKeycloakCR(cr, mountedVolumes)
 
 	return mountedVolumes
@@ -374,26 +391,26 @@ func addVolumeMountsFromKeycloakCR(cr *v1alpha1.Keycloak, mountedVolumes []v1.Vo
 func KeycloakVolumes(cr *v1alpha1.Keycloak, dbSSLSecret *v1.Secret) []v1.Volume {
 	volumes := []v1.Volume{
 		{
-			Name: ServingCertSecretName,
+			Name: ServingCertSecretName(cr),
 			VolumeSource: v1.VolumeSource{
 				Secret: &v1.SecretVolumeSource{
-					SecretName: ServingCertSecretName,
+					SecretName: ServingCertSecretName(cr),
 					Optional:   &[]bool{true}[0],
 				},
 			},
 		},
 		{
-			Name: "keycloak-extensions",
+			Name: KeycloakExtensionsVolumeName,
 			VolumeSource: v1.VolumeSource{
 				EmptyDir: &v1.EmptyDirVolumeSource{},
 			},
 		},
 		{
-			Name: KeycloakProbesName,
+			Name: KeycloakProbesName(cr),
 			VolumeSource: v1.VolumeSource{
 				ConfigMap: &v1.ConfigMapVolumeSource{
 					LocalObjectReference: v1.LocalObjectReference{
-						Name: KeycloakProbesName,
+						Name: KeycloakProbesName(cr),
 					},
 					DefaultMode: &[]int32{0555}[0],
 				},
@@ -402,16 +419,17 @@ func KeycloakVolumes(cr *v1alpha1.Keycloak, dbSSLSecret *v1.Secret) []v1.Volume
 	}
 	if dbSSLSecret != nil {
 		volumes = append(volumes, v1.Volume{
-			Name: DatabaseSecretSslCert + "-vol",
+			Name: DatabaseSecretSslCert(cr) + "-vol",
 			VolumeSource: v1.VolumeSource{
 				Secret: &v1.SecretVolumeSource{
-					SecretName: DatabaseSecretSslCert,
+					SecretName: DatabaseSecretSslCert(cr),
 					Optional:   &[]bool{false}[0],
 				},
 			},
 		})
 	}
 
+	volumes = append(volumes, keycloakThemeVolumes(cr)...)
 	volumes = addVolumesFromKeycloakCR(cr, volumes)
 
 	return volumes
@@ -539,10 +557,3 @@ func KeycloakPodAffinity(cr *v1alpha1.Keycloak) *v1.Affinity {
 		},
 	}
 }
-
-func GetLabelsSelector() map[string]string {
-	return map[string]string{
-		"app":       ApplicationName,
-		"component": KeycloakDeploymentComponent,
-	}
-}
@@ -70,6 +70,14 @@ func TestKeycloakDeploymentReconciled_testDisableReplicasSyncingTrue(t *testing.
 	testDisableDeploymentReplicasSyncingTrue(t, KeycloakDeployment, KeycloakDeploymentReconciled)
 }
 
+func TestKeycloakDeployment_testDeploymentSpecPodSettings(t *testing.T) {
+	testDeploymentSpecPodSettings(t, KeycloakDeployment, KeycloakDeploymentReconciled)
+}
+
+func TestKeycloakDeployment_testSidecarsMergedByName(t *testing.T) {
+	testSidecarsMergedByName(t, KeycloakDeployment, KeycloakDeploymentReconciled)
+}
+
 func testExperimentalEnvs(t *testing.T, deploymentFunction createDeploymentStatefulSet) {
 	//given
 	dbSecret := &v1.Secret{}
@@ -318,7 +326,7 @@ func testPostgresEnvs(t *testing.T, deploymentFunction createDeploymentStatefulS
 	//then
 	assert.Equal(t, getEnvValueByName(envs, "DB_VENDOR"), "POSTGRES")
 	assert.Equal(t, getEnvValueByName(envs, "DB_SCHEMA"), "public")
-	assert.Equal(t, getEnvValueByName(envs, "DB_ADDR"), PostgresqlServiceName+"."+cr.Namespace)
+	assert.Equal(t, getEnvValueByName(envs, "DB_ADDR"), PostgresqlServiceName(cr)+"."+cr.Namespace)
 	assert.True(t, getEnvValueByName(envs, "DB_PORT") != "")
 	assert.Equal(t, getEnvValueByName(envs, "DB_PORT"), fmt.Sprintf("%v", PostgresDefaultPort))
 	assert.Equal(t, getEnvValueByName(envs, "DB_DATABASE"), PostgresqlDatabase)
@@ -345,7 +353,7 @@ func testPostgresEnvs(t *testing.T, deploymentFunction createDeploymentStatefulS
 	//then
 	assert.Equal(t, "POSTGRES", getEnvValueByName(envs, "DB_VENDOR"))
 	assert.Equal(t, "public", getEnvValueByName(envs, "DB_SCHEMA"))
-	assert.Equal(t, PostgresqlServiceName+"."+cr.Namespace, getEnvValueByName(envs, "DB_ADDR"))
+	assert.Equal(t, PostgresqlServiceName(cr)+"."+cr.Namespace, getEnvValueByName(envs, "DB_ADDR"))
 	assert.True(t, getEnvValueByName(envs, "DB_PORT") != "")
 	assert.Equal(t, "12345", getEnvValueByName(envs, "DB_PORT"))
 	assert.Equal(t, "test", getEnvValueByName(envs, "DB_DATABASE"))
@@ -514,7 +522,7 @@ func testDisableDeploymentReplicasSyncingFalse(t *testing.T, deploymentFunction
 	}
 	statefulSet := deploymentFunction(cr, dbSecret, nil)
 
-	// when
+	//when
 	statefulSet.Spec.Replicas = &[]int32{4}[0]
 	replicasCount := deploymentFunction2(cr, statefulSet, dbSecret, nil).Spec.Replicas
 
@@ -540,3 +548,95 @@ func testDisableDeploymentReplicasSyncingTrue(t *testing.T, deploymentFunction c
 	//then
 	assert.Equal(t, int32(4), *replicasCountAfterSyncing)
 }
+
+func testDeploymentSpecPodSettings(t *testing.T, deploymentFunction createDeploymentStatefulSet, deploymentReconciledFunction reconciledDeployment) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			Extensions: []string{"https://example.com/extension.jar"},
+			KeycloakDeploymentSpec: v1alpha1.KeycloakDeploymentSpec{
+				DeploymentSpec: v1alpha1.DeploymentSpec{
+					ImagePullSecrets:  []v1.LocalObjectReference{{Name: "registry"}},
+					Tolerations:       []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpExists}},
+					NodeSelector:      map[string]string{"pool": "keycloak"},
+					PriorityClassName: "high",
+					PodSecurityContext: &v1.PodSecurityContext{
+						RunAsNonRoot:   &[]bool{true}[0],
+						SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+					},
+					SecurityContext: &v1.SecurityContext{
+						ReadOnlyRootFilesystem:   &[]bool{true}[0],
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+					},
+					TerminationGracePeriodSeconds: &[]int64{60}[0],
+					HostAliases:                   []v1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"ldap.local"}}},
+					ServiceAccountName:            "keycloak",
+				},
+				Experimental: v1alpha1.ExperimentalSpec{
+					ServiceAccountName: "experimental",
+				},
+			},
+		},
+	}
+
+	//when
+	deployment := deploymentFunction(cr, nil, nil)
+	reconciled := deploymentReconciledFunction(cr, deploymentFunction(&v1alpha1.Keycloak{}, nil, nil), nil, nil)
+
+	//then
+	for _, podSpec := range []v1.PodSpec{deployment.Spec.Template.Spec, reconciled.Spec.Template.Spec} {
+		assert.Equal(t, cr.Spec.KeycloakDeploymentSpec.ImagePullSecrets, podSpec.ImagePullSecrets)
+		assert.Equal(t, cr.Spec.KeycloakDeploymentSpec.Tolerations, podSpec.Tolerations)
+		assert.Equal(t, "keycloak", podSpec.NodeSelector["pool"])
+		assert.Equal(t, "high", podSpec.PriorityClassName)
+		assert.Equal(t, cr.Spec.KeycloakDeploymentSpec.PodSecurityContext, podSpec.SecurityContext)
+		assert.Equal(t, int64(60), *podSpec.TerminationGracePeriodSeconds)
+		assert.Equal(t, cr.Spec.KeycloakDeploymentSpec.HostAliases, podSpec.HostAliases)
+		assert.Equal(t, "keycloak", podSpec.ServiceAccountName)
+		assert.Equal(t, cr.Spec.KeycloakDeploymentSpec.SecurityContext, podSpec.Containers[0].SecurityContext)
+		assert.Equal(t, cr.Spec.KeycloakDeploymentSpec.SecurityContext, podSpec.InitContainers[0].SecurityContext)
+	}
+}
+
+func testSidecarsMergedByName(t *testing.T, deploymentFunction createDeploymentStatefulSet, deploymentReconciledFunction reconciledDeployment) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			KeycloakDeploymentSpec: v1alpha1.KeycloakDeploymentSpec{
+				Sidecars: []v1.Container{
+					{Name: "log-shipper", Image: "fluent-bit:1"},
+					{Name: "cloud-sql-proxy", Image: "cloud-sql-proxy:1"},
+					{Name: KeycloakContainerName, Image: "ignored"},
+				},
+				InitContainers: []v1.Container{{Name: "theme-fetcher", Image: "busybox"}},
+			},
+		},
+	}
+	current := deploymentFunction(cr, nil, nil)
+	current.Spec.Template.Spec.Containers = append(current.Spec.Template.Spec.Containers, v1.Container{Name: "istio-proxy"})
+	current.Spec.Template.Spec.Containers[1].Image = "fluent-bit:0"
+
+	//when
+	created := deploymentFunction(cr, nil, nil)
+	cr.Spec.KeycloakDeploymentSpec.Sidecars = cr.Spec.KeycloakDeploymentSpec.Sidecars[:1]
+	reconciled := deploymentReconciledFunction(cr, current, nil, nil)
+
+	//then
+	names := func(containers []v1.Container) []string {
+		result := []string{}
+		for _, container := range containers {
+			result = append(result, container.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{KeycloakContainerName, "log-shipper", "cloud-sql-proxy"}, names(created.Spec.Template.Spec.Containers))
+	assert.Equal(t, []string{KeycloakExtensionsInitContainerName, "theme-fetcher"}, names(created.Spec.Template.Spec.InitContainers))
+	assert.NotEqual(t, "ignored", created.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "cloud-sql-proxy,log-shipper", created.Spec.Template.Annotations[KeycloakSidecarsAnnotation])
+
+	// The removed sidecar is gone, the one injected by others is kept
+	assert.Equal(t, []string{KeycloakContainerName, "log-shipper", "istio-proxy"}, names(reconciled.Spec.Template.Spec.Containers))
+	assert.Equal(t, "fluent-bit:1", reconciled.Spec.Template.Spec.Containers[1].Image)
+	assert.Equal(t, []string{KeycloakExtensionsInitContainerName, "theme-fetcher"}, names(reconciled.Spec.Template.Spec.InitContainers))
+	assert.Equal(t, "log-shipper", reconciled.Spec.Template.Annotations[KeycloakSidecarsAnnotation])
+}
@@ -11,17 +11,14 @@ import (
 func KeycloakDiscoveryService(cr *v1alpha1.Keycloak) *v1.Service {
 	return &v1.Service{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      KeycloakDiscoveryServiceName,
+			Name:      KeycloakDiscoveryServiceName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 		},
 		Spec: v1.ServiceSpec{
-			Selector: map[string]string{
-				"app":       ApplicationName,
-				"component": KeycloakDeploymentComponent,
-			},
+			Selector: GetLabelsSelector(cr),
 			Ports: []v1.ServicePort{
 				{
 					Port:       8080,
@@ -35,7 +32,7 @@ func KeycloakDiscoveryService(cr *v1alpha1.Keycloak) *v1.Service {
 
 func KeycloakDiscoveryServiceSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      KeycloakDiscoveryServiceName,
+		Name:      KeycloakDiscoveryServiceName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -0,0 +1,278 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"path"
+	"regexp"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	KeycloakExtensionsVolumeName       = "keycloak-extensions"
+	KeycloakThemesVolumeName           = "keycloak-themes"
+	KeycloakExtensionsHashAnnotation   = "keycloak.org/extensions-hash"
+	ExtensionCredentialsUsernameKey    = "username"
+	ExtensionCredentialsPasswordKey    = "password"
+	DefaultExtensionSourceImagePath    = "/extensions"
+	DefaultThemeSourceImagePath        = "/theme"
+	extensionDownloadInitContainerName = "extension-"
+	themeInitContainerName             = "theme-"
+)
+
+// Downloads the file to a temporary name and only moves it into place once the checksum matches
+const extensionDownloadScript = `set -eu
+target="${EXTENSIONS_PATH}/${EXTENSION_FILE}"
+if [ -n "${EXTENSION_USERNAME:-}" ]; then
+  curl -fsSL --retry 5 --retry-delay 2 --user "${EXTENSION_USERNAME}:${EXTENSION_PASSWORD}" -o "${target}.download" "${EXTENSION_URL}"
+else
+  curl -fsSL --retry 5 --retry-delay 2 -o "${target}.download" "${EXTENSION_URL}"
+fi
+echo "${EXTENSION_SHA256}  ${target}.download" | sha256sum -c -
+mv "${target}.download" "${target}"
+`
+
+const extensionCopyScript = `set -eu
+mkdir -p "${TARGET_PATH}"
+cp -R "${SOURCE_PATH}/." "${TARGET_PATH}/"
+`
+
+var sha256Pattern = regexp.MustCompile("^[a-fA-F0-9]{64}$")
+
+// ValidateExtensionSources returns an error if an extension source can not be delivered
+func ValidateExtensionSources(cr *v1alpha1.Keycloak) error {
+	names := map[string]bool{}
+	for _, source := range cr.Spec.ExtensionSources {
+		if errs := validation.IsDNS1123Label(source.Name); len(errs) > 0 {
+			return errors.Errorf("invalid name of extension source %q: %v", source.Name, errs)
+		}
+		if names[source.Name] {
+			return errors.Errorf("duplicate extension source %q", source.Name)
+		}
+		names[source.Name] = true
+
+		sources := 0
+		for _, set := range []bool{source.URL != "", source.Image != "", source.ConfigMap != nil} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return errors.Errorf("extension source %q needs exactly one of url, image and configMap", source.Name)
+		}
+
+		switch source.Type {
+		case v1alpha1.DefaultExtensionSourceType, v1alpha1.ExtensionExtensionSourceType:
+			if source.ConfigMap != nil {
+				return errors.Errorf("extension source %q: configMap is only supported for themes", source.Name)
+			}
+		case v1alpha1.ThemeExtensionSourceType:
+			if source.URL != "" {
+				return errors.Errorf("extension source %q: url is only supported for extensions", source.Name)
+			}
+		default:
+			return errors.Errorf("extension source %q has unknown type %q", source.Name, source.Type)
+		}
+
+		if source.URL != "" {
+			if !sha256Pattern.MatchString(source.SHA256) {
+				return errors.Errorf("extension source %q needs a hex encoded sha256 checksum", source.Name)
+			}
+			if extensionFileName(source) == "" {
+				return errors.Errorf("extension source %q has no file name in its url", source.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// KeycloakExtensionsHash returns a hash of all extensions and themes. It is added to the pod template, so that any
+// change of the extensions, including a new checksum of the same URL, rolls out the StatefulSet.
+func KeycloakExtensionsHash(cr *v1alpha1.Keycloak) string {
+	if len(cr.Spec.Extensions) == 0 && len(cr.Spec.ExtensionSources) == 0 {
+		return ""
+	}
+
+	// Marshalling structs and slices is deterministic
+	content, _ := json.Marshal(struct {
+		Extensions       []string
+		ExtensionSources []v1alpha1.KeycloakExtensionSource
+	}{cr.Spec.Extensions, cr.Spec.ExtensionSources})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// keycloakExtensionSourcesInitContainers returns an init container per extension or theme that is downloaded or
+// copied from an image. Themes from ConfigMaps are mounted directly.
+func keycloakExtensionSourcesInitContainers(cr *v1alpha1.Keycloak) []v1.Container {
+	containers := []v1.Container{}
+	for _, source := range cr.Spec.ExtensionSources {
+		switch {
+		case source.URL != "":
+			containers = append(containers, extensionDownloadInitContainer(cr, source))
+		case source.Image != "" && isTheme(source):
+			containers = append(containers, extensionCopyInitContainer(cr, source, themeInitContainerName+source.Name,
+				KeycloakThemesVolumeName, KeycloakThemesInitContainerPath, path.Join(KeycloakThemesInitContainerPath, source.Name)))
+		case source.Image != "":
+			containers = append(containers, extensionCopyInitContainer(cr, source, extensionDownloadInitContainerName+source.Name,
+				KeycloakExtensionsVolumeName, KeycloakExtensionsInitContainerPath, KeycloakExtensionsInitContainerPath))
+		}
+	}
+	return containers
+}
+
+func extensionDownloadInitContainer(cr *v1alpha1.Keycloak, source v1alpha1.KeycloakExtensionSource) v1.Container {
+	env := []v1.EnvVar{
+		{Name: "EXTENSIONS_PATH", Value: KeycloakExtensionsInitContainerPath},
+		{Name: "EXTENSION_FILE", Value: extensionFileName(source)},
+		{Name: "EXTENSION_URL", Value: source.URL},
+		{Name: "EXTENSION_SHA256", Value: source.SHA256},
+	}
+	if source.CredentialsSecret != "" {
+		env = append(env, extensionCredentialsEnvVar("EXTENSION_USERNAME", source.CredentialsSecret, ExtensionCredentialsUsernameKey),
+			extensionCredentialsEnvVar("EXTENSION_PASSWORD", source.CredentialsSecret, ExtensionCredentialsPasswordKey))
+	}
+
+	return v1.Container{
+		Name:    extensionDownloadInitContainerName + source.Name,
+		Image:   Images.Images[ExtensionsDownloader],
+		Command: []string{"/bin/sh", "-c", extensionDownloadScript},
+		Env:     env,
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      KeycloakExtensionsVolumeName,
+				MountPath: KeycloakExtensionsInitContainerPath,
+			},
+		},
+		TerminationMessagePath:   "/dev/termination-log",
+		TerminationMessagePolicy: "File",
+		ImagePullPolicy:          cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+	}
+}
+
+func extensionCopyInitContainer(cr *v1alpha1.Keycloak, source v1alpha1.KeycloakExtensionSource, name, volume, mountPath, targetPath string) v1.Container {
+	sourcePath := source.Path
+	if sourcePath == "" {
+		sourcePath = DefaultExtensionSourceImagePath
+		if isTheme(source) {
+			sourcePath = DefaultThemeSourceImagePath
+		}
+	}
+
+	return v1.Container{
+		Name:    name,
+		Image:   source.Image,
+		Command: []string{"/bin/sh", "-c", extensionCopyScript},
+		Env: []v1.EnvVar{
+			{Name: "SOURCE_PATH", Value: sourcePath},
+			{Name: "TARGET_PATH", Value: targetPath},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      volume,
+				MountPath: mountPath,
+			},
+		},
+		TerminationMessagePath:   "/dev/termination-log",
+		TerminationMessagePolicy: "File",
+		ImagePullPolicy:          cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+	}
+}
+
+func extensionCredentialsEnvVar(name, secret, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: secret,
+				},
+				Key: key,
+			},
+		},
+	}
+}
+
+// keycloakThemeVolumes returns the volume the themes are copied to and the volumes of the themes from ConfigMaps
+func keycloakThemeVolumes(cr *v1alpha1.Keycloak) []v1.Volume {
+	volumes := []v1.Volume{}
+	themesFromImages := false
+	for _, source := range cr.Spec.ExtensionSources {
+		if !isTheme(source) {
+			continue
+		}
+		if source.ConfigMap != nil {
+			volumes = append(volumes, v1.Volume{
+				Name: themeInitContainerName + source.Name,
+				VolumeSource: v1.VolumeSource{
+					ConfigMap: source.ConfigMap.DeepCopy(),
+				},
+			})
+		} else if source.Image != "" {
+			themesFromImages = true
+		}
+	}
+
+	if themesFromImages {
+		volumes = append(volumes, v1.Volume{
+			Name: KeycloakThemesVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	return volumes
+}
+
+// keycloakThemeVolumeMounts mounts every theme in its own directory below the themes directory of Keycloak
+func keycloakThemeVolumeMounts(cr *v1alpha1.Keycloak, themesPath string) []v1.VolumeMount {
+	mounts := []v1.VolumeMount{}
+	for _, source := range cr.Spec.ExtensionSources {
+		if !isTheme(source) {
+			continue
+		}
+		if source.ConfigMap != nil {
+			mounts = append(mounts, v1.VolumeMount{
+				Name:      themeInitContainerName + source.Name,
+				ReadOnly:  true,
+				MountPath: path.Join(themesPath, source.Name),
+			})
+		} else if source.Image != "" {
+			mounts = append(mounts, v1.VolumeMount{
+				Name:      KeycloakThemesVolumeName,
+				ReadOnly:  true,
+				MountPath: path.Join(themesPath, source.Name),
+				SubPath:   source.Name,
+			})
+		}
+	}
+	return mounts
+}
+
+// addKeycloakExtensionsHash sets the extensions hash on the pod template, or removes it if there are no extensions
+func addKeycloakExtensionsHash(cr *v1alpha1.Keycloak, template *v1.PodTemplateSpec) {
+	template.Annotations = setAnnotation(template.Annotations, KeycloakExtensionsHashAnnotation, KeycloakExtensionsHash(cr))
+}
+
+func isTheme(source v1alpha1.KeycloakExtensionSource) bool {
+	return source.Type == v1alpha1.ThemeExtensionSourceType
+}
+
+// The file name is taken from the URL, so that Keycloak deploys it with the same name as a downloaded extension
+func extensionFileName(source v1alpha1.KeycloakExtensionSource) string {
+	parsed, err := url.Parse(source.URL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
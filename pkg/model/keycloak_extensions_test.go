@@ -0,0 +1,123 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testExtensionSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func extensionSourcesCR(sources ...v1alpha1.KeycloakExtensionSource) *v1alpha1.Keycloak {
+	return &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			ExtensionSources: sources,
+		},
+	}
+}
+
+func TestKeycloakExtensions_testValidation(t *testing.T) {
+	valid := v1alpha1.KeycloakExtensionSource{Name: "metrics", URL: "https://example.com/metrics.jar", SHA256: testExtensionSHA256}
+
+	assert.NoError(t, ValidateExtensionSources(extensionSourcesCR(valid)))
+	assert.Error(t, ValidateExtensionSources(extensionSourcesCR(valid, valid)))
+	assert.Error(t, ValidateExtensionSources(extensionSourcesCR(v1alpha1.KeycloakExtensionSource{Name: "metrics", URL: "https://example.com/metrics.jar"})))
+	assert.Error(t, ValidateExtensionSources(extensionSourcesCR(v1alpha1.KeycloakExtensionSource{Name: "metrics", URL: "https://example.com/metrics.jar", SHA256: testExtensionSHA256, Image: "metrics:1.0"})))
+	assert.Error(t, ValidateExtensionSources(extensionSourcesCR(v1alpha1.KeycloakExtensionSource{Name: "theme", Type: v1alpha1.ThemeExtensionSourceType, URL: "https://example.com/theme.jar", SHA256: testExtensionSHA256})))
+	assert.Error(t, ValidateExtensionSources(extensionSourcesCR(v1alpha1.KeycloakExtensionSource{Name: "theme", ConfigMap: &v1.ConfigMapVolumeSource{}})))
+	assert.Error(t, ValidateExtensionSources(extensionSourcesCR(v1alpha1.KeycloakExtensionSource{Name: "Invalid_Name", Image: "metrics:1.0"})))
+}
+
+func TestKeycloakExtensions_testInitContainersVolumesAndHash(t *testing.T) {
+	//given
+	cr := extensionSourcesCR(
+		v1alpha1.KeycloakExtensionSource{Name: "metrics", URL: "https://example.com/metrics.jar?raw=true", SHA256: testExtensionSHA256, CredentialsSecret: "nexus"},
+		v1alpha1.KeycloakExtensionSource{Name: "spi", Image: "registry.example.com/spi:1.0"},
+		v1alpha1.KeycloakExtensionSource{Name: "corporate", Type: v1alpha1.ThemeExtensionSourceType, Image: "registry.example.com/theme:1.0", Path: "/themes/corporate"},
+		v1alpha1.KeycloakExtensionSource{Name: "login", Type: v1alpha1.ThemeExtensionSourceType, ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: "login-theme"}}},
+	)
+
+	//when
+	deployment := KeycloakDeployment(cr, nil, nil)
+
+	//then
+	initContainers := deployment.Spec.Template.Spec.InitContainers
+	assert.Equal(t, []string{KeycloakExtensionsInitContainerName, "extension-metrics", "extension-spi", "theme-corporate"}, containerNames(initContainers))
+	assert.Equal(t, Images.Images[ExtensionsDownloader], initContainers[1].Image)
+	assert.Contains(t, initContainers[1].Env, v1.EnvVar{Name: "EXTENSION_FILE", Value: "metrics.jar"})
+	assert.Contains(t, initContainers[1].Env, v1.EnvVar{Name: "EXTENSION_SHA256", Value: testExtensionSHA256})
+	assert.Equal(t, "nexus", initContainers[1].Env[len(initContainers[1].Env)-1].ValueFrom.SecretKeyRef.Name)
+	assert.True(t, strings.Contains(initContainers[1].Command[2], "sha256sum -c"))
+	assert.Equal(t, "registry.example.com/spi:1.0", initContainers[2].Image)
+	assert.Contains(t, initContainers[2].Env, v1.EnvVar{Name: "SOURCE_PATH", Value: DefaultExtensionSourceImagePath})
+	assert.Contains(t, initContainers[3].Env, v1.EnvVar{Name: "SOURCE_PATH", Value: "/themes/corporate"})
+	assert.Contains(t, initContainers[3].Env, v1.EnvVar{Name: "TARGET_PATH", Value: KeycloakThemesInitContainerPath + "/corporate"})
+
+	mounts := deployment.Spec.Template.Spec.Containers[0].VolumeMounts
+	assert.Contains(t, mounts, v1.VolumeMount{Name: KeycloakThemesVolumeName, ReadOnly: true, MountPath: KeycloakThemesPath + "/corporate", SubPath: "corporate"})
+	assert.Contains(t, mounts, v1.VolumeMount{Name: "theme-login", ReadOnly: true, MountPath: KeycloakThemesPath + "/login"})
+	volumes := map[string]bool{}
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		volumes[volume.Name] = true
+	}
+	assert.True(t, volumes[KeycloakThemesVolumeName])
+	assert.True(t, volumes["theme-login"])
+
+	assert.Equal(t, KeycloakExtensionsHash(cr), deployment.Spec.Template.Annotations[KeycloakExtensionsHashAnnotation])
+	assert.Equal(t, KeycloakExtensionsHash(cr), KeycloakExtensionsHash(cr.DeepCopy()))
+}
+
+func TestKeycloakExtensions_testRemovedSourceRollsOut(t *testing.T) {
+	//given
+	cr := extensionSourcesCR(
+		v1alpha1.KeycloakExtensionSource{Name: "metrics", URL: "https://example.com/metrics.jar", SHA256: testExtensionSHA256},
+		v1alpha1.KeycloakExtensionSource{Name: "spi", Image: "registry.example.com/spi:1.0"},
+	)
+	current := KeycloakDeployment(cr, nil, nil)
+	cr.Spec.ExtensionSources = cr.Spec.ExtensionSources[:1]
+
+	//when
+	reconciled := KeycloakDeploymentReconciled(cr, current, nil, nil)
+
+	//then
+	assert.Equal(t, []string{KeycloakExtensionsInitContainerName, "extension-metrics"}, containerNames(reconciled.Spec.Template.Spec.InitContainers))
+	assert.NotEqual(t, current.Spec.Template.Annotations[KeycloakExtensionsHashAnnotation], reconciled.Spec.Template.Annotations[KeycloakExtensionsHashAnnotation])
+
+	cr.Spec.ExtensionSources = nil
+	reconciled = KeycloakDeploymentReconciled(cr, reconciled, nil, nil)
+	_, hasHash := reconciled.Spec.Template.Annotations[KeycloakExtensionsHashAnnotation]
+	assert.False(t, hasHash)
+}
+
+func TestKeycloakExtensions_testForeignInitContainersKept(t *testing.T) {
+	//given
+	cr := extensionSourcesCR(
+		v1alpha1.KeycloakExtensionSource{Name: "metrics", URL: "https://example.com/metrics.jar", SHA256: testExtensionSHA256},
+	)
+	current := KeycloakDeployment(cr, nil, nil)
+	// Added by someone else, with a name like the ones of extension sources
+	current.Spec.Template.Spec.InitContainers = append(current.Spec.Template.Spec.InitContainers, v1.Container{Name: "extension-vault"})
+	cr.Spec.ExtensionSources = nil
+
+	//when
+	reconciled := KeycloakDeploymentReconciled(cr, current, nil, nil)
+
+	//then
+	assert.Equal(t, "extension-metrics", current.Spec.Template.Annotations[KeycloakInitContainersAnnotation])
+	assert.Equal(t, []string{KeycloakExtensionsInitContainerName, "extension-vault"}, containerNames(reconciled.Spec.Template.Spec.InitContainers))
+	_, hasAnnotation := reconciled.Spec.Template.Annotations[KeycloakInitContainersAnnotation]
+	assert.False(t, hasAnnotation)
+}
+
+func containerNames(containers []v1.Container) []string {
+	names := []string{}
+	for _, container := range containers {
+		names = append(names, container.Name)
+	}
+	return names
+}
@@ -0,0 +1,222 @@
+package model
+
+import (
+	"strings"
+
+	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The Gateway API is not vendored, its routes are handled as unstructured objects
+const (
+	GatewayAPIGroup = "gateway.networking.k8s.io"
+	HTTPRouteKind   = "HTTPRoute"
+	TLSRouteKind    = "TLSRoute"
+)
+
+var (
+	HTTPRouteGroupVersionKind = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1", Kind: HTTPRouteKind}
+	TLSRouteGroupVersionKind  = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1alpha2", Kind: TLSRouteKind}
+)
+
+// KeycloakHTTPRoute terminates TLS on the Gateway, which forwards the requests to the HTTP port of the Keycloak service
+func KeycloakHTTPRoute(cr *kc.Keycloak) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGroupVersionKind)
+	route.SetName(KeycloakServiceName(cr))
+	route.SetNamespace(cr.Namespace)
+	route.SetLabels(keycloakIngressLabels(cr, nil))
+	route.SetAnnotations(keycloakGatewayRouteAnnotations(cr, nil))
+	route.Object["spec"] = keycloakHTTPRouteSpec(cr)
+	return route
+}
+
+func KeycloakHTTPRouteReconciled(cr *kc.Keycloak, currentState *unstructured.Unstructured) *unstructured.Unstructured {
+	reconciled := currentState.DeepCopy()
+	reconciled.SetLabels(keycloakIngressLabels(cr, currentState.GetLabels()))
+	reconciled.SetAnnotations(keycloakGatewayRouteAnnotations(cr, currentState.GetAnnotations()))
+	reconciled.Object["spec"] = keycloakHTTPRouteSpec(cr)
+	return reconciled
+}
+
+func KeycloakHTTPRouteSelector(cr *kc.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakServiceName(cr),
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakTLSRoute passes the encrypted traffic through the Gateway to Keycloak
+func KeycloakTLSRoute(cr *kc.Keycloak) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(TLSRouteGroupVersionKind)
+	route.SetName(KeycloakServiceName(cr))
+	route.SetNamespace(cr.Namespace)
+	route.SetLabels(keycloakIngressLabels(cr, nil))
+	route.SetAnnotations(keycloakGatewayRouteAnnotations(cr, nil))
+	route.Object["spec"] = keycloakTLSRouteSpec(cr)
+	return route
+}
+
+func KeycloakTLSRouteReconciled(cr *kc.Keycloak, currentState *unstructured.Unstructured) *unstructured.Unstructured {
+	reconciled := currentState.DeepCopy()
+	reconciled.SetLabels(keycloakIngressLabels(cr, currentState.GetLabels()))
+	reconciled.SetAnnotations(keycloakGatewayRouteAnnotations(cr, currentState.GetAnnotations()))
+	reconciled.Object["spec"] = keycloakTLSRouteSpec(cr)
+	return reconciled
+}
+
+func KeycloakTLSRouteSelector(cr *kc.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakServiceName(cr),
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakGatewayTerminatesTLS returns true if Keycloak is exposed by an HTTPRoute
+func KeycloakGatewayTerminatesTLS(cr *kc.Keycloak) bool {
+	access := cr.Spec.ExternalAccess
+	return access.Enabled && access.Mode == kc.GatewayExternalAccessMode && access.TLSTermination != kc.PassthroughTLSTerminationType
+}
+
+// KeycloakGatewayRouteHostnames returns the hostnames of an HTTPRoute or TLSRoute
+func KeycloakGatewayRouteHostnames(route *unstructured.Unstructured) []string {
+	if route == nil {
+		return nil
+	}
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	return hostnames
+}
+
+func keycloakGatewayRouteHostnames(cr *kc.Keycloak) []interface{} {
+	hostnames := cr.Spec.ExternalAccess.Gateway.Hostnames
+	if len(hostnames) == 0 && cr.Spec.ExternalAccess.Host != "" {
+		hostnames = []string{cr.Spec.ExternalAccess.Host}
+	}
+
+	result := []interface{}{}
+	for _, hostname := range hostnames {
+		result = append(result, hostname)
+	}
+	return result
+}
+
+func keycloakGatewayRouteParentRefs(cr *kc.Keycloak) []interface{} {
+	gateway := cr.Spec.ExternalAccess.Gateway
+	namespace := gateway.Namespace
+	if namespace == "" {
+		namespace = cr.Namespace
+	}
+
+	parentRef := map[string]interface{}{
+		"group":     GatewayAPIGroup,
+		"kind":      "Gateway",
+		"name":      gateway.Name,
+		"namespace": namespace,
+	}
+	if gateway.SectionName != "" {
+		parentRef["sectionName"] = gateway.SectionName
+	}
+	return []interface{}{parentRef}
+}
+
+func keycloakGatewayRouteBackendRefs(cr *kc.Keycloak, port int) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"name": KeycloakServiceName(cr),
+			"port": int64(port),
+		},
+	}
+}
+
+func keycloakHTTPRouteSpec(cr *kc.Keycloak) map[string]interface{} {
+	prefix := keycloakIngressPathPrefix(cr)
+	path := prefix
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	rules := []interface{}{
+		// Requests for the metrics are redirected to the realm, like the metrics Route on OpenShift does
+		map[string]interface{}{
+			"matches": []interface{}{
+				map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":  "PathPrefix",
+						"value": prefix + KeycloakIngressBlockedMetricsPath,
+					},
+				},
+			},
+			"filters": []interface{}{
+				map[string]interface{}{
+					"type": "RequestRedirect",
+					"requestRedirect": map[string]interface{}{
+						"statusCode": int64(301),
+						"path": map[string]interface{}{
+							"type":            "ReplaceFullPath",
+							"replaceFullPath": strings.TrimSuffix(prefix, "/") + KeycloakMetricsRouteRewritePath,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rule := map[string]interface{}{
+		"matches": []interface{}{
+			map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":  "PathPrefix",
+					"value": path,
+				},
+			},
+		},
+		"backendRefs": keycloakGatewayRouteBackendRefs(cr, KeycloakServiceHTTPPort),
+	}
+	// Keycloak is served under the root path and configured with the prefixed frontend URL
+	if prefix != "/" {
+		rule["filters"] = []interface{}{
+			map[string]interface{}{
+				"type": "URLRewrite",
+				"urlRewrite": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":               "ReplacePrefixMatch",
+						"replacePrefixMatch": "/",
+					},
+				},
+			},
+		}
+	}
+	rules = append(rules, rule)
+
+	return map[string]interface{}{
+		"parentRefs": keycloakGatewayRouteParentRefs(cr),
+		"hostnames":  keycloakGatewayRouteHostnames(cr),
+		"rules":      rules,
+	}
+}
+
+func keycloakTLSRouteSpec(cr *kc.Keycloak) map[string]interface{} {
+	return map[string]interface{}{
+		"parentRefs": keycloakGatewayRouteParentRefs(cr),
+		"hostnames":  keycloakGatewayRouteHostnames(cr),
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": keycloakGatewayRouteBackendRefs(cr, KeycloakServicePort),
+			},
+		},
+	}
+}
+
+func keycloakGatewayRouteAnnotations(cr *kc.Keycloak, current map[string]string) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range current {
+		annotations[key] = value
+	}
+	for key, value := range cr.Spec.ExternalAccess.Annotations {
+		annotations[key] = value
+	}
+	return annotations
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKeycloakGatewayRoute_testHTTPRoute(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v1.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled: true,
+				Host:    "sso.example.com",
+				Mode:    v1alpha1.GatewayExternalAccessMode,
+				Gateway: v1alpha1.KeycloakGatewayAccess{
+					Name:        "public",
+					Namespace:   "gateways",
+					SectionName: "https",
+				},
+			},
+		},
+	}
+
+	//when
+	route := KeycloakHTTPRoute(cr)
+
+	//then
+	assert.Equal(t, HTTPRouteGroupVersionKind, route.GroupVersionKind())
+	assert.Equal(t, "sso", route.GetName())
+	assert.Equal(t, []string{"sso.example.com"}, KeycloakGatewayRouteHostnames(route))
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	assert.Equal(t, "public", parentRefs[0].(map[string]interface{})["name"])
+	assert.Equal(t, "gateways", parentRefs[0].(map[string]interface{})["namespace"])
+	assert.Equal(t, "https", parentRefs[0].(map[string]interface{})["sectionName"])
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	assert.Len(t, rules, 2)
+	backendRefs := rules[1].(map[string]interface{})["backendRefs"].([]interface{})
+	assert.Equal(t, "sso", backendRefs[0].(map[string]interface{})["name"])
+	assert.Equal(t, int64(KeycloakServiceHTTPPort), backendRefs[0].(map[string]interface{})["port"])
+	assert.Nil(t, rules[1].(map[string]interface{})["filters"])
+}
+
+func TestKeycloakGatewayRoute_testHTTPRoutePathPrefix(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v1.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled:    true,
+				Host:       "example.com",
+				PathPrefix: "/sso",
+				Mode:       v1alpha1.GatewayExternalAccessMode,
+				Gateway:    v1alpha1.KeycloakGatewayAccess{Name: "public"},
+			},
+		},
+	}
+
+	//when
+	route := KeycloakHTTPRoute(cr)
+
+	//then
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	matches := rules[1].(map[string]interface{})["matches"].([]interface{})
+	assert.Equal(t, "/sso", matches[0].(map[string]interface{})["path"].(map[string]interface{})["value"])
+	filters := rules[1].(map[string]interface{})["filters"].([]interface{})
+	replacePrefixMatch, _, _ := unstructured.NestedString(filters[0].(map[string]interface{}), "urlRewrite", "path", "replacePrefixMatch")
+	assert.Equal(t, "URLRewrite", filters[0].(map[string]interface{})["type"])
+	assert.Equal(t, "/", replacePrefixMatch)
+}
+
+func TestKeycloakGatewayRoute_testServiceHTTPPort(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v1.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled: true,
+				Mode:    v1alpha1.GatewayExternalAccessMode,
+				Gateway: v1alpha1.KeycloakGatewayAccess{Name: "public"},
+			},
+		},
+	}
+	passthrough := cr.DeepCopy()
+	passthrough.Spec.ExternalAccess.TLSTermination = v1alpha1.PassthroughTLSTerminationType
+
+	//when
+	service := KeycloakService(cr)
+	reconciled := KeycloakServiceReconciled(passthrough, service)
+
+	//then
+	assert.Len(t, service.Spec.Ports, 2)
+	assert.Equal(t, int32(KeycloakServiceHTTPPort), service.Spec.Ports[1].Port)
+	assert.Equal(t, KeycloakServiceHTTPPortName, service.Spec.Ports[1].Name)
+	assert.Len(t, reconciled.Spec.Ports, 1)
+	assert.Equal(t, int32(KeycloakServicePort), reconciled.Spec.Ports[0].Port)
+	assert.Len(t, KeycloakService(&v1alpha1.Keycloak{}).Spec.Ports, 1)
+}
+
+func TestKeycloakGatewayRoute_testTLSRouteReconciled(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v1.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled:        true,
+				TLSTermination: v1alpha1.PassthroughTLSTerminationType,
+				Mode:           v1alpha1.GatewayExternalAccessMode,
+				Gateway: v1alpha1.KeycloakGatewayAccess{
+					Name:      "public",
+					Hostnames: []string{"sso.example.com", "login.example.com"},
+				},
+			},
+		},
+	}
+	currentState := KeycloakTLSRoute(&v1alpha1.Keycloak{ObjectMeta: v1.ObjectMeta{Name: "sso", Namespace: "keycloak"}})
+	currentState.SetAnnotations(map[string]string{"external": "value"})
+
+	//when
+	route := KeycloakTLSRouteReconciled(cr, currentState)
+
+	//then
+	assert.Equal(t, TLSRouteGroupVersionKind, route.GroupVersionKind())
+	assert.Equal(t, []string{"sso.example.com", "login.example.com"}, KeycloakGatewayRouteHostnames(route))
+	assert.Equal(t, "value", route.GetAnnotations()["external"])
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	assert.Equal(t, "keycloak", parentRefs[0].(map[string]interface{})["namespace"])
+	assert.Empty(t, KeycloakGatewayRouteHostnames(currentState))
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/apps/v1"
+	"k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// autoscaling/v2 is not vendored. Its schema equals the one of autoscaling/v2beta2, the HorizontalPodAutoscaler is
+// built from the v2beta2 types and handled as an unstructured autoscaling/v2 object.
+const HorizontalPodAutoscalerKind = "HorizontalPodAutoscaler"
+
+var HorizontalPodAutoscalerGroupVersionKind = schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: HorizontalPodAutoscalerKind}
+
+func KeycloakHorizontalPodAutoscaler(cr *v1alpha1.Keycloak) *unstructured.Unstructured {
+	hpa := &unstructured.Unstructured{}
+	hpa.SetGroupVersionKind(HorizontalPodAutoscalerGroupVersionKind)
+	hpa.SetName(KeycloakDeploymentName(cr))
+	hpa.SetNamespace(cr.Namespace)
+	hpa.SetLabels(keycloakHorizontalPodAutoscalerLabels(cr, nil))
+	hpa.Object["spec"] = keycloakHorizontalPodAutoscalerSpec(cr)
+	return hpa
+}
+
+func KeycloakHorizontalPodAutoscalerReconciled(cr *v1alpha1.Keycloak, currentState *unstructured.Unstructured) *unstructured.Unstructured {
+	reconciled := currentState.DeepCopy()
+	reconciled.SetLabels(keycloakHorizontalPodAutoscalerLabels(cr, currentState.GetLabels()))
+	reconciled.Object["spec"] = keycloakHorizontalPodAutoscalerSpec(cr)
+	return reconciled
+}
+
+func KeycloakHorizontalPodAutoscalerSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakDeploymentName(cr),
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakReplicasSynced returns true if the replicas of the StatefulSet are synced from the instances of the CR
+func KeycloakReplicasSynced(cr *v1alpha1.Keycloak) bool {
+	return !cr.Spec.DisableReplicasSyncing && !cr.Spec.Autoscaling.Enabled
+}
+
+// KeycloakAutoscalingMinReplicas returns the lower bound of the HorizontalPodAutoscaler
+func KeycloakAutoscalingMinReplicas(cr *v1alpha1.Keycloak) int32 {
+	if cr.Spec.Autoscaling.MinReplicas != nil {
+		return *cr.Spec.Autoscaling.MinReplicas
+	}
+	return *SanitizeNumberOfReplicas(cr.Spec.Instances, true)
+}
+
+func keycloakHorizontalPodAutoscalerSpec(cr *v1alpha1.Keycloak) map[string]interface{} {
+	autoscaling := cr.Spec.Autoscaling
+	spec := v2beta2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: v2beta2.CrossVersionObjectReference{
+			APIVersion: v13.SchemeGroupVersion.String(),
+			Kind:       "StatefulSet",
+			Name:       KeycloakDeploymentName(cr),
+		},
+		MinReplicas: &[]int32{KeycloakAutoscalingMinReplicas(cr)}[0],
+		MaxReplicas: autoscaling.MaxReplicas,
+		Metrics:     []v2beta2.MetricSpec{},
+	}
+
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		spec.Metrics = append(spec.Metrics, resourceMetric(v1.ResourceCPU, *autoscaling.TargetCPUUtilizationPercentage))
+	}
+	if autoscaling.TargetMemoryUtilizationPercentage != nil {
+		spec.Metrics = append(spec.Metrics, resourceMetric(v1.ResourceMemory, *autoscaling.TargetMemoryUtilizationPercentage))
+	}
+
+	for _, metric := range autoscaling.Metrics {
+		target := v2beta2.MetricTarget{
+			Type:         v2beta2.AverageValueMetricType,
+			AverageValue: &[]resource.Quantity{metric.TargetAverageValue.DeepCopy()}[0],
+		}
+
+		if metric.Type == v1alpha1.ServiceAutoscalingMetricType {
+			spec.Metrics = append(spec.Metrics, v2beta2.MetricSpec{
+				Type: v2beta2.ObjectMetricSourceType,
+				Object: &v2beta2.ObjectMetricSource{
+					DescribedObject: v2beta2.CrossVersionObjectReference{
+						APIVersion: v1.SchemeGroupVersion.String(),
+						Kind:       "Service",
+						Name:       KeycloakMonitoringServiceName(cr),
+					},
+					Metric: v2beta2.MetricIdentifier{Name: metric.Name},
+					Target: target,
+				},
+			})
+			continue
+		}
+
+		spec.Metrics = append(spec.Metrics, v2beta2.MetricSpec{
+			Type: v2beta2.PodsMetricSourceType,
+			Pods: &v2beta2.PodsMetricSource{
+				Metric: v2beta2.MetricIdentifier{Name: metric.Name},
+				Target: target,
+			},
+		})
+	}
+
+	// Without any metric the HorizontalPodAutoscaler scales on the CPU, like kubectl autoscale does
+	if len(spec.Metrics) == 0 {
+		spec.Metrics = append(spec.Metrics, resourceMetric(v1.ResourceCPU, 80))
+	}
+
+	if autoscaling.ScaleDownStabilizationWindowSeconds != nil {
+		spec.Behavior = &v2beta2.HorizontalPodAutoscalerBehavior{
+			ScaleDown: &v2beta2.HPAScalingRules{
+				StabilizationWindowSeconds: autoscaling.ScaleDownStabilizationWindowSeconds,
+			},
+		}
+	}
+
+	// Converting the typed spec can't fail
+	content, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	return content
+}
+
+func keycloakHorizontalPodAutoscalerLabels(cr *v1alpha1.Keycloak, current map[string]string) map[string]string {
+	labels := map[string]string{}
+	for key, value := range current {
+		labels[key] = value
+	}
+	for key, value := range instanceLabels(cr, map[string]string{"app": ApplicationName}) {
+		labels[key] = value
+	}
+	return labels
+}
+
+func resourceMetric(name v1.ResourceName, averageUtilization int32) v2beta2.MetricSpec {
+	return v2beta2.MetricSpec{
+		Type: v2beta2.ResourceMetricSourceType,
+		Resource: &v2beta2.ResourceMetricSource{
+			Name: name,
+			Target: v2beta2.MetricTarget{
+				Type:               v2beta2.UtilizationMetricType,
+				AverageUtilization: &[]int32{averageUtilization}[0],
+			},
+		},
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKeycloakHorizontalPodAutoscaler_testMetrics(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			Instances: 2,
+			Autoscaling: v1alpha1.KeycloakAutoscaling{
+				Enabled:                        true,
+				MaxReplicas:                    6,
+				TargetCPUUtilizationPercentage: &[]int32{70}[0],
+				Metrics: []v1alpha1.KeycloakAutoscalingMetric{
+					{Name: "keycloak_active_sessions", Type: v1alpha1.ServiceAutoscalingMetricType, TargetAverageValue: resource.MustParse("500")},
+					{Name: "keycloak_logins_per_second", TargetAverageValue: resource.MustParse("20")},
+				},
+				ScaleDownStabilizationWindowSeconds: &[]int32{600}[0],
+			},
+		},
+	}
+
+	//when
+	hpa := KeycloakHorizontalPodAutoscaler(cr)
+
+	//then
+	assert.Equal(t, HorizontalPodAutoscalerGroupVersionKind, hpa.GroupVersionKind())
+	target, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "name")
+	assert.Equal(t, KeycloakDeploymentName(cr), target)
+	minReplicas, _, _ := unstructured.NestedInt64(hpa.Object, "spec", "minReplicas")
+	assert.Equal(t, int64(2), minReplicas)
+	maxReplicas, _, _ := unstructured.NestedInt64(hpa.Object, "spec", "maxReplicas")
+	assert.Equal(t, int64(6), maxReplicas)
+
+	metrics, _, _ := unstructured.NestedSlice(hpa.Object, "spec", "metrics")
+	assert.Len(t, metrics, 3)
+	utilization, _, _ := unstructured.NestedInt64(metrics[0].(map[string]interface{}), "resource", "target", "averageUtilization")
+	assert.Equal(t, int64(70), utilization)
+	service, _, _ := unstructured.NestedString(metrics[1].(map[string]interface{}), "object", "describedObject", "name")
+	assert.Equal(t, KeycloakMonitoringServiceName(cr), service)
+	podsMetric, _, _ := unstructured.NestedString(metrics[2].(map[string]interface{}), "pods", "metric", "name")
+	assert.Equal(t, "keycloak_logins_per_second", podsMetric)
+	window, _, _ := unstructured.NestedInt64(hpa.Object, "spec", "behavior", "scaleDown", "stabilizationWindowSeconds")
+	assert.Equal(t, int64(600), window)
+}
+
+func TestKeycloakHorizontalPodAutoscaler_testReplicasNotSynced(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakSpec{
+			Instances:   2,
+			Autoscaling: v1alpha1.KeycloakAutoscaling{Enabled: true, MaxReplicas: 6},
+		},
+	}
+	current := KeycloakDeployment(cr, nil, nil)
+	current.Spec.Replicas = &[]int32{5}[0]
+
+	//when
+	reconciled := KeycloakDeploymentReconciled(cr, current, nil, nil)
+
+	//then
+	assert.Equal(t, int32(5), *reconciled.Spec.Replicas)
+	metrics, _, _ := unstructured.NestedSlice(KeycloakHorizontalPodAutoscaler(cr).Object, "spec", "metrics")
+	assert.Len(t, metrics, 1)
+}
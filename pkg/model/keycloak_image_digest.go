@@ -0,0 +1,54 @@
+package model
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// KeycloakImageDigest returns the image pinned to the digest the Keycloak containers of the pods run, e.g.
+// quay.io/keycloak/keycloak@sha256:<digest>. It's empty unless all pods run the image with the same digest.
+func KeycloakImageDigest(image string, pods []v1.Pod) string {
+	digest := ""
+	for _, pod := range pods {
+		podDigest := keycloakContainerDigest(image, &pod)
+		if podDigest == "" || (digest != "" && podDigest != digest) {
+			return ""
+		}
+		digest = podDigest
+	}
+	if digest == "" {
+		return ""
+	}
+	return imageRepository(image) + "@" + digest
+}
+
+// The digest of the image the Keycloak container runs, the ID reported by the runtime is prefixed by a scheme like
+// docker-pullable:// and the repository
+func keycloakContainerDigest(image string, pod *v1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == KeycloakContainerName && container.Image != image {
+			return ""
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != KeycloakContainerName {
+			continue
+		}
+		separator := strings.LastIndex(status.ImageID, "@")
+		if separator < 0 {
+			return ""
+		}
+		return status.ImageID[separator+1:]
+	}
+	return ""
+}
+
+// The image without tag and digest
+func imageRepository(image string) string {
+	image = strings.SplitN(image, "@", 2)[0]
+	if separator := strings.LastIndex(image, ":"); separator > strings.LastIndex(image, "/") {
+		return image[:separator]
+	}
+	return image
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestKeycloakImageDigest_testAllPodsRunTheSameDigest(t *testing.T) {
+	//given
+	image := "registry:5000/keycloak/keycloak:15.0.2"
+	pods := []v1.Pod{
+		keycloakPod(image, "docker-pullable://registry:5000/keycloak/keycloak@sha256:4f2a"),
+		keycloakPod(image, "registry:5000/keycloak/keycloak@sha256:4f2a"),
+	}
+
+	//when
+	digest := KeycloakImageDigest(image, pods)
+
+	//then
+	assert.Equal(t, "registry:5000/keycloak/keycloak@sha256:4f2a", digest)
+}
+
+func TestKeycloakImageDigest_testPinnedImage(t *testing.T) {
+	image := "quay.io/keycloak/keycloak:15.0.2@sha256:4f2a"
+	digest := KeycloakImageDigest(image, []v1.Pod{keycloakPod(image, "quay.io/keycloak/keycloak@sha256:4f2a")})
+	assert.Equal(t, "quay.io/keycloak/keycloak@sha256:4f2a", digest)
+}
+
+func TestKeycloakImageDigest_testUnresolvedDigest(t *testing.T) {
+	image := "quay.io/keycloak/keycloak:15.0.2"
+
+	// pods running different digests during a rollout
+	assert.Empty(t, KeycloakImageDigest(image, []v1.Pod{
+		keycloakPod(image, "quay.io/keycloak/keycloak@sha256:4f2a"),
+		keycloakPod(image, "quay.io/keycloak/keycloak@sha256:9c1e"),
+	}))
+	// pod still running the previous image
+	assert.Empty(t, KeycloakImageDigest(image, []v1.Pod{
+		keycloakPod("quay.io/keycloak/keycloak:14.0.0", "quay.io/keycloak/keycloak@sha256:4f2a"),
+	}))
+	// image id without repository digest
+	assert.Empty(t, KeycloakImageDigest(image, []v1.Pod{keycloakPod(image, "sha256:4f2a")}))
+	assert.Empty(t, KeycloakImageDigest(image, nil))
+}
+
+func keycloakPod(image string, imageID string) v1.Pod {
+	return v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: KeycloakContainerName, Image: image}},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{Name: KeycloakContainerName, Image: image, ImageID: imageID}},
+		},
+	}
+}
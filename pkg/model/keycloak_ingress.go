@@ -1,83 +1,154 @@
 package model
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	kc "github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
 	networkingv1 "k8s.io/api/networking/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func KeycloakIngress(cr *kc.Keycloak) *networkingv1.Ingress {
-	ingressHost := cr.Spec.ExternalAccess.Host
-	if ingressHost == "" {
-		ingressHost = IngressDefaultHost
+// Paths that should not be reachable from outside the cluster, relative to the path prefix
+const (
+	KeycloakIngressBlockedMetricsPath = "auth/realms/master/metrics"
+	KeycloakIngressBlockedAdminPath   = "auth/admin"
+)
+
+// The prefix is used in the regular expressions of the rewrites, so it is limited to unreserved URL characters
+var keycloakIngressPathPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9._~/-]*$`)
+
+// Annotations set by the ingress profiles, they are removed if the profile or the settings they depend on change
+var ingressProfileAnnotationKeys = []string{
+	"nginx.ingress.kubernetes.io/backend-protocol",
+	"nginx.ingress.kubernetes.io/server-snippet",
+	"nginx.ingress.kubernetes.io/use-regex",
+	"nginx.ingress.kubernetes.io/rewrite-target",
+	"haproxy.org/server-ssl",
+	"haproxy.org/backend-config-snippet",
+	"haproxy.org/path-rewrite",
+}
+
+// ValidateExternalAccess returns an error if the ingress profile, path type or path prefix can't be applied
+func ValidateExternalAccess(cr *kc.Keycloak) error {
+	access := cr.Spec.ExternalAccess
+	switch access.IngressProfile {
+	case kc.DefaultIngressProfile, kc.NginxIngressProfile, kc.HAProxyIngressProfile, kc.NoneIngressProfile:
+	default:
+		return errors.Errorf("externalAccess.ingressProfile %q is not supported, use nginx, haproxy or none", access.IngressProfile)
 	}
 
-	pathTypeImplementationSpecific := networkingv1.PathTypeImplementationSpecific // a workaround to get constant's address
+	switch networkingv1.PathType(access.PathType) {
+	case "", networkingv1.PathTypePrefix, networkingv1.PathTypeExact, networkingv1.PathTypeImplementationSpecific:
+	default:
+		return errors.Errorf("externalAccess.pathType %q is not supported, use Prefix, Exact or ImplementationSpecific", access.PathType)
+	}
 
+	if !keycloakIngressPathPrefixPattern.MatchString(access.PathPrefix) {
+		return errors.Errorf("externalAccess.pathPrefix %q may only contain letters, digits, '.', '_', '~', '-' and '/'", access.PathPrefix)
+	}
+	if keycloakIngressRewritesRegex(cr) && access.PathType != "" && networkingv1.PathType(access.PathType) != networkingv1.PathTypeImplementationSpecific {
+		return errors.Errorf("externalAccess.pathType needs to be ImplementationSpecific with a pathPrefix and the nginx ingress profile")
+	}
+	return nil
+}
+
+// KeycloakFrontendURL returns the external URL of Keycloak if it is exposed under a path prefix, so Keycloak includes
+// the prefix in its redirects and links. Without a prefix the URL is taken from the request.
+func KeycloakFrontendURL(cr *kc.Keycloak) string {
+	prefix := keycloakIngressPathPrefix(cr)
+	if !cr.Spec.ExternalAccess.Enabled || prefix == "/" {
+		return ""
+	}
+
+	host := keycloakIngressHost(cr)
+	if hostnames := cr.Spec.ExternalAccess.Gateway.Hostnames; cr.Spec.ExternalAccess.Mode == kc.GatewayExternalAccessMode && len(hostnames) > 0 {
+		host = hostnames[0]
+	}
+	return "https://" + host + prefix + "auth"
+}
+
+func KeycloakIngress(cr *kc.Keycloak) *networkingv1.Ingress {
 	return &networkingv1.Ingress{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      ApplicationName,
-			Namespace: cr.Namespace,
-			Labels: map[string]string{
-				"app": ApplicationName,
-			},
-			Annotations: map[string]string{
-				"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
-				"nginx.ingress.kubernetes.io/server-snippet": `
-                      location ~* "^/auth/realms/master/metrics" {
-                          return 301 /auth/realms/master;
-                        }`,
-			},
-		},
-		Spec: networkingv1.IngressSpec{
-			Rules: []networkingv1.IngressRule{
-				{
-					Host: ingressHost,
-					IngressRuleValue: networkingv1.IngressRuleValue{
-						HTTP: &networkingv1.HTTPIngressRuleValue{
-							Paths: []networkingv1.HTTPIngressPath{
-								{
-									Path:     "/",
-									PathType: &pathTypeImplementationSpecific,
-									Backend: networkingv1.IngressBackend{
-										Service: &networkingv1.IngressServiceBackend{
-											Name: ApplicationName,
-											Port: networkingv1.ServiceBackendPort{
-												Number: KeycloakServicePort,
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+			Name:        KeycloakServiceName(cr),
+			Namespace:   cr.Namespace,
+			Labels:      keycloakIngressLabels(cr, nil),
+			Annotations: keycloakIngressAnnotations(cr, nil),
 		},
+		Spec: keycloakIngressSpec(cr, nil),
 	}
 }
 
 func KeycloakIngressReconciled(cr *kc.Keycloak, currentState *networkingv1.Ingress) *networkingv1.Ingress {
 	reconciled := currentState.DeepCopy()
-	reconciledHost := currentState.Spec.Rules[0].Host
-	reconciledSpecTLS := currentState.Spec.TLS
-	pathTypeImplementationSpecific := networkingv1.PathTypeImplementationSpecific // a workaround to get constant's address
+	reconciled.Labels = keycloakIngressLabels(cr, currentState.Labels)
+	reconciled.Annotations = keycloakIngressAnnotations(cr, currentState.Annotations)
+	reconciled.Spec = keycloakIngressSpec(cr, currentState.Spec.TLS)
+	return reconciled
+}
+
+func KeycloakIngressSelector(cr *kc.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakServiceName(cr),
+		Namespace: cr.Namespace,
+	}
+}
 
-	reconciled.Spec = networkingv1.IngressSpec{
-		TLS: reconciledSpecTLS,
+func keycloakIngressHost(cr *kc.Keycloak) string {
+	if cr.Spec.ExternalAccess.Host != "" {
+		return cr.Spec.ExternalAccess.Host
+	}
+	return IngressDefaultHost
+}
+
+// Returns the path prefix with a leading and a trailing slash
+func keycloakIngressPathPrefix(cr *kc.Keycloak) string {
+	prefix := strings.Trim(cr.Spec.ExternalAccess.PathPrefix, "/")
+	if prefix == "" {
+		return "/"
+	}
+	return "/" + prefix + "/"
+}
+
+// The nginx profile strips the path prefix with a regular expression matching the prefix and capturing the rest
+func keycloakIngressRewritesRegex(cr *kc.Keycloak) bool {
+	profile := cr.Spec.ExternalAccess.IngressProfile
+	return keycloakIngressPathPrefix(cr) != "/" && (profile == kc.DefaultIngressProfile || profile == kc.NginxIngressProfile)
+}
+
+func keycloakIngressSpec(cr *kc.Keycloak, currentTLS []networkingv1.IngressTLS) networkingv1.IngressSpec {
+	pathType := networkingv1.PathTypeImplementationSpecific
+	if cr.Spec.ExternalAccess.PathType != "" {
+		pathType = networkingv1.PathType(cr.Spec.ExternalAccess.PathType)
+	}
+
+	path := keycloakIngressPathPrefix(cr)
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if keycloakIngressRewritesRegex(cr) {
+		path += "(/|$)(.*)"
+	}
+
+	spec := networkingv1.IngressSpec{
+		// TLS settings are kept unless a certificate secret is configured
+		TLS: currentTLS,
 		Rules: []networkingv1.IngressRule{
 			{
-				Host: reconciledHost,
+				Host: keycloakIngressHost(cr),
 				IngressRuleValue: networkingv1.IngressRuleValue{
 					HTTP: &networkingv1.HTTPIngressRuleValue{
 						Paths: []networkingv1.HTTPIngressPath{
 							{
-								Path:     "/",
-								PathType: &pathTypeImplementationSpecific,
+								Path:     path,
+								PathType: &pathType,
 								Backend: networkingv1.IngressBackend{
 									Service: &networkingv1.IngressServiceBackend{
-										Name: ApplicationName,
+										Name: KeycloakServiceName(cr),
 										Port: networkingv1.ServiceBackendPort{
 											Number: KeycloakServicePort,
 										},
@@ -91,12 +162,104 @@ func KeycloakIngressReconciled(cr *kc.Keycloak, currentState *networkingv1.Ingre
 		},
 	}
 
-	return reconciled
+	if cr.Spec.ExternalAccess.IngressClassName != "" {
+		ingressClassName := cr.Spec.ExternalAccess.IngressClassName
+		spec.IngressClassName = &ingressClassName
+	}
+
+	if cr.Spec.ExternalAccess.TLSSecretName != "" {
+		spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      []string{keycloakIngressHost(cr)},
+				SecretName: cr.Spec.ExternalAccess.TLSSecretName,
+			},
+		}
+	}
+
+	return spec
 }
 
-func KeycloakIngressSelector(cr *kc.Keycloak) client.ObjectKey {
-	return client.ObjectKey{
-		Name:      ApplicationName,
-		Namespace: cr.Namespace,
+func keycloakIngressLabels(cr *kc.Keycloak, current map[string]string) map[string]string {
+	labels := map[string]string{}
+	for key, value := range current {
+		labels[key] = value
+	}
+	for key, value := range instanceLabels(cr, map[string]string{"app": ApplicationName}) {
+		labels[key] = value
+	}
+	for key, value := range cr.Spec.ExternalAccess.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// Merges the annotations of the ingress profile and the ones set by the user into the current annotations.
+// Annotations of a profile that is no longer used are removed.
+func keycloakIngressAnnotations(cr *kc.Keycloak, current map[string]string) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range current {
+		annotations[key] = value
+	}
+	for _, key := range ingressProfileAnnotationKeys {
+		delete(annotations, key)
+	}
+	for key, value := range ingressProfileAnnotations(cr, cr.Spec.ExternalAccess.IngressProfile) {
+		annotations[key] = value
+	}
+	for key, value := range cr.Spec.ExternalAccess.Annotations {
+		annotations[key] = value
+	}
+	return annotations
+}
+
+// Returns the paths blocked on the Ingress
+func keycloakIngressBlockedPaths(cr *kc.Keycloak) []string {
+	prefix := keycloakIngressPathPrefix(cr)
+	paths := []string{prefix + KeycloakIngressBlockedMetricsPath}
+	if cr.Spec.ExternalAccess.BlockAdminPaths {
+		paths = append(paths, prefix+KeycloakIngressBlockedAdminPath)
+	}
+	return paths
+}
+
+func ingressProfileAnnotations(cr *kc.Keycloak, profile kc.IngressProfile) map[string]string {
+	switch profile {
+	case kc.DefaultIngressProfile, kc.NginxIngressProfile:
+		// Requests for the metrics are redirected to the realm, like the metrics Route on OpenShift does
+		snippet := fmt.Sprintf(`
+                      location ~* "^%s" {
+                          return 301 %s;
+                        }`, keycloakIngressPathPrefix(cr)+KeycloakIngressBlockedMetricsPath, strings.TrimSuffix(keycloakIngressPathPrefix(cr), "/")+KeycloakMetricsRouteRewritePath)
+		if cr.Spec.ExternalAccess.BlockAdminPaths {
+			snippet += fmt.Sprintf(`
+                      location ~* "^%s" {
+                          return 403;
+                        }`, keycloakIngressPathPrefix(cr)+KeycloakIngressBlockedAdminPath)
+		}
+		annotations := map[string]string{
+			"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
+			"nginx.ingress.kubernetes.io/server-snippet":   snippet,
+		}
+		// Keycloak is served under /auth, the prefix is stripped
+		if keycloakIngressRewritesRegex(cr) {
+			annotations["nginx.ingress.kubernetes.io/use-regex"] = "true"
+			annotations["nginx.ingress.kubernetes.io/rewrite-target"] = "/$2"
+		}
+		return annotations
+	case kc.HAProxyIngressProfile:
+		snippet := ""
+		for _, path := range keycloakIngressBlockedPaths(cr) {
+			snippet += fmt.Sprintf("http-request deny deny_status 403 if { path_beg %s }\n", path)
+		}
+		annotations := map[string]string{
+			"haproxy.org/server-ssl":             "true",
+			"haproxy.org/backend-config-snippet": snippet,
+		}
+		if prefix := keycloakIngressPathPrefix(cr); prefix != "/" {
+			annotations["haproxy.org/path-rewrite"] = fmt.Sprintf(`^%s(/|$)(.*) /\2`, strings.TrimSuffix(prefix, "/"))
+		}
+		return annotations
+	default:
+		return map[string]string{}
 	}
 }
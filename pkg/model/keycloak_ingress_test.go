@@ -5,6 +5,7 @@ import (
 
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 )
 
@@ -132,3 +133,153 @@ func TestKeycloakIngress_testHostOverrideReconciled(t *testing.T) {
 	//then
 	assert.Equal(t, "host-override", reconciledIngress.Spec.Rules[0].Host)
 }
+
+func TestKeycloakIngress_testIngressSettings(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled:          true,
+				Host:             "sso.example.com",
+				IngressClassName: "public",
+				TLSSecretName:    "sso-tls",
+				PathPrefix:       "/sso/",
+				Annotations:      map[string]string{"nginx.ingress.kubernetes.io/proxy-buffer-size": "128k"},
+				Labels:           map[string]string{"team": "identity"},
+			},
+		},
+	}
+
+	//when
+	ingress := KeycloakIngress(cr)
+
+	//then
+	assert.Equal(t, "public", *ingress.Spec.IngressClassName)
+	assert.Equal(t, []networkingv1.IngressTLS{{Hosts: []string{"sso.example.com"}, SecretName: "sso-tls"}}, ingress.Spec.TLS)
+	assert.Equal(t, "/sso(/|$)(.*)", ingress.Spec.Rules[0].HTTP.Paths[0].Path)
+	assert.Equal(t, networkingv1.PathTypeImplementationSpecific, *ingress.Spec.Rules[0].HTTP.Paths[0].PathType)
+	assert.Equal(t, "true", ingress.Annotations["nginx.ingress.kubernetes.io/use-regex"])
+	assert.Equal(t, "/$2", ingress.Annotations["nginx.ingress.kubernetes.io/rewrite-target"])
+	assert.Equal(t, "128k", ingress.Annotations["nginx.ingress.kubernetes.io/proxy-buffer-size"])
+	assert.Contains(t, ingress.Annotations["nginx.ingress.kubernetes.io/server-snippet"], "^/sso/auth/realms/master/metrics")
+	assert.Equal(t, "identity", ingress.Labels["team"])
+}
+
+func TestKeycloakIngress_testTLSSecretReconciled(t *testing.T) {
+	//given
+	currentState := KeycloakIngress(&v1alpha1.Keycloak{})
+	currentState.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{IngressDefaultHost}, SecretName: "old-secret"}}
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled:       true,
+				TLSSecretName: "new-secret",
+			},
+		},
+	}
+
+	//when
+	reconciledIngress := KeycloakIngressReconciled(cr, currentState)
+
+	//then
+	assert.Equal(t, 1, len(reconciledIngress.Spec.TLS))
+	assert.Equal(t, "new-secret", reconciledIngress.Spec.TLS[0].SecretName)
+}
+
+func TestKeycloakIngress_testProfiles(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled:         true,
+				IngressProfile:  v1alpha1.HAProxyIngressProfile,
+				BlockAdminPaths: true,
+			},
+		},
+	}
+	currentState := KeycloakIngress(&v1alpha1.Keycloak{})
+	currentState.Annotations["cert-manager.io/cluster-issuer"] = "letsencrypt"
+
+	//when
+	reconciledIngress := KeycloakIngressReconciled(cr, currentState)
+
+	//then
+	assert.NotContains(t, reconciledIngress.Annotations, "nginx.ingress.kubernetes.io/server-snippet")
+	assert.NotContains(t, reconciledIngress.Annotations, "nginx.ingress.kubernetes.io/backend-protocol")
+	assert.Equal(t, "letsencrypt", reconciledIngress.Annotations["cert-manager.io/cluster-issuer"])
+	assert.Equal(t, "true", reconciledIngress.Annotations["haproxy.org/server-ssl"])
+	assert.Contains(t, reconciledIngress.Annotations["haproxy.org/backend-config-snippet"], "path_beg /auth/realms/master/metrics")
+	assert.Contains(t, reconciledIngress.Annotations["haproxy.org/backend-config-snippet"], "path_beg /auth/admin")
+
+	//when
+	cr.Spec.ExternalAccess.IngressProfile = v1alpha1.NoneIngressProfile
+	reconciledIngress = KeycloakIngressReconciled(cr, reconciledIngress)
+
+	//then
+	assert.Equal(t, map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt"}, reconciledIngress.Annotations)
+}
+
+func TestKeycloakIngress_testPathPrefixRewrite(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled:        true,
+				Host:           "sso.example.com",
+				IngressProfile: v1alpha1.HAProxyIngressProfile,
+				PathPrefix:     "sso",
+				PathType:       "Prefix",
+			},
+		},
+	}
+
+	//when
+	ingress := KeycloakIngress(cr)
+
+	//then
+	assert.Equal(t, "/sso", ingress.Spec.Rules[0].HTTP.Paths[0].Path)
+	assert.Equal(t, networkingv1.PathTypePrefix, *ingress.Spec.Rules[0].HTTP.Paths[0].PathType)
+	assert.Equal(t, `^/sso(/|$)(.*) /\2`, ingress.Annotations["haproxy.org/path-rewrite"])
+	assert.Equal(t, "https://sso.example.com/sso/auth", KeycloakFrontendURL(cr))
+
+	//when
+	cr.Spec.ExternalAccess.PathPrefix = ""
+	reconciledIngress := KeycloakIngressReconciled(cr, ingress)
+
+	//then
+	assert.NotContains(t, reconciledIngress.Annotations, "haproxy.org/path-rewrite")
+	assert.Equal(t, "", KeycloakFrontendURL(cr))
+}
+
+func TestKeycloakIngress_testFrontendURLEnv(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			ExternalAccess: v1alpha1.KeycloakExternalAccess{
+				Enabled:    true,
+				PathPrefix: "/sso",
+			},
+		},
+	}
+
+	//when
+	env := getKeycloakEnv(cr, nil)
+
+	//then
+	assert.Contains(t, env, v1.EnvVar{Name: "KEYCLOAK_FRONTEND_URL", Value: "https://keycloak.local/sso/auth"})
+}
+
+func TestKeycloakIngress_testValidation(t *testing.T) {
+	valid := v1alpha1.KeycloakExternalAccess{Enabled: true, PathPrefix: "/sso", PathType: "ImplementationSpecific"}
+	assert.NoError(t, ValidateExternalAccess(&v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{ExternalAccess: valid}}))
+
+	for _, access := range []v1alpha1.KeycloakExternalAccess{
+		{IngressProfile: "traefik"},
+		{PathType: "Regex"},
+		{PathPrefix: "/sso(.*)"},
+		{PathPrefix: "/sso", PathType: "Prefix"},
+		{PathPrefix: "/sso", PathType: "Exact", IngressProfile: v1alpha1.NginxIngressProfile},
+	} {
+		assert.Error(t, ValidateExternalAccess(&v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{ExternalAccess: access}}), "%+v", access)
+	}
+}
@@ -11,11 +11,11 @@ func KeycloakMetricsRoute(cr *kc.Keycloak, keycloakMainRoute *v1.Route) *v1.Rout
 	keycloakMainRouteCopy := keycloakMainRoute.DeepCopy()
 	return &v1.Route{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      KeycloakMetricsRouteName,
+			Name:      KeycloakMetricsRouteName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 			Annotations: map[string]string{
 				"haproxy.router.openshift.io/balance":        RouteLoadBalancingStrategy,
 				"haproxy.router.openshift.io/rewrite-target": KeycloakMetricsRouteRewritePath,
@@ -50,7 +50,7 @@ func KeycloakMetricsRouteReconciled(cr *kc.Keycloak, currentState *v1.Route, key
 
 func KeycloakMetricsRouteSelector(cr *kc.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      KeycloakMetricsRouteName,
+		Name:      KeycloakMetricsRouteName(cr),
 		Namespace: cr.Namespace,
 	}
 }
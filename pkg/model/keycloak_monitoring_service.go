@@ -11,25 +11,22 @@ import (
 func KeycloakMonitoringService(cr *v1alpha1.Keycloak) *v1.Service {
 	return &v1.Service{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      KeycloakMonitoringServiceName,
+			Name:      KeycloakMonitoringServiceName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 			Annotations: map[string]string{
 				"description": "The monitoring service for Prometheus",
 			},
 		},
 		Spec: v1.ServiceSpec{
-			Selector: map[string]string{
-				"app":       ApplicationName,
-				"component": KeycloakDeploymentComponent,
-			},
+			Selector: GetLabelsSelector(cr),
 			Ports: []v1.ServicePort{
 				{
 					Port:       9990,
 					TargetPort: intstr.FromInt(9990),
-					Name:       KeycloakMonitoringServiceName,
+					Name:       KeycloakMonitoringPortName,
 					Protocol:   "TCP",
 				},
 			},
@@ -39,7 +36,7 @@ func KeycloakMonitoringService(cr *v1alpha1.Keycloak) *v1.Service {
 
 func KeycloakMonitoringServiceSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      KeycloakMonitoringServiceName,
+		Name:      KeycloakMonitoringServiceName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -50,7 +47,7 @@ func KeycloakMonitoringServiceReconciled(cr *v1alpha1.Keycloak, currentState *v1
 		{
 			Port:       9990,
 			TargetPort: intstr.FromInt(9990),
-			Name:       KeycloakMonitoringServiceName,
+			Name:       KeycloakMonitoringPortName,
 			Protocol:   "TCP",
 		},
 	}
@@ -47,7 +47,7 @@ curl -s --max-time 10 --fail http://$(hostname -i):8080/auth > /dev/null
 func KeycloakProbes(cr *v1alpha1.Keycloak) *v1.ConfigMap {
 	return &v1.ConfigMap{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      KeycloakProbesName,
+			Name:      KeycloakProbesName(cr),
 			Namespace: cr.Namespace,
 			Labels: map[string]string{
 				"app":           ApplicationName,
@@ -63,7 +63,7 @@ func KeycloakProbes(cr *v1alpha1.Keycloak) *v1.ConfigMap {
 
 func KeycloakProbesSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      KeycloakProbesName,
+		Name:      KeycloakProbesName(cr),
 		Namespace: cr.Namespace,
 	}
 }
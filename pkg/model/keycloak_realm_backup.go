@@ -0,0 +1,244 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Realms backups export every realm with the admin API into realms-<timestamp>/<realm>.json. The users aren't part
+// of the partial export, they are paged and added to the export with their role mappings and groups. The admin API
+// doesn't export their credentials.
+const (
+	realmBackupPageSize       = "100"
+	realmBackupTLSVolumeName  = "keycloak-tls"
+	realmBackupTLSPath        = "/etc/keycloak-tls"
+	realmBackupDirectoryRegex = `^realms-[0-9]+$`
+)
+
+// Defines api, calling the admin API with curl. Admin tokens are short-lived, so every call logs in. Like the
+// operator, the serving certificate of Keycloak is trusted if present.
+const realmBackupAPIScript = `set -e
+if [ -s ` + realmBackupTLSPath + `/` + ServingCertKey + ` ]; then
+  cat ` + realmBackupTLSPath + `/* > /tmp/keycloak-ca.crt
+  CURL="curl --silent --show-error --fail --cacert /tmp/keycloak-ca.crt"
+else
+  CURL="curl --silent --show-error --fail --insecure"
+fi
+api() {
+  TOKEN=$($CURL --data grant_type=password --data client_id=admin-cli --data-urlencode "username=$KEYCLOAK_USER" --data-urlencode "password=$KEYCLOAK_PASSWORD" $KEYCLOAK_URL/realms/master/protocol/openid-connect/token | jq -r .access_token)
+  $CURL --header "Authorization: Bearer $TOKEN" --header "Content-Type: application/json" "$@"
+}`
+
+// Adds the role mappings and groups of a user to the page of users, in the representation of the partial import
+const realmBackupUserMappingsFilter = `map(if .id == $id then .realmRoles = [$roles[0].realmMappings[]?.name] | .clientRoles = (($roles[0].clientMappings // {}) | map_values([.mappings[]?.name])) | .groups = [$groups[0][].path] else . end)`
+
+// Exports the realms into /backup/$DIR
+const realmBackupExportScript = realmBackupAPIScript + `
+DIR=realms-$(date -u +%Y%m%d%H%M%S)
+trap 'rm -rf /backup/$DIR.tmp /tmp/export' EXIT
+mkdir -p /backup/$DIR.tmp /tmp/export
+for REALM in ${BACKUP_REALMS:-$(api $KEYCLOAK_URL/admin/realms | jq -r '.[].realm')}; do
+  api --request POST "$KEYCLOAK_URL/admin/realms/$REALM/partial-export?exportClients=true&exportGroupsAndRoles=true" > /tmp/export/realm.json
+  echo '[]' > /tmp/export/users.json
+  FIRST=0
+  while :; do
+    api "$KEYCLOAK_URL/admin/realms/$REALM/users?briefRepresentation=false&first=$FIRST&max=` + realmBackupPageSize + `" > /tmp/export/page.json
+    [ "$(jq length /tmp/export/page.json)" -gt 0 ] || break
+    for ID in $(jq -r '.[].id' /tmp/export/page.json); do
+      api "$KEYCLOAK_URL/admin/realms/$REALM/users/$ID/role-mappings" > /tmp/export/roles.json
+      api "$KEYCLOAK_URL/admin/realms/$REALM/users/$ID/groups" > /tmp/export/groups.json
+      jq --arg id "$ID" --slurpfile roles /tmp/export/roles.json --slurpfile groups /tmp/export/groups.json '` + realmBackupUserMappingsFilter + `' /tmp/export/page.json > /tmp/export/mapped.json
+      mv /tmp/export/mapped.json /tmp/export/page.json
+    done
+    jq --slurp add /tmp/export/users.json /tmp/export/page.json > /tmp/export/merged.json
+    mv /tmp/export/merged.json /tmp/export/users.json
+    FIRST=$((FIRST + ` + realmBackupPageSize + `))
+  done
+  jq --slurpfile users /tmp/export/users.json '.users = $users[0]' /tmp/export/realm.json > /backup/$DIR.tmp/$REALM.json
+done
+mv /backup/$DIR.tmp /backup/$DIR`
+
+// Prunes the exports in the local Persistent Volume by the retention of the CR
+const realmLocalBackupScript = realmBackupExportScript + `
+if [ "$BACKUP_RETENTION_COUNT" -gt 0 ]; then
+  ls -1 /backup | grep -E '` + realmBackupDirectoryRegex + `' | sort -r | tail -n +$((BACKUP_RETENTION_COUNT + 1)) | while read OLD; do rm -rf /backup/$OLD; done
+fi
+if [ "$BACKUP_RETENTION_MAX_AGE_DAYS" -gt 0 ]; then
+  find /backup -mindepth 1 -maxdepth 1 -type d -name 'realms-*' ! -name '*.tmp' -mmin +$((BACKUP_RETENTION_MAX_AGE_DAYS * 1440)) -exec rm -rf {} \;
+fi
+printf '{"file":"%s","size":%s}' $DIR $(cat /backup/$DIR/* | wc -c) > /dev/termination-log`
+
+// Uploads the export and prunes the exports in the destination by the retention of the CR. Directories in buckets have
+// no modification time, so the age is taken from their names.
+const realmDestinationUploadScript = `set -e
+DIR=$(ls -1 /backup | grep -E '` + realmBackupDirectoryRegex + `')
+rclone copy /backup/$DIR destination:$BACKUP_PATH/$DIR
+if [ "$BACKUP_RETENTION_COUNT" -gt 0 ]; then
+  rclone lsf destination:$BACKUP_PATH --dirs-only --include 'realms-*/' | sed 's#/$##' | sort -r | tail -n +$((BACKUP_RETENTION_COUNT + 1)) | while read OLD; do rclone purge destination:$BACKUP_PATH/$OLD; done
+fi
+if [ "$BACKUP_RETENTION_MAX_AGE_DAYS" -gt 0 ]; then
+  CUTOFF=$(date -u -d @$(($(date +%s) - BACKUP_RETENTION_MAX_AGE_DAYS * 86400)) +%Y%m%d%H%M%S)
+  rclone lsf destination:$BACKUP_PATH --dirs-only --include 'realms-*/' | sed 's#/$##' | while read OLD; do
+    if [ "${OLD#realms-}" -lt "$CUTOFF" ]; then rclone purge destination:$BACKUP_PATH/$OLD; fi
+  done
+fi
+printf '{"file":"%s","size":%s}' $DIR $(cat /backup/$DIR/* | wc -c) > /dev/termination-log`
+
+// Select the export to restore from the local Persistent Volume or download it from the destination
+const (
+	realmLocalRestoreSourceScript = `set -e
+FILE=${RESTORE_FILE:-$(ls -1 /backup | grep -E '` + realmBackupDirectoryRegex + `' | sort -r | head -n 1)}
+[ -n "$FILE" ] || { echo "no backup to restore" >&2; exit 1; }
+SOURCE=/backup/$FILE`
+
+	realmDestinationRestoreDownloadScript = `set -e
+FILE=${RESTORE_FILE:-$(rclone lsf destination:$BACKUP_PATH --dirs-only --include 'realms-*/' | sed 's#/$##' | sort -r | head -n 1)}
+[ -n "$FILE" ] || { echo "no backup to restore" >&2; exit 1; }
+rclone copy destination:$BACKUP_PATH/$FILE /restore/$FILE`
+
+	realmDestinationRestoreSourceScript = `set -e
+FILE=$(ls -1 /restore | grep -E '` + realmBackupDirectoryRegex + `' | head -n 1)
+SOURCE=/restore/$FILE`
+)
+
+// The exports mask the secrets of confidential clients, they are removed so imported clients get a new secret instead
+// of the mask
+const realmRestoreClientsFilter = `[.clients[]? | del(.secret)]`
+
+// Imports the realms with the partial import, existing resources are handled by the import policy. Missing realms
+// are created from the export. The master realm is only imported if listed. Existing users and clients are never
+// overwritten, their credentials and secrets aren't part of the export. Users are imported last, once the roles and
+// groups they refer to exist.
+const realmRestoreScript = realmBackupAPIScript + `
+for JSON in $SOURCE/*.json; do
+  REALM=$(basename $JSON .json)
+  if ! echo " ${BACKUP_REALMS:-$(ls $SOURCE | sed -n 's/\.json$//p' | grep -vx master)} " | tr '\n' ' ' | grep -q " $REALM "; then
+    continue
+  fi
+  if ! api $KEYCLOAK_URL/admin/realms/$REALM > /dev/null 2>&1; then
+    jq '.clients = ` + realmRestoreClientsFilter + `' $JSON | api --request POST --data @- $KEYCLOAK_URL/admin/realms > /dev/null
+  elif [ "$IMPORT_POLICY" = OVERWRITE ]; then
+    jq '{ifResourceExists: "SKIP", clients: ` + realmRestoreClientsFilter + `}' $JSON | api --request POST --data @- $KEYCLOAK_URL/admin/realms/$REALM/partialImport > /dev/null
+    jq --arg policy "$IMPORT_POLICY" '{ifResourceExists: $policy, groups, identityProviders, roles}' $JSON | api --request POST --data @- $KEYCLOAK_URL/admin/realms/$REALM/partialImport > /dev/null
+    jq '{ifResourceExists: "SKIP", users}' $JSON | api --request POST --data @- $KEYCLOAK_URL/admin/realms/$REALM/partialImport > /dev/null
+  else
+    jq --arg policy "$IMPORT_POLICY" '{ifResourceExists: $policy, users, clients: ` + realmRestoreClientsFilter + `, groups, identityProviders, roles}' $JSON | api --request POST --data @- $KEYCLOAK_URL/admin/realms/$REALM/partialImport > /dev/null
+  fi
+  echo "imported realm $REALM"
+done
+printf '{"file":"%s"}' $FILE > /dev/termination-log`
+
+// ValidateRealmBackup returns an error if the CR combines realms backups with unsupported features
+func ValidateRealmBackup(cr *v1alpha1.KeycloakBackup) error {
+	switch cr.Spec.Type {
+	case "", v1alpha1.DatabaseBackupType:
+		return nil
+	case v1alpha1.RealmsBackupType:
+	default:
+		return errors.Errorf("unknown type %q of backup %v/%v", cr.Spec.Type, cr.Namespace, cr.Name)
+	}
+
+	switch {
+	case cr.Spec.AWS != (v1alpha1.KeycloakAWSSpec{}):
+		return errors.Errorf("realms backup %v/%v can't be uploaded to aws", cr.Namespace, cr.Name)
+	case cr.Spec.Encryption != nil:
+		return errors.Errorf("realms backup %v/%v can't be encrypted", cr.Namespace, cr.Name)
+	case cr.Spec.Verify:
+		return errors.Errorf("realms backup %v/%v can't be verified", cr.Namespace, cr.Name)
+	}
+	return nil
+}
+
+func realmBackupPodSpec(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) v1.PodSpec {
+	if cr.Spec.Destination != nil {
+		return destinationBackupPodSpec(cr, v1.Container{
+			Image:        Images.Images[RealmExporter],
+			Args:         []string{realmBackupExportScript},
+			Env:          realmBackupEnv(cr, keycloak),
+			VolumeMounts: []v1.VolumeMount{realmBackupTLSVolumeMount()},
+		}, []v1.Volume{realmBackupTLSVolume(keycloak)}, realmDestinationUploadScript)
+	}
+
+	return v1.PodSpec{
+		Volumes: []v1.Volume{postgresqlLocalBackupVolume(cr), realmBackupTLSVolume(keycloak)},
+		Containers: []v1.Container{
+			{
+				Name:         cr.Name,
+				Image:        Images.Images[RealmExporter],
+				Command:      []string{"/bin/sh", "-c"},
+				Args:         []string{realmLocalBackupScript},
+				Env:          append(realmBackupEnv(cr, keycloak), postgresqlBackupRetentionEnv(cr)...),
+				VolumeMounts: []v1.VolumeMount{postgresqlLocalBackupVolumeMount(cr), realmBackupTLSVolumeMount()},
+			},
+		},
+		RestartPolicy:      v1.RestartPolicyNever,
+		ServiceAccountName: PostgresqlBackupServiceAccountName,
+	}
+}
+
+func realmRestorePodSpec(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, file string) v1.PodSpec {
+	importPolicy := cr.Spec.ImportPolicy
+	if importPolicy == "" {
+		importPolicy = v1alpha1.RealmImportPolicyFail
+	}
+
+	podSpec := postgresqlBackupSourcePodSpec(cr, file, v1.Container{
+		Name:  postgresqlRestoreName(cr),
+		Image: Images.Images[RealmExporter],
+		Args:  []string{realmRestoreScript},
+		Env: append(realmBackupEnv(cr, keycloak), v1.EnvVar{
+			Name:  "IMPORT_POLICY",
+			Value: string(importPolicy),
+		}),
+		VolumeMounts: []v1.VolumeMount{realmBackupTLSVolumeMount()},
+	})
+	podSpec.Volumes = append(podSpec.Volumes, realmBackupTLSVolume(keycloak))
+	return podSpec
+}
+
+// The admin API of the Keycloak instance and the realms to export or import
+func realmBackupEnv(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) []v1.EnvVar {
+	adminSecretName := KeycloakAdminSecretSelector(keycloak).Name
+	return []v1.EnvVar{
+		{
+			Name:  "KEYCLOAK_URL",
+			Value: fmt.Sprintf("https://%v.%v.svc:%v/auth", KeycloakServiceName(keycloak), keycloak.Namespace, KeycloakServicePort),
+		},
+		secretEnvVar("KEYCLOAK_USER", adminSecretName, AdminUsernameProperty),
+		secretEnvVar("KEYCLOAK_PASSWORD", adminSecretName, AdminPasswordProperty),
+		{
+			Name:  "BACKUP_REALMS",
+			Value: strings.Join(cr.Spec.Realms, " "),
+		},
+	}
+}
+
+// The certificates of the serving certificate Secret, without the private key
+func realmBackupTLSVolume(keycloak *v1alpha1.Keycloak) v1.Volume {
+	optional := true
+	return v1.Volume{
+		Name: realmBackupTLSVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: ServingCertSecretName(keycloak),
+				Items: []v1.KeyToPath{
+					{Key: ServingCertKey, Path: ServingCertKey},
+					{Key: ServingCertCAKey, Path: ServingCertCAKey},
+				},
+				Optional: &optional,
+			},
+		},
+	}
+}
+
+func realmBackupTLSVolumeMount() v1.VolumeMount {
+	return v1.VolumeMount{
+		Name:      realmBackupTLSVolumeName,
+		MountPath: realmBackupTLSPath,
+		ReadOnly:  true,
+	}
+}
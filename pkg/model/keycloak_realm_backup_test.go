@@ -0,0 +1,102 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRealmBackup_testLocalExport(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "tenants", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Type:   v1alpha1.RealmsBackupType,
+			Realms: []string{"tenant-a", "tenant-b"},
+		},
+	}
+	keycloak := &v1alpha1.Keycloak{ObjectMeta: v12.ObjectMeta{Name: "example", Namespace: "keycloak"}}
+
+	//when
+	podSpec := PostgresqlBackup(cr, keycloak).Spec.Template.Spec
+
+	//then
+	container := podSpec.Containers[0]
+	assert.Equal(t, Images.Images[RealmExporter], container.Image)
+	assert.Contains(t, container.Args[0], "partial-export")
+	assert.Contains(t, container.Args[0], realmBackupUserMappingsFilter)
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "KEYCLOAK_URL", Value: "https://example.keycloak.svc:8443/auth"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "BACKUP_REALMS", Value: "tenant-a tenant-b"})
+	assert.Contains(t, container.Env, secretEnvVar("KEYCLOAK_PASSWORD", "credential-example", AdminPasswordProperty))
+	assert.Equal(t, PostgresqlBackupPersistentVolumeName+"-tenants", podSpec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Len(t, podSpec.Volumes[1].Secret.Items, 2, "the private key of the serving certificate isn't mounted")
+	assert.True(t, *podSpec.Volumes[1].Secret.Optional)
+}
+
+func TestRealmBackup_testDestinationExport(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "tenants", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Type: v1alpha1.RealmsBackupType,
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				S3: &v1alpha1.KeycloakBackupS3Destination{Bucket: "backups", CredentialsSecretName: "s3-credentials"},
+			},
+		},
+	}
+
+	//when
+	podSpec := PostgresqlBackup(cr, &v1alpha1.Keycloak{}).Spec.Template.Spec
+
+	//then
+	assert.Equal(t, Images.Images[RealmExporter], podSpec.InitContainers[0].Image)
+	assert.Equal(t, "/backup", podSpec.InitContainers[0].VolumeMounts[0].MountPath)
+	assert.Equal(t, realmBackupTLSPath, podSpec.InitContainers[0].VolumeMounts[1].MountPath)
+	assert.Equal(t, Images.Images[BackupUploader], podSpec.Containers[0].Image)
+	assert.Equal(t, realmDestinationUploadScript, podSpec.Containers[0].Args[0])
+}
+
+func TestRealmBackup_testRestore(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "tenants", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Type:              v1alpha1.RealmsBackupType,
+			RestoreGeneration: 1,
+			RestoreFile:       "realms-20261018030000",
+		},
+	}
+
+	//when
+	podSpec := PostgresqlRestore(cr, &v1alpha1.Keycloak{}, cr.Spec.RestoreFile).Spec.Template.Spec
+
+	//then
+	container := podSpec.Containers[0]
+	assert.Equal(t, Images.Images[RealmExporter], container.Image)
+	assert.Contains(t, container.Args[0], realmLocalRestoreSourceScript)
+	assert.Contains(t, container.Args[0], "partialImport")
+	assert.Contains(t, container.Args[0], `{ifResourceExists: "SKIP", clients: [.clients[]? | del(.secret)]}`)
+	assert.NotContains(t, container.Args[0], "users, clients, groups")
+	assert.Contains(t, container.Args[0], `{ifResourceExists: "SKIP", users}`)
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "IMPORT_POLICY", Value: "FAIL"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "RESTORE_FILE", Value: "realms-20261018030000"})
+	assert.Len(t, container.VolumeMounts, 3)
+	assert.Equal(t, realmBackupTLSVolumeName, podSpec.Volumes[2].Name)
+}
+
+func TestRealmBackup_testValidation(t *testing.T) {
+	assert.NoError(t, ValidateRealmBackup(&v1alpha1.KeycloakBackup{}))
+	assert.NoError(t, ValidateRealmBackup(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{Type: v1alpha1.RealmsBackupType}}))
+	assert.Error(t, ValidateRealmBackup(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{Type: "files"}}))
+	assert.Error(t, ValidateRealmBackup(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Type: v1alpha1.RealmsBackupType,
+		AWS:  v1alpha1.KeycloakAWSSpec{CredentialsSecretName: "aws-secret"},
+	}}))
+	assert.Error(t, ValidateRealmBackup(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Type:   v1alpha1.RealmsBackupType,
+		Verify: true,
+	}}))
+}
@@ -11,11 +11,11 @@ import (
 func KeycloakRoute(cr *kc.Keycloak) *v1.Route {
 	return &v1.Route{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      ApplicationName,
+			Name:      KeycloakServiceName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 			Annotations: map[string]string{
 				"haproxy.router.openshift.io/balance": RouteLoadBalancingStrategy,
 			},
@@ -29,7 +29,7 @@ func KeycloakRoute(cr *kc.Keycloak) *v1.Route {
 			},
 			To: v1.RouteTargetReference{
 				Kind: "Service",
-				Name: ApplicationName,
+				Name: KeycloakServiceName(cr),
 			},
 		},
 	}
@@ -46,7 +46,7 @@ func KeycloakRouteReconciled(cr *kc.Keycloak, currentState *v1.Route) *v1.Route
 		},
 		To: v1.RouteTargetReference{
 			Kind: "Service",
-			Name: ApplicationName,
+			Name: KeycloakServiceName(cr),
 		},
 	}
 
@@ -62,7 +62,7 @@ func getTLSTerminationType(cr *kc.Keycloak) v1.TLSTerminationType {
 
 func KeycloakRouteSelector(cr *kc.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      ApplicationName,
+		Name:      KeycloakServiceName(cr),
 		Namespace: cr.Namespace,
 	}
 }
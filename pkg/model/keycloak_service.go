@@ -8,46 +8,54 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// Annotation requesting the serving certificate from the service CA on OpenShift
+const ServingCertAnnotation = "service.alpha.openshift.io/serving-cert-secret-name"
+
 func KeycloakService(cr *v1alpha1.Keycloak) *v1.Service {
-	return &v1.Service{
+	service := &v1.Service{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      ApplicationName,
+			Name:      KeycloakServiceName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 			Annotations: map[string]string{
-				"description": "The web server's https port.",
-				"service.alpha.openshift.io/serving-cert-secret-name": ServingCertSecretName,
+				"description":         "The web server's https port.",
+				ServingCertAnnotation: ServingCertSecretName(cr),
 			},
 		},
 		Spec: v1.ServiceSpec{
-			Selector: map[string]string{
-				"app":       ApplicationName,
-				"component": KeycloakDeploymentComponent,
-			},
-			Ports: []v1.ServicePort{
-				{
-					Port:       KeycloakServicePort,
-					TargetPort: intstr.FromInt(KeycloakServicePort),
-					Name:       ApplicationName,
-					Protocol:   "TCP",
-				},
-			},
+			Selector: GetLabelsSelector(cr),
+			Ports:    keycloakServicePorts(cr),
 		},
 	}
+
+	// The certificate is issued by cert-manager instead
+	if cr.Spec.CertManager.Enabled {
+		delete(service.Annotations, ServingCertAnnotation)
+	}
+	return service
 }
 
 func KeycloakServiceSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      ApplicationName,
+		Name:      KeycloakServiceName(cr),
 		Namespace: cr.Namespace,
 	}
 }
 
 func KeycloakServiceReconciled(cr *v1alpha1.Keycloak, currentState *v1.Service) *v1.Service {
 	reconciled := currentState.DeepCopy()
-	reconciled.Spec.Ports = []v1.ServicePort{
+	if cr.Spec.CertManager.Enabled {
+		delete(reconciled.Annotations, ServingCertAnnotation)
+	}
+	reconciled.Spec.Ports = keycloakServicePorts(cr)
+	return reconciled
+}
+
+// The HTTP port is only exposed for an HTTPRoute, the Gateway terminates TLS and forwards the requests to it
+func keycloakServicePorts(cr *v1alpha1.Keycloak) []v1.ServicePort {
+	ports := []v1.ServicePort{
 		{
 			Port:       KeycloakServicePort,
 			TargetPort: intstr.FromInt(KeycloakServicePort),
@@ -55,5 +63,13 @@ func KeycloakServiceReconciled(cr *v1alpha1.Keycloak, currentState *v1.Service)
 			Protocol:   "TCP",
 		},
 	}
-	return reconciled
+	if KeycloakGatewayTerminatesTLS(cr) {
+		ports = append(ports, v1.ServicePort{
+			Port:       KeycloakServiceHTTPPort,
+			TargetPort: intstr.FromInt(KeycloakServiceHTTPPort),
+			Name:       KeycloakServiceHTTPPortName,
+			Protocol:   "TCP",
+		})
+	}
+	return ports
 }
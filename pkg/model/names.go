@@ -0,0 +1,159 @@
+package model
+
+import (
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Names of the resources created for a Keycloak instance. They are derived from the name of the Keycloak CR so that
+// multiple instances can run in one namespace. Instances that already owned resources with the fixed names of earlier
+// operator versions keep them (see Keycloak.Status.LegacyNames): renaming would orphan the database and the
+// immutable selectors of the StatefulSet and the PostgreSQL Deployment can not be changed.
+
+const (
+	// Label holding the name of the Keycloak CR a resource belongs to
+	InstanceLabel = ApplicationName
+	// Label holding the name of the KeycloakBackup CR a backup Job belongs to
+	BackupLabel = ApplicationName + "-backup"
+
+	LegacyServingCertSecretName = "sso-x509-https-secret"
+	LegacyMigrateBackupName     = "migrate-backup"
+)
+
+// LegacyNamedResources returns empty objects keyed by the fixed names of earlier operator versions. The presence of
+// any of them, owned by the instance, means the instance has to keep using the legacy names.
+func LegacyNamedResources(cr *v1alpha1.Keycloak) map[client.ObjectKey]runtime.Object {
+	legacy := cr.DeepCopy()
+	legacy.Status.LegacyNames = true
+	return map[client.ObjectKey]runtime.Object{
+		KeycloakDeploymentSelector(legacy): &v13.StatefulSet{},
+		DatabaseSecretSelector(legacy):     &v1.Secret{},
+	}
+}
+
+// IsLegacyNamedResource returns true if the object was created for the instance by an operator version using the
+// legacy names. Resources created since carry the instance label, even when the CR name matches the legacy name.
+func IsLegacyNamedResource(cr *v1alpha1.Keycloak, object v12.Object) bool {
+	_, hasInstanceLabel := object.GetLabels()[InstanceLabel]
+	return !hasInstanceLabel && v12.IsControlledBy(object, cr)
+}
+
+// IsInstanceResource returns true if the operator created the object for the instance: it is controlled by the CR or
+// carries its instance label. Objects with the names of the instance that someone else created are left alone.
+func IsInstanceResource(cr *v1alpha1.Keycloak, object v12.Object) bool {
+	if v12.IsControlledBy(object, cr) {
+		return true
+	}
+	instance, hasInstanceLabel := object.GetLabels()[InstanceLabel]
+	return hasInstanceLabel && instance == cr.Name
+}
+
+func instanceName(cr *v1alpha1.Keycloak, suffix string) string {
+	if cr.Status.LegacyNames {
+		return ApplicationName + suffix
+	}
+	return cr.Name + suffix
+}
+
+func KeycloakDeploymentName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "")
+}
+
+// KeycloakServiceName is also used for the Ingress, Route and other resources exposing or monitoring the instance
+func KeycloakServiceName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "")
+}
+
+func KeycloakDiscoveryServiceName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-discovery")
+}
+
+func KeycloakMonitoringServiceName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-monitoring")
+}
+
+func KeycloakProbesName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-probes")
+}
+
+func KeycloakMetricsRouteName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-metrics-rewrite")
+}
+
+func ServiceMonitorName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-service-monitor")
+}
+
+func DatabaseSecretName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-db-secret")
+}
+
+// DatabaseSecretSslCert is the name of the user provided secret with the certificate of an external database
+func DatabaseSecretSslCert(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-db-ssl-cert-secret")
+}
+
+func PostgresqlDeploymentName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-postgresql")
+}
+
+func PostgresqlServiceName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-postgresql")
+}
+
+func PostgresqlPersistentVolumeName(cr *v1alpha1.Keycloak) string {
+	return instanceName(cr, "-postgresql-claim")
+}
+
+// PostgresqlUpgradeName is the name of the Job preparing the data directory for the upgrade from a major version
+func PostgresqlUpgradeName(cr *v1alpha1.Keycloak, fromVersion string) string {
+	return PostgresqlDeploymentName(cr) + "-upgrade-" + fromVersion
+}
+
+func ServingCertSecretName(cr *v1alpha1.Keycloak) string {
+	if cr.Status.LegacyNames {
+		return LegacyServingCertSecretName
+	}
+	return cr.Name + "-x509-https-secret"
+}
+
+// MigrateBackupName is the prefix of the backups created before a migration
+func MigrateBackupName(cr *v1alpha1.Keycloak) string {
+	if cr.Status.LegacyNames {
+		return LegacyMigrateBackupName
+	}
+	return cr.Name + "-" + LegacyMigrateBackupName
+}
+
+// PostgresqlUpgradeBackupName is the name of the backup taken before upgrading PostgreSQL from a major version
+func PostgresqlUpgradeBackupName(cr *v1alpha1.Keycloak, fromVersion string) string {
+	return MigrateBackupName(cr) + "-postgresql-" + fromVersion
+}
+
+// Adds the instance label to a selector unless the instance uses the legacy names, whose selectors can not be changed
+func instanceLabels(cr *v1alpha1.Keycloak, labels map[string]string) map[string]string {
+	if !cr.Status.LegacyNames {
+		labels[InstanceLabel] = cr.Name
+	}
+	return labels
+}
+
+// GetLabelsSelector returns the labels selecting the Keycloak pods of an instance
+func GetLabelsSelector(cr *v1alpha1.Keycloak) map[string]string {
+	return instanceLabels(cr, map[string]string{
+		"app":       ApplicationName,
+		"component": KeycloakDeploymentComponent,
+	})
+}
+
+// PostgresqlLabelsSelector returns the labels selecting the PostgreSQL pod of an instance
+func PostgresqlLabelsSelector(cr *v1alpha1.Keycloak) map[string]string {
+	return instanceLabels(cr, map[string]string{
+		"app":       ApplicationName,
+		"component": PostgresqlDeploymentComponent,
+	})
+}
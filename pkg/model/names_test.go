@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v13 "k8s.io/api/apps/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNames_Test_Derived_From_CR_Name(t *testing.T) {
+	// given
+	first := &v1alpha1.Keycloak{ObjectMeta: v12.ObjectMeta{Name: "first", Namespace: "sso"}}
+	second := &v1alpha1.Keycloak{ObjectMeta: v12.ObjectMeta{Name: "second", Namespace: "sso"}}
+
+	// then
+	assert.Equal(t, "first", KeycloakDeploymentName(first))
+	assert.Equal(t, "first-db-secret", DatabaseSecretName(first))
+	assert.Equal(t, "first-postgresql", PostgresqlServiceName(first))
+	assert.Equal(t, "first-x509-https-secret", ServingCertSecretName(first))
+	assert.NotEqual(t, KeycloakDeployment(first, nil, nil).Spec.Selector.MatchLabels, KeycloakDeployment(second, nil, nil).Spec.Selector.MatchLabels)
+	assert.NotEqual(t, PostgresqlDeployment(first, false).Spec.Selector.MatchLabels, PostgresqlDeployment(second, false).Spec.Selector.MatchLabels)
+	assert.Equal(t, "second", KeycloakService(second).Spec.Selector[InstanceLabel])
+	assert.Equal(t, "second", KeycloakService(second).Labels[InstanceLabel])
+}
+
+func TestNames_Test_Legacy_Names(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "sso"},
+		Status:     v1alpha1.KeycloakStatus{LegacyNames: true},
+	}
+
+	// then
+	assert.Equal(t, "keycloak", KeycloakDeploymentName(cr))
+	assert.Equal(t, "keycloak", KeycloakService(cr).Name)
+	assert.Equal(t, "keycloak-db-secret", DatabaseSecretName(cr))
+	assert.Equal(t, "keycloak-postgresql", PostgresqlServiceName(cr))
+	assert.Equal(t, "keycloak-postgresql-claim", PostgresqlPersistentVolumeName(cr))
+	assert.Equal(t, LegacyServingCertSecretName, ServingCertSecretName(cr))
+	assert.Equal(t, map[string]string{"app": ApplicationName, "component": KeycloakDeploymentComponent}, KeycloakDeployment(cr, nil, nil).Spec.Selector.MatchLabels)
+	assert.Equal(t, map[string]string{"app": ApplicationName, "component": PostgresqlDeploymentComponent}, PostgresqlDeployment(cr, false).Spec.Selector.MatchLabels)
+}
+
+func TestNames_Test_Is_Legacy_Named_Resource(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{ObjectMeta: v12.ObjectMeta{Name: "keycloak", Namespace: "sso", UID: types.UID("uid")}}
+	controller := true
+	owner := []v12.OwnerReference{{Name: cr.Name, UID: cr.UID, Controller: &controller}}
+
+	legacy := &v13.StatefulSet{ObjectMeta: v12.ObjectMeta{Labels: map[string]string{"app": ApplicationName}, OwnerReferences: owner}}
+	current := KeycloakDeployment(cr, nil, nil)
+	current.OwnerReferences = owner
+	foreign := &v13.StatefulSet{ObjectMeta: v12.ObjectMeta{Labels: map[string]string{"app": ApplicationName}}}
+
+	// then
+	assert.True(t, IsLegacyNamedResource(cr, legacy))
+	assert.False(t, IsLegacyNamedResource(cr, current))
+	assert.False(t, IsLegacyNamedResource(cr, foreign))
+	assert.Len(t, LegacyNamedResources(cr), 2)
+	assert.False(t, cr.Status.LegacyNames)
+}
@@ -4,40 +4,100 @@ import (
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"k8s.io/api/policy/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func PodDisruptionBudget(cr *v1alpha1.Keycloak) *v1beta1.PodDisruptionBudget {
-	return &v1beta1.PodDisruptionBudget{
-		ObjectMeta: v1.ObjectMeta{
-			Name:      ApplicationName,
-			Namespace: cr.Namespace,
-			Labels: map[string]string{
-				"app": ApplicationName,
-			},
-		},
-		Spec: v1beta1.PodDisruptionBudgetSpec{
-			MaxUnavailable: &intstr.IntOrString{IntVal: MaxUnavailableNumberOfPods},
-			Selector: &v1.LabelSelector{
-				MatchLabels: map[string]string{"component": KeycloakDeploymentComponent},
-			},
-		},
-	}
+// policy/v1 is not vendored. The spec of policy/v1 equals the one of policy/v1beta1, so the PodDisruptionBudgets are
+// built from the v1beta1 types and handled as unstructured objects of the version served by the cluster.
+var (
+	PodDisruptionBudgetGroupVersionKind        = schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}
+	PodDisruptionBudgetV1beta1GroupVersionKind = v1beta1.SchemeGroupVersion.WithKind("PodDisruptionBudget")
+)
+
+func PodDisruptionBudget(cr *v1alpha1.Keycloak, groupVersionKind schema.GroupVersionKind) *unstructured.Unstructured {
+	return podDisruptionBudget(groupVersionKind, KeycloakServiceName(cr), cr.Namespace, podDisruptionBudgetLabels(cr, nil),
+		podDisruptionBudgetSpec(cr.Spec.PodDisruptionBudget, &intstr.IntOrString{IntVal: MaxUnavailableNumberOfPods}, nil, GetLabelsSelector(cr)))
 }
 
-func PodDisruptionBudgetReconciled(cr *v1alpha1.Keycloak, currentState *v1beta1.PodDisruptionBudget) *v1beta1.PodDisruptionBudget {
+func PodDisruptionBudgetReconciled(cr *v1alpha1.Keycloak, currentState *unstructured.Unstructured) *unstructured.Unstructured {
 	reconciled := currentState.DeepCopy()
-	reconciled.Spec.MaxUnavailable = &intstr.IntOrString{IntVal: MaxUnavailableNumberOfPods}
-	reconciled.Spec.Selector = &v1.LabelSelector{
-		MatchLabels: map[string]string{"component": KeycloakDeploymentComponent},
-	}
+	reconciled.SetLabels(podDisruptionBudgetLabels(cr, currentState.GetLabels()))
+	reconciled.Object["spec"] = podDisruptionBudgetSpec(cr.Spec.PodDisruptionBudget, &intstr.IntOrString{IntVal: MaxUnavailableNumberOfPods}, nil, GetLabelsSelector(cr))
 	return reconciled
 }
 
 func PodDisruptionBudgetSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      ApplicationName,
+		Name:      KeycloakServiceName(cr),
+		Namespace: cr.Namespace,
+	}
+}
+
+// PostgresqlPodDisruptionBudget protects the single pod of the embedded PostgreSQL from voluntary evictions. The
+// default blocks node drains until the pod is deleted manually.
+func PostgresqlPodDisruptionBudget(cr *v1alpha1.Keycloak, groupVersionKind schema.GroupVersionKind) *unstructured.Unstructured {
+	return podDisruptionBudget(groupVersionKind, PostgresqlDeploymentName(cr), cr.Namespace, podDisruptionBudgetLabels(cr, nil),
+		podDisruptionBudgetSpec(cr.Spec.PostgresDeploymentSpec.PodDisruptionBudget, nil, &intstr.IntOrString{IntVal: PostgresqlMinAvailableNumberOfPods}, PostgresqlLabelsSelector(cr)))
+}
+
+func PostgresqlPodDisruptionBudgetReconciled(cr *v1alpha1.Keycloak, currentState *unstructured.Unstructured) *unstructured.Unstructured {
+	reconciled := currentState.DeepCopy()
+	reconciled.SetLabels(podDisruptionBudgetLabels(cr, currentState.GetLabels()))
+	reconciled.Object["spec"] = podDisruptionBudgetSpec(cr.Spec.PostgresDeploymentSpec.PodDisruptionBudget, nil, &intstr.IntOrString{IntVal: PostgresqlMinAvailableNumberOfPods}, PostgresqlLabelsSelector(cr))
+	return reconciled
+}
+
+func PostgresqlPodDisruptionBudgetSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      PostgresqlDeploymentName(cr),
 		Namespace: cr.Namespace,
 	}
 }
+
+func podDisruptionBudget(groupVersionKind schema.GroupVersionKind, name, namespace string, labels map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	pdb := &unstructured.Unstructured{}
+	pdb.SetGroupVersionKind(groupVersionKind)
+	pdb.SetName(name)
+	pdb.SetNamespace(namespace)
+	pdb.SetLabels(labels)
+	pdb.Object["spec"] = spec
+	return pdb
+}
+
+// The budget of the CR takes precedence over the defaults. Only one of minAvailable and maxUnavailable is set.
+func podDisruptionBudgetSpec(config v1alpha1.PodDisruptionBudgetConfig, defaultMaxUnavailable, defaultMinAvailable *intstr.IntOrString, selector map[string]string) map[string]interface{} {
+	spec := v1beta1.PodDisruptionBudgetSpec{
+		Selector: &v1.LabelSelector{
+			MatchLabels: selector,
+		},
+	}
+
+	switch {
+	case config.MinAvailable != nil:
+		spec.MinAvailable = config.MinAvailable
+	case config.MaxUnavailable != nil:
+		spec.MaxUnavailable = config.MaxUnavailable
+	default:
+		spec.MinAvailable = defaultMinAvailable
+		spec.MaxUnavailable = defaultMaxUnavailable
+	}
+
+	// Converting the typed spec can't fail
+	content, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	return content
+}
+
+func podDisruptionBudgetLabels(cr *v1alpha1.Keycloak, current map[string]string) map[string]string {
+	labels := map[string]string{}
+	for key, value := range current {
+		labels[key] = value
+	}
+	for key, value := range instanceLabels(cr, map[string]string{"app": ApplicationName}) {
+		labels[key] = value
+	}
+	return labels
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestPodDisruptionBudget_testDefaultBudgets(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+	}
+
+	//when
+	pdb := PodDisruptionBudget(cr, PodDisruptionBudgetGroupVersionKind)
+	postgresqlPDB := PostgresqlPodDisruptionBudget(cr, PodDisruptionBudgetV1beta1GroupVersionKind)
+
+	//then
+	assert.Equal(t, "policy/v1", pdb.GetAPIVersion())
+	maxUnavailable, _, _ := unstructured.NestedInt64(pdb.Object, "spec", "maxUnavailable")
+	assert.Equal(t, int64(MaxUnavailableNumberOfPods), maxUnavailable)
+	_, hasMinAvailable, _ := unstructured.NestedFieldNoCopy(pdb.Object, "spec", "minAvailable")
+	assert.False(t, hasMinAvailable)
+	selector, _, _ := unstructured.NestedStringMap(pdb.Object, "spec", "selector", "matchLabels")
+	assert.Equal(t, GetLabelsSelector(cr), selector)
+
+	assert.Equal(t, "policy/v1beta1", postgresqlPDB.GetAPIVersion())
+	assert.Equal(t, PostgresqlDeploymentName(cr), postgresqlPDB.GetName())
+	minAvailable, _, _ := unstructured.NestedInt64(postgresqlPDB.Object, "spec", "minAvailable")
+	assert.Equal(t, int64(PostgresqlMinAvailableNumberOfPods), minAvailable)
+	_, hasMaxUnavailable, _ := unstructured.NestedFieldNoCopy(postgresqlPDB.Object, "spec", "maxUnavailable")
+	assert.False(t, hasMaxUnavailable)
+	selector, _, _ = unstructured.NestedStringMap(postgresqlPDB.Object, "spec", "selector", "matchLabels")
+	assert.Equal(t, PostgresqlLabelsSelector(cr), selector)
+}
+
+func TestPodDisruptionBudget_testConfiguredBudget(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		ObjectMeta: v12.ObjectMeta{Name: "sso", Namespace: "keycloak"},
+	}
+	current := PodDisruptionBudget(cr, PodDisruptionBudgetV1beta1GroupVersionKind)
+	minAvailable := intstr.FromString("50%")
+	cr.Spec.PodDisruptionBudget = v1alpha1.PodDisruptionBudgetConfig{Enabled: true, MinAvailable: &minAvailable}
+
+	//when
+	reconciled := PodDisruptionBudgetReconciled(cr, current)
+
+	//then
+	assert.Equal(t, "policy/v1beta1", reconciled.GetAPIVersion())
+	value, _, _ := unstructured.NestedString(reconciled.Object, "spec", "minAvailable")
+	assert.Equal(t, "50%", value)
+	_, hasMaxUnavailable, _ := unstructured.NestedFieldNoCopy(reconciled.Object, "spec", "maxUnavailable")
+	assert.False(t, hasMaxUnavailable)
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// The images encrypting backups with age by the PostgreSQL version
+var postgresqlVersionEncryptorImages = map[string]string{
+	"10": BackupEncryptor,
+	"12": BackupEncryptor12,
+	"13": BackupEncryptor13,
+	"15": BackupEncryptor15,
+}
+
+// Keys of the Secrets holding the encryption keys of backups
+const (
+	BackupAgeRecipientsProperty  = "AGE_RECIPIENTS"
+	BackupAgeIdentitiesProperty  = "AGE_IDENTITIES"
+	BackupGPGPublicKeysProperty  = "GPG_PUBLIC_KEYS"
+	BackupGPGPrivateKeysProperty = "GPG_PRIVATE_KEYS"
+
+	postgresqlBackupKeysVolumeName = "backup-keys"
+	postgresqlBackupKeysPath       = "/etc/backup-keys"
+)
+
+// Sets ENCRYPT to the command encrypting stdin, SUFFIX to the suffix of the encrypted file and KEYS to the
+// comma separated fingerprints of the keys the file is encrypted to
+const (
+	postgresqlBackupNoEncryptionScript = `set -e
+ENCRYPT=cat
+SUFFIX=
+KEYS=`
+
+	postgresqlBackupAgeEncryptionScript = `set -e
+ENCRYPT="age -R ` + postgresqlBackupKeysPath + `/` + BackupAgeRecipientsProperty + `"
+SUFFIX=.age
+KEYS=$(grep -v '^#' ` + postgresqlBackupKeysPath + `/` + BackupAgeRecipientsProperty + ` | grep . | tr '\n' ',' | sed 's/,$//')`
+
+	postgresqlBackupGPGEncryptionScript = `set -e
+export GNUPGHOME=$(mktemp -d)
+gpg --batch --quiet --import ` + postgresqlBackupKeysPath + `/` + BackupGPGPublicKeysProperty + `
+KEYS=$(gpg --batch --with-colons --list-keys | awk -F: '/^pub/ {pub=1; next} /^fpr/ && pub {print $10; pub=0}' | tr '\n' ',' | sed 's/,$//')
+ENCRYPT="gpg --batch --trust-model always --encrypt $(echo $KEYS | tr ',' '\n' | sed 's/^/--recipient /' | tr '\n' ' ')"
+SUFFIX=.gpg`
+)
+
+// Defines decrypt, decrypting stdin by the suffix of the file name passed as argument
+const postgresqlBackupDecryptScript = `decrypt() {
+  case "$1" in
+    *.age) age -d -i ` + postgresqlBackupKeysPath + `/` + BackupAgeIdentitiesProperty + ` ;;
+    *.gpg) export GNUPGHOME=$(mktemp -d); gpg --batch --quiet --import ` + postgresqlBackupKeysPath + `/` + BackupGPGPrivateKeysProperty + `; gpg --batch --quiet --decrypt ;;
+    *) cat ;;
+  esac
+}`
+
+// ValidateBackupEncryption returns an error if the encryption of the CR is incomplete
+func ValidateBackupEncryption(cr *v1alpha1.KeycloakBackup) error {
+	encryption := cr.Spec.Encryption
+	if encryption == nil {
+		return nil
+	}
+	if cr.Spec.AWS != (v1alpha1.KeycloakAWSSpec{}) {
+		return errors.Errorf("backup %v/%v to aws is encrypted with aws.encryptionKeySecretName", cr.Namespace, cr.Name)
+	}
+	if encryption.Type != v1alpha1.AgeBackupEncryptionType && encryption.Type != v1alpha1.GPGBackupEncryptionType {
+		return errors.Errorf("unknown encryption type %q of backup %v/%v", encryption.Type, cr.Namespace, cr.Name)
+	}
+	if encryption.PublicKeysSecretName == "" {
+		return errors.Errorf("encryption of backup %v/%v needs a public keys secret", cr.Namespace, cr.Name)
+	}
+	if cr.Spec.RestoreGeneration != 0 && encryption.PrivateKeysSecretName == "" {
+		return errors.Errorf("restore of encrypted backup %v/%v needs a private keys secret", cr.Namespace, cr.Name)
+	}
+	return nil
+}
+
+// ValidateBackupEncryptor returns an error if there is no image encrypting the backups of the Keycloak instance with
+// age, the image has to match the PostgreSQL version as pg_dump refuses to dump newer servers
+func ValidateBackupEncryptor(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) error {
+	if cr.Spec.Encryption == nil || cr.Spec.Encryption.Type != v1alpha1.AgeBackupEncryptionType {
+		return nil
+	}
+	version := PostgresqlVersion(keycloak)
+	image, ok := postgresqlVersionEncryptorImages[version]
+	if !ok {
+		return errors.Errorf("age encryption of backup %v/%v is not supported for postgresVersion %q", cr.Namespace, cr.Name, version)
+	}
+	if Images.Images[image] == "" {
+		return errors.Errorf("age encryption of backup %v/%v needs an image providing pg_dump %v and age, set by %v", cr.Namespace, cr.Name, version, image)
+	}
+	return nil
+}
+
+func postgresqlBackupEncryptScript(cr *v1alpha1.KeycloakBackup) string {
+	if cr.Spec.Encryption == nil {
+		return postgresqlBackupNoEncryptionScript
+	}
+	if cr.Spec.Encryption.Type == v1alpha1.AgeBackupEncryptionType {
+		return postgresqlBackupAgeEncryptionScript
+	}
+	return postgresqlBackupGPGEncryptionScript
+}
+
+// Dumps the database through $ENCRYPT into the file. A failing pg_dump fails the script although it's not the
+// last command of the pipe.
+func postgresqlBackupDumpCommand(file string) string {
+	return `{ pg_dump --clean --if-exists $POSTGRES_DB || touch /tmp/dump-failed; } | $ENCRYPT > ` + file + `
+[ ! -e /tmp/dump-failed ]`
+}
+
+// The image dumping and restoring the database of the PostgreSQL version, it has to provide the encryption tools. The
+// PostgreSQL images provide gpg but not age.
+func postgresqlBackupImage(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) string {
+	if cr.Spec.Encryption != nil && cr.Spec.Encryption.Type == v1alpha1.AgeBackupEncryptionType {
+		return Images.Images[postgresqlVersionEncryptorImages[PostgresqlVersion(keycloak)]]
+	}
+	return Profiles.GetPostgresqlImage(keycloak)
+}
+
+// The Secret with the keys used for encryption, or the one for decryption if restoring
+func postgresqlBackupKeysSecretName(cr *v1alpha1.KeycloakBackup, restore bool) string {
+	if cr.Spec.Encryption == nil {
+		return ""
+	}
+	if restore {
+		return cr.Spec.Encryption.PrivateKeysSecretName
+	}
+	return cr.Spec.Encryption.PublicKeysSecretName
+}
+
+func postgresqlBackupKeysVolume(secretName string) v1.Volume {
+	return v1.Volume{
+		Name: postgresqlBackupKeysVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: secretName,
+			},
+		},
+	}
+}
+
+func postgresqlBackupKeysVolumeMount() v1.VolumeMount {
+	return v1.VolumeMount{
+		Name:      postgresqlBackupKeysVolumeName,
+		MountPath: postgresqlBackupKeysPath,
+		ReadOnly:  true,
+	}
+}
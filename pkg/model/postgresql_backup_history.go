@@ -0,0 +1,113 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// PostgresqlBackupHistoryLimit is the maximum number of entries in the backup history of a CR
+const PostgresqlBackupHistoryLimit = 20
+
+// Label set on the pods of a Job by the Job controller
+const jobNameLabel = "job-name"
+
+// Termination message written by the local and destination backup and the verification containers
+type postgresqlBackupTermination struct {
+	File string `json:"file"`
+	Size int64  `json:"size"`
+	// Comma separated fingerprints of the encryption keys
+	Keys     string `json:"keys"`
+	Checksum string `json:"checksum"`
+	// Written by the verification only
+	Realms        int32  `json:"realms"`
+	SchemaVersion string `json:"schemaVersion"`
+}
+
+// PostgresqlBackupHistory adds the finished backup Jobs to the history of the CR. Entries of Jobs that were already
+// removed, e.g. by the history limits of the CronJob, are kept. The newest PostgresqlBackupHistoryLimit entries are
+// returned, newest first.
+func PostgresqlBackupHistory(cr *v1alpha1.KeycloakBackup, jobs []v13.Job, pods []v1.Pod) []v1alpha1.KeycloakBackupHistoryEntry {
+	entries := map[string]v1alpha1.KeycloakBackupHistoryEntry{}
+	for _, entry := range cr.Status.History {
+		entries[entry.Job] = entry
+	}
+
+	for _, job := range jobs {
+		result, finished := postgresqlBackupJobResult(&job)
+		if !finished {
+			continue
+		}
+
+		// The verification of an entry is kept
+		entry := entries[job.Name]
+		entry.Job = job.Name
+		entry.Timestamp = job.CreationTimestamp
+		entry.Result = result
+		if job.Status.StartTime != nil {
+			entry.Timestamp = *job.Status.StartTime
+		}
+		if termination, ok := postgresqlBackupJobTermination(job.Name, pods); ok {
+			entry.File = termination.File
+			entry.Size = termination.Size
+			if termination.Keys != "" {
+				entry.KeyFingerprints = strings.Split(termination.Keys, ",")
+			}
+			entry.Checksum = termination.Checksum
+		}
+		entries[job.Name] = entry
+	}
+
+	history := make([]v1alpha1.KeycloakBackupHistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		history = append(history, entry)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].Timestamp.Equal(&history[j].Timestamp) {
+			return history[i].Job > history[j].Job
+		}
+		return history[j].Timestamp.Before(&history[i].Timestamp)
+	})
+
+	if len(history) > PostgresqlBackupHistoryLimit {
+		history = history[:PostgresqlBackupHistoryLimit]
+	}
+	return history
+}
+
+func postgresqlBackupJobResult(job *v13.Job) (v1alpha1.BackupResult, bool) {
+	if job.Status.Succeeded > 0 {
+		return v1alpha1.BackupResultSucceeded, true
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == v13.JobFailed && condition.Status == v1.ConditionTrue {
+			return v1alpha1.BackupResultFailed, true
+		}
+	}
+	return "", false
+}
+
+// The termination message of the successful pod of the Job, failed attempts don't write one
+func postgresqlBackupJobTermination(jobName string, pods []v1.Pod) (postgresqlBackupTermination, bool) {
+	for _, pod := range pods {
+		if pod.Labels[jobNameLabel] != jobName {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil || terminated.ExitCode != 0 || terminated.Message == "" {
+				continue
+			}
+
+			termination := postgresqlBackupTermination{}
+			if err := json.Unmarshal([]byte(terminated.Message), &termination); err == nil {
+				return termination, true
+			}
+		}
+	}
+	return postgresqlBackupTermination{}, false
+}
@@ -0,0 +1,229 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPostgresqlBackup_testRetention(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "nightly", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Schedule:  "0 3 * * *",
+			Retention: v1alpha1.KeycloakBackupRetention{Count: 7, MaxAgeDays: 30},
+		},
+	}
+
+	//when
+	cronJob := PostgresqlPeriodicBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	assert.Equal(t, "0 3 * * *", cronJob.Spec.Schedule)
+	podTemplate := cronJob.Spec.JobTemplate.Spec.Template
+	assert.Equal(t, "nightly", podTemplate.Labels[BackupLabel])
+	env := map[string]string{}
+	for _, variable := range podTemplate.Spec.Containers[0].Env {
+		env[variable.Name] = variable.Value
+	}
+	assert.Equal(t, "7", env["BACKUP_RETENTION_COUNT"])
+	assert.Equal(t, "30", env["BACKUP_RETENTION_MAX_AGE_DAYS"])
+}
+
+func TestPostgresqlBackup_testHistory(t *testing.T) {
+	//given
+	first := v12.NewTime(time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC))
+	second := v12.NewTime(time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC))
+	third := v12.NewTime(time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC))
+	cr := &v1alpha1.KeycloakBackup{
+		Status: v1alpha1.KeycloakBackupStatus{
+			History: []v1alpha1.KeycloakBackupHistoryEntry{
+				{Job: "nightly-1", Timestamp: first, File: "backup-20261016030000.sql", Size: 1024, Result: v1alpha1.BackupResultSucceeded},
+			},
+		},
+	}
+	jobs := []v13.Job{
+		{
+			ObjectMeta: v12.ObjectMeta{Name: "nightly-2"},
+			Status:     v13.JobStatus{StartTime: &second, Succeeded: 1},
+		},
+		{
+			ObjectMeta: v12.ObjectMeta{Name: "nightly-3"},
+			Status: v13.JobStatus{StartTime: &third, Conditions: []v13.JobCondition{
+				{Type: v13.JobFailed, Status: v1.ConditionTrue},
+			}},
+		},
+		{
+			ObjectMeta: v12.ObjectMeta{Name: "nightly-4"},
+			Status:     v13.JobStatus{Active: 1},
+		},
+	}
+	pods := []v1.Pod{
+		{
+			ObjectMeta: v12.ObjectMeta{Labels: map[string]string{"job-name": "nightly-2"}},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Message: `{"file":"backup-20261017030000.sql","size":2048}`}}},
+			}},
+		},
+		{
+			ObjectMeta: v12.ObjectMeta{Labels: map[string]string{"job-name": "nightly-3"}},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1}}},
+			}},
+		},
+	}
+
+	//when
+	history := PostgresqlBackupHistory(cr, jobs, pods)
+
+	//then
+	assert.Equal(t, []v1alpha1.KeycloakBackupHistoryEntry{
+		{Job: "nightly-3", Timestamp: third, Result: v1alpha1.BackupResultFailed},
+		{Job: "nightly-2", Timestamp: second, File: "backup-20261017030000.sql", Size: 2048, Result: v1alpha1.BackupResultSucceeded},
+		{Job: "nightly-1", Timestamp: first, File: "backup-20261016030000.sql", Size: 1024, Result: v1alpha1.BackupResultSucceeded},
+	}, history)
+}
+
+func TestPostgresqlBackup_testEncryption(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "nightly", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Encryption: &v1alpha1.KeycloakBackupEncryption{
+				Type:                 v1alpha1.AgeBackupEncryptionType,
+				PublicKeysSecretName: "backup-recipients",
+			},
+		},
+	}
+	defer func(image string) { Images.Images[BackupEncryptor] = image }(Images.Images[BackupEncryptor])
+	Images.Images[BackupEncryptor] = "registry:5000/backup-encryptor:1"
+
+	//when
+	podSpec := PostgresqlBackup(cr, &v1alpha1.Keycloak{}).Spec.Template.Spec
+
+	//then
+	container := podSpec.Containers[0]
+	assert.Equal(t, "registry:5000/backup-encryptor:1", container.Image)
+	assert.Contains(t, container.Args[0], "age -R "+postgresqlBackupKeysPath+"/"+BackupAgeRecipientsProperty)
+	assert.Equal(t, "backup-recipients", podSpec.Volumes[1].Secret.SecretName)
+	assert.Equal(t, postgresqlBackupKeysPath, container.VolumeMounts[1].MountPath)
+}
+
+func TestPostgresqlBackup_testPostgresImageOfInstance(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "nightly", Namespace: "keycloak"},
+	}
+	keycloak := &v1alpha1.Keycloak{}
+	keycloak.Spec.PostgresImage = "registry:5000/postgresql:13"
+	keycloak.Spec.PostgresDeploymentSpec.ImagePullSecrets = []v1.LocalObjectReference{{Name: "registry"}}
+
+	//when
+	backupPodSpec := PostgresqlBackup(cr, keycloak).Spec.Template.Spec
+	restorePodSpec := PostgresqlRestore(cr, keycloak, "").Spec.Template.Spec
+
+	//then
+	for _, podSpec := range []v1.PodSpec{backupPodSpec, restorePodSpec} {
+		assert.Equal(t, "registry:5000/postgresql:13", podSpec.Containers[0].Image)
+		assert.Equal(t, keycloak.Spec.PostgresDeploymentSpec.ImagePullSecrets, podSpec.ImagePullSecrets)
+	}
+}
+
+func TestPostgresqlBackup_testEncryptionValidation(t *testing.T) {
+	encryption := &v1alpha1.KeycloakBackupEncryption{Type: v1alpha1.GPGBackupEncryptionType, PublicKeysSecretName: "backup-keys"}
+
+	assert.NoError(t, ValidateBackupEncryption(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{Encryption: encryption}}))
+	assert.Error(t, ValidateBackupEncryption(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{Encryption: encryption, RestoreGeneration: 1}}))
+	assert.Error(t, ValidateBackupEncryption(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Encryption: &v1alpha1.KeycloakBackupEncryption{Type: "rot13", PublicKeysSecretName: "backup-keys"},
+	}}))
+	assert.Error(t, ValidateBackupEncryption(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Encryption: encryption,
+		AWS:        v1alpha1.KeycloakAWSSpec{CredentialsSecretName: "aws-secret"},
+	}}))
+}
+
+func TestPostgresqlBackup_testAgeEncryptionNeedsEncryptorImageOfVersion(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Encryption: &v1alpha1.KeycloakBackupEncryption{Type: v1alpha1.AgeBackupEncryptionType, PublicKeysSecretName: "backup-recipients"},
+	}}
+	keycloak := &v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{PostgresVersion: "15"}}
+	defer func(image string) { Images.Images[BackupEncryptor15] = image }(Images.Images[BackupEncryptor15])
+
+	//when
+	Images.Images[BackupEncryptor15] = ""
+
+	//then
+	assert.Error(t, ValidateBackupEncryptor(cr, keycloak))
+	Images.Images[BackupEncryptor15] = "registry:5000/backup-encryptor:15"
+	assert.NoError(t, ValidateBackupEncryptor(cr, keycloak))
+	assert.Equal(t, "registry:5000/backup-encryptor:15", PostgresqlBackup(cr, keycloak).Spec.Template.Spec.Containers[0].Image)
+	assert.Error(t, ValidateBackupEncryptor(cr, &v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{PostgresVersion: "16", PostgresImage: "registry:5000/postgresql:16"}}))
+	assert.NoError(t, ValidateBackupEncryptor(&v1alpha1.KeycloakBackup{}, keycloak))
+}
+
+func TestPostgresqlBackup_testGPGEncryptionUsesPostgresImageOfVersion(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Encryption: &v1alpha1.KeycloakBackupEncryption{Type: v1alpha1.GPGBackupEncryptionType, PublicKeysSecretName: "backup-keys"},
+	}}
+	keycloak := &v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{PostgresVersion: "13"}}
+
+	//when
+	podSpec := PostgresqlBackup(cr, keycloak).Spec.Template.Spec
+
+	//then
+	assert.NoError(t, ValidateBackupEncryptor(cr, keycloak))
+	assert.Equal(t, Images.Images[PostgresqlImage13], podSpec.Containers[0].Image)
+}
+
+func TestPostgresqlBackup_testHistoryRecordsKeyFingerprints(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	jobs := []v13.Job{
+		{ObjectMeta: v12.ObjectMeta{Name: "nightly-1"}, Status: v13.JobStatus{Succeeded: 1}},
+	}
+	pods := []v1.Pod{
+		{
+			ObjectMeta: v12.ObjectMeta{Labels: map[string]string{"job-name": "nightly-1"}},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					Message: `{"file":"backup-20261018030000.sql.gpg","size":512,"keys":"1FDDD86D8632D640D7FBD870833F5C9CE1173B03,4B8A5B0B17C6D1E2A2F1B0A5C3D7E9F1A2B3C4D5"}`,
+				}}},
+			}},
+		},
+	}
+
+	//when
+	history := PostgresqlBackupHistory(cr, jobs, pods)
+
+	//then
+	assert.Equal(t, "backup-20261018030000.sql.gpg", history[0].File)
+	assert.Equal(t, []string{"1FDDD86D8632D640D7FBD870833F5C9CE1173B03", "4B8A5B0B17C6D1E2A2F1B0A5C3D7E9F1A2B3C4D5"}, history[0].KeyFingerprints)
+}
+
+func TestPostgresqlBackup_testHistoryKeepsVerification(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		Status: v1alpha1.KeycloakBackupStatus{History: []v1alpha1.KeycloakBackupHistoryEntry{
+			{Job: "nightly-1", Result: v1alpha1.BackupResultSucceeded, Verification: v1alpha1.BackupVerified, RealmCount: 2},
+		}},
+	}
+	jobs := []v13.Job{
+		{ObjectMeta: v12.ObjectMeta{Name: "nightly-1"}, Status: v13.JobStatus{Succeeded: 1}},
+	}
+
+	//when
+	history := PostgresqlBackupHistory(cr, jobs, nil)
+
+	//then
+	assert.Equal(t, v1alpha1.BackupVerified, history[0].Verification)
+	assert.Equal(t, int32(2), history[0].RealmCount)
+}
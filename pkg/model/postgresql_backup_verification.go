@@ -0,0 +1,147 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Verification Jobs are removed a day after they finished, their result is kept in the history
+const postgresqlVerificationTTLSeconds = int32(86400)
+
+const postgresqlVerificationSuffix = "-verify"
+
+// Restores the backup into a temporary database inside the pod. The roles owning the objects of the dump are created
+// first, the realms and the latest changeset of the Keycloak schema are reported in the termination message.
+const postgresqlVerificationScript = postgresqlBackupDecryptScript + `
+CHECKSUM=$(sha256sum $SOURCE | cut -d ' ' -f 1)
+if [ -n "$BACKUP_CHECKSUM" ] && [ "$CHECKSUM" != "$BACKUP_CHECKSUM" ]; then
+  echo "checksum $CHECKSUM of $FILE doesn't match $BACKUP_CHECKSUM" >&2
+  exit 1
+fi
+initdb --auth=trust --username=postgres > /dev/null
+pg_ctl start --wait --silent --options "-c listen_addresses='' -c unix_socket_directories=/tmp"
+decrypt $FILE < $SOURCE > /restore/restore.sql
+sed -n 's/^ALTER .* OWNER TO \(.*\);$/\1/p' /restore/restore.sql | sort -u | while read ROLE; do
+  psql --quiet --command "CREATE ROLE $ROLE" 2> /dev/null || true
+done
+psql --set ON_ERROR_STOP=1 --quiet --file /restore/restore.sql > /dev/null
+REALMS=$(psql --tuples-only --no-align --command 'SELECT count(*) FROM realm')
+SCHEMA=$(psql --tuples-only --no-align --command 'SELECT id FROM databasechangelog ORDER BY orderexecuted DESC LIMIT 1')
+if [ "$REALMS" -eq 0 ]; then
+  echo "$FILE contains no realms" >&2
+  exit 1
+fi
+printf '{"file":"%s","checksum":"%s","realms":%s,"schemaVersion":"%s"}' $FILE $CHECKSUM $REALMS "$SCHEMA" > /dev/termination-log`
+
+// ValidateBackupVerification returns an error if the backups of the CR can't be verified
+func ValidateBackupVerification(cr *v1alpha1.KeycloakBackup) error {
+	if !cr.Spec.Verify {
+		return nil
+	}
+	if cr.Spec.AWS != (v1alpha1.KeycloakAWSSpec{}) {
+		return errors.Errorf("backup %v/%v to aws can't be verified", cr.Namespace, cr.Name)
+	}
+	if cr.Spec.Encryption != nil && cr.Spec.Encryption.PrivateKeysSecretName == "" {
+		return errors.Errorf("verification of encrypted backup %v/%v needs a private keys secret", cr.Namespace, cr.Name)
+	}
+	return nil
+}
+
+// PostgresqlVerification verifies the backup of a history entry
+func PostgresqlVerification(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, entry *v1alpha1.KeycloakBackupHistoryEntry) *v13.Job {
+	ttlSeconds := postgresqlVerificationTTLSeconds
+	backoffLimit := int32(0)
+	podSpec := postgresqlBackupSourcePodSpec(cr, entry.File, v1.Container{
+		Name:  PostgresqlVerificationName(entry),
+		Image: postgresqlBackupImage(cr, keycloak),
+		Args:  []string{postgresqlVerificationScript},
+		Env: []v1.EnvVar{
+			{Name: "BACKUP_CHECKSUM", Value: entry.Checksum},
+			{Name: "PGDATA", Value: "/restore/data"},
+			{Name: "PGHOST", Value: "/tmp"},
+			{Name: "PGUSER", Value: "postgres"},
+			{Name: "PGDATABASE", Value: "postgres"},
+		},
+	})
+	podSpec.ImagePullSecrets = keycloak.Spec.PostgresDeploymentSpec.ImagePullSecrets
+	return &v13.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      PostgresqlVerificationName(entry),
+			Namespace: cr.Namespace,
+			Labels:    PostgresqlVerificationLabels(cr),
+		},
+		Spec: v13.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttlSeconds,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{
+					Labels: PostgresqlVerificationLabels(cr),
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+// PostgresqlVerificationName returns the name of the Job verifying the backup of a history entry
+func PostgresqlVerificationName(entry *v1alpha1.KeycloakBackupHistoryEntry) string {
+	return entry.Job + postgresqlVerificationSuffix
+}
+
+// PostgresqlVerificationLabels returns the labels of the Jobs and pods verifying the backups of a CR
+func PostgresqlVerificationLabels(cr *v1alpha1.KeycloakBackup) map[string]string {
+	return map[string]string{
+		"app":       ApplicationName,
+		"component": PostgresqlVerificationComponent,
+		BackupLabel: cr.Name,
+	}
+}
+
+// PostgresqlUnverifiedBackup returns the newest history entry if it's a successful backup that isn't verified yet.
+// Older backups aren't verified, they might already be pruned by the retention.
+func PostgresqlUnverifiedBackup(history []v1alpha1.KeycloakBackupHistoryEntry) *v1alpha1.KeycloakBackupHistoryEntry {
+	if len(history) == 0 {
+		return nil
+	}
+	entry := history[0]
+	if entry.Result != v1alpha1.BackupResultSucceeded || entry.File == "" || entry.Verification != "" {
+		return nil
+	}
+	return &entry
+}
+
+// PostgresqlBackupVerification adds the results of the finished verification Jobs to the history entries
+func PostgresqlBackupVerification(history []v1alpha1.KeycloakBackupHistoryEntry, jobs []v13.Job, pods []v1.Pod) []v1alpha1.KeycloakBackupHistoryEntry {
+	for _, job := range jobs {
+		result, finished := postgresqlBackupJobResult(&job)
+		if !finished {
+			continue
+		}
+
+		for i := range history {
+			entry := &history[i]
+			if PostgresqlVerificationName(entry) != job.Name || entry.Verification != "" {
+				continue
+			}
+
+			entry.Verification = v1alpha1.BackupUnverified
+			if result != v1alpha1.BackupResultSucceeded {
+				continue
+			}
+			if termination, ok := postgresqlBackupJobTermination(job.Name, pods); ok {
+				entry.Verification = v1alpha1.BackupVerified
+				entry.RealmCount = termination.Realms
+				entry.SchemaVersion = strings.TrimSpace(termination.SchemaVersion)
+				if entry.Checksum == "" {
+					entry.Checksum = termination.Checksum
+				}
+			}
+		}
+	}
+	return history
+}
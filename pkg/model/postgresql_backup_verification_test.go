@@ -0,0 +1,95 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPostgresqlVerification_testTemporaryDatabase(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "nightly", Namespace: "keycloak"},
+		Spec:       v1alpha1.KeycloakBackupSpec{Verify: true},
+	}
+	keycloak := &v1alpha1.Keycloak{}
+	entry := &v1alpha1.KeycloakBackupHistoryEntry{Job: "nightly-27790380", File: "backup-20261018030000.sql", Checksum: "4f2a"}
+
+	//when
+	job := PostgresqlVerification(cr, keycloak, entry)
+
+	//then
+	assert.Equal(t, "nightly-27790380-verify", job.Name)
+	assert.Equal(t, PostgresqlVerificationComponent, job.Labels["component"])
+	assert.Equal(t, postgresqlVerificationTTLSeconds, *job.Spec.TTLSecondsAfterFinished)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "BACKUP_CHECKSUM", Value: "4f2a"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "RESTORE_FILE", Value: "backup-20261018030000.sql"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "PGHOST", Value: "/tmp"})
+	for _, env := range container.Env {
+		assert.NotEqual(t, "PGPASSWORD", env.Name, "the verification must not connect to the database of Keycloak")
+	}
+	assert.Contains(t, container.Args[0], "initdb")
+}
+
+func TestPostgresqlVerification_testOnlyNewestBackupIsVerified(t *testing.T) {
+	succeeded := v1alpha1.KeycloakBackupHistoryEntry{Job: "nightly-2", File: "backup-2.sql", Result: v1alpha1.BackupResultSucceeded}
+	failed := v1alpha1.KeycloakBackupHistoryEntry{Job: "nightly-2", Result: v1alpha1.BackupResultFailed}
+	verified := succeeded
+	verified.Verification = v1alpha1.BackupVerified
+	older := v1alpha1.KeycloakBackupHistoryEntry{Job: "nightly-1", File: "backup-1.sql", Result: v1alpha1.BackupResultSucceeded}
+
+	assert.Equal(t, &succeeded, PostgresqlUnverifiedBackup([]v1alpha1.KeycloakBackupHistoryEntry{succeeded, older}))
+	assert.Nil(t, PostgresqlUnverifiedBackup([]v1alpha1.KeycloakBackupHistoryEntry{failed, older}))
+	assert.Nil(t, PostgresqlUnverifiedBackup([]v1alpha1.KeycloakBackupHistoryEntry{verified, older}))
+	assert.Nil(t, PostgresqlUnverifiedBackup(nil))
+}
+
+func TestPostgresqlVerification_testResultsAreRecorded(t *testing.T) {
+	//given
+	history := []v1alpha1.KeycloakBackupHistoryEntry{
+		{Job: "nightly-2", File: "backup-2.sql", Checksum: "4f2a", Result: v1alpha1.BackupResultSucceeded},
+		{Job: "nightly-1", File: "backup-1.sql", Checksum: "9c1b", Result: v1alpha1.BackupResultSucceeded},
+	}
+	jobs := []v13.Job{
+		{ObjectMeta: v12.ObjectMeta{Name: "nightly-2-verify"}, Status: v13.JobStatus{Succeeded: 1}},
+		{ObjectMeta: v12.ObjectMeta{Name: "nightly-1-verify"}, Status: v13.JobStatus{
+			Conditions: []v13.JobCondition{{Type: v13.JobFailed, Status: v1.ConditionTrue}},
+		}},
+	}
+	pods := []v1.Pod{
+		{
+			ObjectMeta: v12.ObjectMeta{Labels: map[string]string{"job-name": "nightly-2-verify"}},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					Message: `{"file":"backup-2.sql","checksum":"4f2a","realms":3,"schemaVersion":"22.0.0-17484"}`,
+				}}},
+			}},
+		},
+	}
+
+	//when
+	history = PostgresqlBackupVerification(history, jobs, pods)
+
+	//then
+	assert.Equal(t, v1alpha1.BackupVerified, history[0].Verification)
+	assert.Equal(t, int32(3), history[0].RealmCount)
+	assert.Equal(t, "22.0.0-17484", history[0].SchemaVersion)
+	assert.Equal(t, v1alpha1.BackupUnverified, history[1].Verification)
+}
+
+func TestPostgresqlVerification_testValidation(t *testing.T) {
+	assert.NoError(t, ValidateBackupVerification(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{Verify: true}}))
+	assert.Error(t, ValidateBackupVerification(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Verify: true,
+		AWS:    v1alpha1.KeycloakAWSSpec{CredentialsSecretName: "aws-secret"},
+	}}))
+	assert.Error(t, ValidateBackupVerification(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Verify:     true,
+		Encryption: &v1alpha1.KeycloakBackupEncryption{Type: v1alpha1.GPGBackupEncryptionType, PublicKeysSecretName: "backup-keys"},
+	}}))
+}
@@ -1,11 +1,154 @@
 package model
 
 import (
+	"strconv"
+
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 )
 
-func postgresqlAwsBackupCommonContainers(cr *v1alpha1.KeycloakBackup) []v1.Container {
+// Every local backup is dumped to a timestamped file. backup.sql links the latest one, older files are pruned by the
+// retention of the CR. The file name, size, keys and checksum are reported in the termination message of the container.
+func postgresqlLocalBackupScript(cr *v1alpha1.KeycloakBackup) string {
+	return postgresqlBackupEncryptScript(cr) + `
+FILE=backup-$(date -u +%Y%m%d%H%M%S).sql$SUFFIX
+trap 'rm -f /backup/$FILE.tmp' EXIT
+` + postgresqlBackupDumpCommand("/backup/$FILE.tmp") + `
+mv /backup/$FILE.tmp /backup/$FILE
+ln -sf $FILE /backup/backup.sql$SUFFIX
+if [ "$BACKUP_RETENTION_COUNT" -gt 0 ]; then
+  ls -1 /backup | grep -E '` + postgresqlBackupFilePattern + `' | sort -r | tail -n +$((BACKUP_RETENTION_COUNT + 1)) | while read OLD; do rm -f /backup/$OLD; done
+fi
+if [ "$BACKUP_RETENTION_MAX_AGE_DAYS" -gt 0 ]; then
+  find /backup -maxdepth 1 -name 'backup-*.sql*' ! -name '*.tmp' -mmin +$((BACKUP_RETENTION_MAX_AGE_DAYS * 1440)) -exec rm -f {} \;
+fi
+printf '{"file":"%s","size":%s,"keys":"%s","checksum":"%s"}' $FILE $(wc -c < /backup/$FILE) "$KEYS" $(sha256sum /backup/$FILE | cut -d ' ' -f 1) > /dev/termination-log`
+}
+
+// Matches the names of the backup files, plain or encrypted
+const postgresqlBackupFilePattern = `^backup-[0-9]+\.sql(\.age|\.gpg)?$`
+
+// PostgresqlBackupLabels returns the labels of the Jobs and pods running the backups of a CR
+func PostgresqlBackupLabels(cr *v1alpha1.KeycloakBackup) map[string]string {
+	return map[string]string{
+		"app":       ApplicationName,
+		"component": PostgresqlBackupComponent,
+		BackupLabel: cr.Name,
+	}
+}
+
+// Pod of the backups without aws, dumping to the local Persistent Volume or uploading to the destination
+func postgresqlBackupPodSpec(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) v1.PodSpec {
+	if cr.Spec.Type == v1alpha1.RealmsBackupType {
+		return realmBackupPodSpec(cr, keycloak)
+	}
+	if cr.Spec.Destination != nil {
+		return postgresqlDestinationBackupPodSpec(cr, keycloak)
+	}
+
+	volumes := []v1.Volume{postgresqlLocalBackupVolume(cr)}
+	volumeMounts := []v1.VolumeMount{postgresqlLocalBackupVolumeMount(cr)}
+	if secretName := postgresqlBackupKeysSecretName(cr, false); secretName != "" {
+		volumes = append(volumes, postgresqlBackupKeysVolume(secretName))
+		volumeMounts = append(volumeMounts, postgresqlBackupKeysVolumeMount())
+	}
+
+	return v1.PodSpec{
+		Volumes: volumes,
+		Containers: []v1.Container{
+			{
+				Name:         cr.Name,
+				Image:        postgresqlBackupImage(cr, keycloak),
+				Command:      []string{"/bin/sh", "-c"},
+				Args:         []string{postgresqlLocalBackupScript(cr)},
+				Env:          append(postgresqlBackupDatabaseEnv(keycloak), postgresqlBackupRetentionEnv(cr)...),
+				VolumeMounts: volumeMounts,
+			},
+		},
+		RestartPolicy:      v1.RestartPolicyNever,
+		ServiceAccountName: PostgresqlBackupServiceAccountName,
+		ImagePullSecrets:   keycloak.Spec.PostgresDeploymentSpec.ImagePullSecrets,
+	}
+}
+
+func postgresqlLocalBackupVolume(cr *v1alpha1.KeycloakBackup) v1.Volume {
+	return v1.Volume{
+		Name: PostgresqlBackupPersistentVolumeName + "-" + cr.Name,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: PostgresqlBackupPersistentVolumeName + "-" + cr.Name,
+			},
+		},
+	}
+}
+
+func postgresqlLocalBackupVolumeMount(cr *v1alpha1.KeycloakBackup) v1.VolumeMount {
+	return v1.VolumeMount{
+		Name:      PostgresqlBackupPersistentVolumeName + "-" + cr.Name,
+		MountPath: "/backup",
+	}
+}
+
+func postgresqlBackupDatabaseEnv(keycloak *v1alpha1.Keycloak) []v1.EnvVar {
+	return []v1.EnvVar{
+		{
+			Name: "POSTGRES_USER",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: DatabaseSecretName(keycloak),
+					},
+					Key: DatabaseSecretUsernameProperty,
+				},
+			},
+		},
+		{
+			Name: "PGUSER",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: DatabaseSecretName(keycloak),
+					},
+					Key: DatabaseSecretUsernameProperty,
+				},
+			},
+		},
+		{
+			Name: "PGPASSWORD",
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: DatabaseSecretName(keycloak),
+					},
+					Key: DatabaseSecretPasswordProperty,
+				},
+			},
+		},
+		{
+			Name:  "POSTGRES_DB",
+			Value: PostgresqlDatabase,
+		},
+		{
+			Name:  "PGHOST",
+			Value: PostgresqlServiceName(keycloak),
+		},
+	}
+}
+
+func postgresqlBackupRetentionEnv(cr *v1alpha1.KeycloakBackup) []v1.EnvVar {
+	return []v1.EnvVar{
+		{
+			Name:  "BACKUP_RETENTION_COUNT",
+			Value: strconv.Itoa(int(cr.Spec.Retention.Count)),
+		},
+		{
+			Name:  "BACKUP_RETENTION_MAX_AGE_DAYS",
+			Value: strconv.Itoa(int(cr.Spec.Retention.MaxAgeDays)),
+		},
+	}
+}
+
+func postgresqlAwsBackupCommonContainers(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) []v1.Container {
 	return []v1.Container{
 		{
 			Name:    cr.Name,
@@ -26,7 +169,7 @@ func postgresqlAwsBackupCommonContainers(cr *v1alpha1.KeycloakBackup) []v1.Conta
 				},
 				{
 					Name:  "COMPONENT_SECRET_NAME",
-					Value: DatabaseSecretName,
+					Value: DatabaseSecretName(keycloak),
 				},
 				{
 					Name:  "COMPONENT_SECRET_NAMESPACE",
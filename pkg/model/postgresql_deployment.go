@@ -39,34 +39,25 @@ func getPostgresResources(cr *v1alpha1.Keycloak) v1.ResourceRequirements {
 func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployment {
 	v13Deployment := &v13.Deployment{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      PostgresqlDeploymentName,
+			Name:      PostgresqlDeploymentName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
-				"app":       ApplicationName,
-				"component": PostgresqlDeploymentComponent,
-			},
+			Labels:    PostgresqlLabelsSelector(cr),
 		},
 		Spec: v13.DeploymentSpec{
 			Selector: &v12.LabelSelector{
-				MatchLabels: map[string]string{
-					"app":       ApplicationName,
-					"component": PostgresqlDeploymentComponent,
-				},
+				MatchLabels: PostgresqlLabelsSelector(cr),
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: v12.ObjectMeta{
-					Name:      PostgresqlDeploymentName,
+					Name:      PostgresqlDeploymentName(cr),
 					Namespace: cr.Namespace,
-					Labels: map[string]string{
-						"app":       ApplicationName,
-						"component": PostgresqlDeploymentComponent,
-					},
+					Labels:    PostgresqlLabelsSelector(cr),
 				},
 				Spec: v1.PodSpec{
 					Containers: []v1.Container{
 						{
-							Name:  PostgresqlDeploymentName,
-							Image: Images.Images[PostgresqlImage],
+							Name:  PostgresqlContainerName,
+							Image: Profiles.GetPostgresqlImage(cr),
 							Ports: []v1.ContainerPort{
 								{
 									ContainerPort: 5432,
@@ -101,7 +92,7 @@ func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployme
 									ValueFrom: &v1.EnvVarSource{
 										SecretKeyRef: &v1.SecretKeySelector{
 											LocalObjectReference: v1.LocalObjectReference{
-												Name: DatabaseSecretName,
+												Name: DatabaseSecretName(cr),
 											},
 											Key: DatabaseSecretUsernameProperty,
 										},
@@ -112,7 +103,7 @@ func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployme
 									ValueFrom: &v1.EnvVarSource{
 										SecretKeyRef: &v1.SecretKeySelector{
 											LocalObjectReference: v1.LocalObjectReference{
-												Name: DatabaseSecretName,
+												Name: DatabaseSecretName(cr),
 											},
 											Key: DatabaseSecretPasswordProperty,
 										},
@@ -125,7 +116,7 @@ func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployme
 							},
 							VolumeMounts: []v1.VolumeMount{
 								{
-									Name:      PostgresqlPersistentVolumeName,
+									Name:      PostgresqlPersistentVolumeName(cr),
 									MountPath: PostgresqlPersistentVolumeMountPath,
 								},
 							},
@@ -135,10 +126,10 @@ func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployme
 					},
 					Volumes: []v1.Volume{
 						{
-							Name: PostgresqlPersistentVolumeName,
+							Name: PostgresqlPersistentVolumeName(cr),
 							VolumeSource: v1.VolumeSource{
 								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
-									ClaimName: PostgresqlPersistentVolumeName,
+									ClaimName: PostgresqlPersistentVolumeName(cr),
 								},
 							},
 						},
@@ -151,17 +142,26 @@ func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployme
 		},
 	}
 
-	if !isOpenshift {
+	if !isOpenshift && !hasPostgresqlFSGroup(cr) {
 		v13Deployment.Spec.Template.Spec.InitContainers = getPostgresqlDeploymentInitContainer(cr)
 	}
+
+	applyDeploymentSpec(&v13Deployment.Spec.Template.Spec, cr.Spec.PostgresDeploymentSpec.DeploymentSpec)
 	return v13Deployment
 }
 
+// The init container changing the owner of the volume runs as root, which is not needed if the volume is owned by
+// the fsGroup of the pod
+func hasPostgresqlFSGroup(cr *v1alpha1.Keycloak) bool {
+	podSecurityContext := cr.Spec.PostgresDeploymentSpec.PodSecurityContext
+	return podSecurityContext != nil && podSecurityContext.FSGroup != nil
+}
+
 func getPostgresqlDeploymentInitContainer(cr *v1alpha1.Keycloak) []v1.Container {
 	return []v1.Container{
 		{
 			Name:  "init-pvc",
-			Image: Images.Images[PostgresqlImage],
+			Image: Profiles.GetPostgresqlImage(cr),
 			SecurityContext: &v1.SecurityContext{
 				RunAsUser: pointer.Int64Ptr(0),
 			},
@@ -172,7 +172,7 @@ func getPostgresqlDeploymentInitContainer(cr *v1alpha1.Keycloak) []v1.Container
 			},
 			VolumeMounts: []v1.VolumeMount{
 				{
-					Name:      PostgresqlPersistentVolumeName,
+					Name:      PostgresqlPersistentVolumeName(cr),
 					MountPath: PostgresqlPersistentVolumeMountPath,
 				},
 			},
@@ -183,7 +183,7 @@ func getPostgresqlDeploymentInitContainer(cr *v1alpha1.Keycloak) []v1.Container
 
 func PostgresqlDeploymentSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      PostgresqlDeploymentName,
+		Name:      PostgresqlDeploymentName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -196,8 +196,8 @@ func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Dep
 	}
 	reconciled.Spec.Template.Spec.Containers = []v1.Container{
 		{
-			Name:  PostgresqlDeploymentName,
-			Image: Images.Images[PostgresqlImage],
+			Name:  PostgresqlContainerName,
+			Image: Profiles.GetPostgresqlImage(cr),
 			Ports: []v1.ContainerPort{
 				{
 					ContainerPort: 5432,
@@ -232,7 +232,7 @@ func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Dep
 					ValueFrom: &v1.EnvVarSource{
 						SecretKeyRef: &v1.SecretKeySelector{
 							LocalObjectReference: v1.LocalObjectReference{
-								Name: DatabaseSecretName,
+								Name: DatabaseSecretName(cr),
 							},
 							Key: DatabaseSecretUsernameProperty,
 						},
@@ -243,7 +243,7 @@ func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Dep
 					ValueFrom: &v1.EnvVarSource{
 						SecretKeyRef: &v1.SecretKeySelector{
 							LocalObjectReference: v1.LocalObjectReference{
-								Name: DatabaseSecretName,
+								Name: DatabaseSecretName(cr),
 							},
 							Key: DatabaseSecretPasswordProperty,
 						},
@@ -256,7 +256,7 @@ func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Dep
 			},
 			VolumeMounts: []v1.VolumeMount{
 				{
-					Name:      PostgresqlPersistentVolumeName,
+					Name:      PostgresqlPersistentVolumeName(cr),
 					MountPath: PostgresqlPersistentVolumeMountPath,
 				},
 			},
@@ -266,13 +266,18 @@ func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Dep
 	}
 	reconciled.Spec.Template.Spec.Volumes = []v1.Volume{
 		{
-			Name: PostgresqlPersistentVolumeName,
+			Name: PostgresqlPersistentVolumeName(cr),
 			VolumeSource: v1.VolumeSource{
 				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
-					ClaimName: PostgresqlPersistentVolumeName,
+					ClaimName: PostgresqlPersistentVolumeName(cr),
 				},
 			},
 		},
 	}
+	if hasPostgresqlFSGroup(cr) {
+		reconciled.Spec.Template.Spec.InitContainers = nil
+	}
+
+	applyDeploymentSpec(&reconciled.Spec.Template.Spec, cr.Spec.PostgresDeploymentSpec.DeploymentSpec)
 	return reconciled
 }
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestPostgresqlDeployment_testDeploymentSpecPodSettings(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			PostgresDeploymentSpec: v1alpha1.PostgresqlDeploymentSpec{
+				DeploymentSpec: v1alpha1.DeploymentSpec{
+					NodeSelector: map[string]string{"pool": "database"},
+					SecurityContext: &v1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+					},
+				},
+			},
+		},
+	}
+
+	//when
+	deployment := PostgresqlDeployment(cr, false)
+	reconciled := PostgresqlDeploymentReconciled(cr, deployment)
+
+	//then
+	for _, podSpec := range []v1.PodSpec{deployment.Spec.Template.Spec, reconciled.Spec.Template.Spec} {
+		assert.Equal(t, "database", podSpec.NodeSelector["pool"])
+		assert.Equal(t, cr.Spec.PostgresDeploymentSpec.SecurityContext, podSpec.Containers[0].SecurityContext)
+		// The init container keeps running as root
+		assert.Equal(t, int64(0), *podSpec.InitContainers[0].SecurityContext.RunAsUser)
+	}
+}
+
+func TestPostgresqlDeployment_testFSGroupReplacesInitContainer(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			PostgresDeploymentSpec: v1alpha1.PostgresqlDeploymentSpec{
+				DeploymentSpec: v1alpha1.DeploymentSpec{
+					PodSecurityContext: &v1.PodSecurityContext{
+						FSGroup: &[]int64{26}[0],
+					},
+				},
+			},
+		},
+	}
+	current := PostgresqlDeployment(&v1alpha1.Keycloak{}, false)
+
+	//when
+	deployment := PostgresqlDeployment(cr, false)
+	reconciled := PostgresqlDeploymentReconciled(cr, current)
+
+	//then
+	assert.Empty(t, deployment.Spec.Template.Spec.InitContainers)
+	assert.Empty(t, reconciled.Spec.Template.Spec.InitContainers)
+	assert.Equal(t, cr.Spec.PostgresDeploymentSpec.PodSecurityContext, reconciled.Spec.Template.Spec.SecurityContext)
+}
@@ -0,0 +1,223 @@
+package model
+
+import (
+	"path"
+	"strconv"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Backups to a destination are uploaded with rclone, configured by environment variables for the remote
+// "destination". The database is dumped by an init container into a volume shared with the upload container.
+const (
+	BackupS3AccessKeyIDProperty       = "AWS_ACCESS_KEY_ID"
+	BackupS3SecretAccessKeyProperty   = "AWS_SECRET_ACCESS_KEY"
+	BackupGCSCredentialsProperty      = "credentials.json"
+	BackupAzureStorageAccountProperty = "AZURE_STORAGE_ACCOUNT"
+	BackupAzureStorageKeyProperty     = "AZURE_STORAGE_KEY"
+
+	postgresqlDestinationBackupVolumeName = "backup"
+	postgresqlDestinationCredentialsName  = "destination-credentials"
+	postgresqlDestinationCredentialsPath  = "/etc/rclone/credentials"
+	postgresqlDestinationEnvPrefix        = "RCLONE_CONFIG_DESTINATION_"
+)
+
+// The dump is encrypted before it's written, the suffix and keys of the encryption are passed to the upload container
+func postgresqlDestinationDumpScript(cr *v1alpha1.KeycloakBackup) string {
+	return postgresqlBackupEncryptScript(cr) + `
+` + postgresqlBackupDumpCommand("/backup/backup.sql") + `
+printf "SUFFIX='%s'\nKEYS='%s'\n" "$SUFFIX" "$KEYS" > /backup/dump.env`
+}
+
+const postgresqlDestinationUploadScript = `set -e
+. /backup/dump.env
+FILE=backup-$(date -u +%Y%m%d%H%M%S).sql$SUFFIX
+rclone copyto /backup/backup.sql destination:$BACKUP_PATH/$FILE
+if [ "$BACKUP_RETENTION_COUNT" -gt 0 ]; then
+  rclone lsf destination:$BACKUP_PATH --files-only --include 'backup-*.sql{,.age,.gpg}' | sort -r | tail -n +$((BACKUP_RETENTION_COUNT + 1)) | while read OLD; do rclone deletefile destination:$BACKUP_PATH/$OLD; done
+fi
+if [ "$BACKUP_RETENTION_MAX_AGE_DAYS" -gt 0 ]; then
+  rclone delete destination:$BACKUP_PATH --max-depth 1 --include 'backup-*.sql{,.age,.gpg}' --min-age ${BACKUP_RETENTION_MAX_AGE_DAYS}d
+fi
+printf '{"file":"%s","size":%s,"keys":"%s","checksum":"%s"}' $FILE $(wc -c < /backup/backup.sql) "$KEYS" $(sha256sum /backup/backup.sql | cut -d ' ' -f 1) > /dev/termination-log`
+
+// ValidateBackupDestination returns an error if the destination of the CR is incomplete or ambiguous
+func ValidateBackupDestination(cr *v1alpha1.KeycloakBackup) error {
+	destination := cr.Spec.Destination
+	if destination == nil {
+		return nil
+	}
+	if cr.Spec.AWS != (v1alpha1.KeycloakAWSSpec{}) {
+		return errors.Errorf("backup %v/%v can't have both aws and a destination", cr.Namespace, cr.Name)
+	}
+
+	destinations := 0
+	for _, set := range []bool{destination.S3 != nil, destination.GCS != nil, destination.Azure != nil} {
+		if set {
+			destinations++
+		}
+	}
+	if destinations != 1 {
+		return errors.Errorf("destination of backup %v/%v needs exactly one of s3, gcs and azure", cr.Namespace, cr.Name)
+	}
+
+	switch {
+	case destination.S3 != nil && (destination.S3.Bucket == "" || destination.S3.CredentialsSecretName == ""):
+		return errors.Errorf("s3 destination of backup %v/%v needs a bucket and a credentials secret", cr.Namespace, cr.Name)
+	case destination.GCS != nil && (destination.GCS.Bucket == "" || destination.GCS.CredentialsSecretName == ""):
+		return errors.Errorf("gcs destination of backup %v/%v needs a bucket and a credentials secret", cr.Namespace, cr.Name)
+	case destination.Azure != nil && (destination.Azure.Container == "" || destination.Azure.CredentialsSecretName == ""):
+		return errors.Errorf("azure destination of backup %v/%v needs a container and a credentials secret", cr.Namespace, cr.Name)
+	}
+	return nil
+}
+
+// BackupDestinationPath returns the bucket or container and prefix the backups of the CR are stored under
+func BackupDestinationPath(destination *v1alpha1.KeycloakBackupDestination) string {
+	switch {
+	case destination.S3 != nil:
+		return path.Join(destination.S3.Bucket, destination.S3.Prefix)
+	case destination.GCS != nil:
+		return path.Join(destination.GCS.Bucket, destination.GCS.Prefix)
+	case destination.Azure != nil:
+		return path.Join(destination.Azure.Container, destination.Azure.Prefix)
+	}
+	return ""
+}
+
+func postgresqlDestinationBackupPodSpec(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) v1.PodSpec {
+	var volumes []v1.Volume
+	var volumeMounts []v1.VolumeMount
+	if secretName := postgresqlBackupKeysSecretName(cr, false); secretName != "" {
+		volumes = append(volumes, postgresqlBackupKeysVolume(secretName))
+		volumeMounts = append(volumeMounts, postgresqlBackupKeysVolumeMount())
+	}
+
+	podSpec := destinationBackupPodSpec(cr, v1.Container{
+		Image:        postgresqlBackupImage(cr, keycloak),
+		Args:         []string{postgresqlDestinationDumpScript(cr)},
+		Env:          postgresqlBackupDatabaseEnv(keycloak),
+		VolumeMounts: volumeMounts,
+	}, volumes, postgresqlDestinationUploadScript)
+	podSpec.ImagePullSecrets = keycloak.Spec.PostgresDeploymentSpec.ImagePullSecrets
+	return podSpec
+}
+
+// Pod writing the backup to /backup with the dump container, uploaded by the upload script afterwards
+func destinationBackupPodSpec(cr *v1alpha1.KeycloakBackup, dump v1.Container, dumpVolumes []v1.Volume, uploadScript string) v1.PodSpec {
+	volumes := []v1.Volume{
+		{
+			Name: postgresqlDestinationBackupVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+	backupVolumeMount := v1.VolumeMount{
+		Name:      postgresqlDestinationBackupVolumeName,
+		MountPath: "/backup",
+	}
+	credentialsVolumes, credentialsVolumeMounts := postgresqlDestinationCredentials(cr.Spec.Destination)
+	volumes = append(append(volumes, credentialsVolumes...), dumpVolumes...)
+
+	dump.Name = cr.Name + "-dump"
+	dump.Command = []string{"/bin/sh", "-c"}
+	dump.VolumeMounts = append([]v1.VolumeMount{backupVolumeMount}, dump.VolumeMounts...)
+
+	return v1.PodSpec{
+		Volumes:        volumes,
+		InitContainers: []v1.Container{dump},
+		Containers: []v1.Container{
+			{
+				Name:         cr.Name,
+				Image:        Images.Images[BackupUploader],
+				Command:      []string{"/bin/sh", "-c"},
+				Args:         []string{uploadScript},
+				Env:          append(postgresqlDestinationEnv(cr.Spec.Destination), postgresqlBackupRetentionEnv(cr)...),
+				VolumeMounts: append([]v1.VolumeMount{backupVolumeMount}, credentialsVolumeMounts...),
+			},
+		},
+		RestartPolicy:      v1.RestartPolicyNever,
+		ServiceAccountName: PostgresqlBackupServiceAccountName,
+	}
+}
+
+// The volume with the service account key of GCS, the other destinations pass their credentials in the environment
+func postgresqlDestinationCredentials(destination *v1alpha1.KeycloakBackupDestination) ([]v1.Volume, []v1.VolumeMount) {
+	if destination.GCS == nil {
+		return nil, nil
+	}
+
+	volume := v1.Volume{
+		Name: postgresqlDestinationCredentialsName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName: destination.GCS.CredentialsSecretName,
+			},
+		},
+	}
+	volumeMount := v1.VolumeMount{
+		Name:      postgresqlDestinationCredentialsName,
+		MountPath: postgresqlDestinationCredentialsPath,
+		ReadOnly:  true,
+	}
+	return []v1.Volume{volume}, []v1.VolumeMount{volumeMount}
+}
+
+// The path of the backups and the configuration of the rclone remote
+func postgresqlDestinationEnv(destination *v1alpha1.KeycloakBackupDestination) []v1.EnvVar {
+	return append([]v1.EnvVar{
+		{
+			Name:  "BACKUP_PATH",
+			Value: BackupDestinationPath(destination),
+		},
+	}, postgresqlDestinationRemoteEnv(destination)...)
+}
+
+func postgresqlDestinationRemoteEnv(destination *v1alpha1.KeycloakBackupDestination) []v1.EnvVar {
+	switch {
+	case destination.S3 != nil:
+		provider := "AWS"
+		if destination.S3.Endpoint != "" {
+			provider = "Other"
+		}
+		return []v1.EnvVar{
+			{Name: postgresqlDestinationEnvPrefix + "TYPE", Value: "s3"},
+			{Name: postgresqlDestinationEnvPrefix + "PROVIDER", Value: provider},
+			{Name: postgresqlDestinationEnvPrefix + "ENDPOINT", Value: destination.S3.Endpoint},
+			{Name: postgresqlDestinationEnvPrefix + "REGION", Value: destination.S3.Region},
+			{Name: postgresqlDestinationEnvPrefix + "FORCE_PATH_STYLE", Value: strconv.FormatBool(destination.S3.ForcePathStyle)},
+			secretEnvVar(postgresqlDestinationEnvPrefix+"ACCESS_KEY_ID", destination.S3.CredentialsSecretName, BackupS3AccessKeyIDProperty),
+			secretEnvVar(postgresqlDestinationEnvPrefix+"SECRET_ACCESS_KEY", destination.S3.CredentialsSecretName, BackupS3SecretAccessKeyProperty),
+		}
+	case destination.GCS != nil:
+		return []v1.EnvVar{
+			{Name: postgresqlDestinationEnvPrefix + "TYPE", Value: "google cloud storage"},
+			{Name: postgresqlDestinationEnvPrefix + "SERVICE_ACCOUNT_FILE", Value: postgresqlDestinationCredentialsPath + "/" + BackupGCSCredentialsProperty},
+			{Name: postgresqlDestinationEnvPrefix + "BUCKET_POLICY_ONLY", Value: "true"},
+		}
+	case destination.Azure != nil:
+		return []v1.EnvVar{
+			{Name: postgresqlDestinationEnvPrefix + "TYPE", Value: "azureblob"},
+			secretEnvVar(postgresqlDestinationEnvPrefix+"ACCOUNT", destination.Azure.CredentialsSecretName, BackupAzureStorageAccountProperty),
+			secretEnvVar(postgresqlDestinationEnvPrefix+"KEY", destination.Azure.CredentialsSecretName, BackupAzureStorageKeyProperty),
+		}
+	}
+	return nil
+}
+
+func secretEnvVar(name, secretName, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		},
+	}
+}
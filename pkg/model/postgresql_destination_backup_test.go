@@ -0,0 +1,92 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPostgresqlDestinationBackup_testS3CompatibleStorage(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "nightly", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				S3: &v1alpha1.KeycloakBackupS3Destination{
+					Bucket:                "backups",
+					Prefix:                "keycloak/",
+					Endpoint:              "http://minio.minio.svc:9000",
+					Region:                "us-east-1",
+					ForcePathStyle:        true,
+					CredentialsSecretName: "minio-credentials",
+				},
+			},
+		},
+	}
+
+	//when
+	job := PostgresqlBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, Images.Images[PostgresqlImage], podSpec.InitContainers[0].Image)
+	assert.Equal(t, Images.Images[BackupUploader], podSpec.Containers[0].Image)
+	assert.NotNil(t, podSpec.Volumes[0].EmptyDir)
+
+	env := map[string]v1.EnvVar{}
+	for _, variable := range podSpec.Containers[0].Env {
+		env[variable.Name] = variable
+	}
+	assert.Equal(t, "backups/keycloak", env["BACKUP_PATH"].Value)
+	assert.Equal(t, "Other", env["RCLONE_CONFIG_DESTINATION_PROVIDER"].Value)
+	assert.Equal(t, "http://minio.minio.svc:9000", env["RCLONE_CONFIG_DESTINATION_ENDPOINT"].Value)
+	assert.Equal(t, "us-east-1", env["RCLONE_CONFIG_DESTINATION_REGION"].Value)
+	assert.Equal(t, "true", env["RCLONE_CONFIG_DESTINATION_FORCE_PATH_STYLE"].Value)
+	assert.Equal(t, "minio-credentials", env["RCLONE_CONFIG_DESTINATION_ACCESS_KEY_ID"].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, BackupS3SecretAccessKeyProperty, env["RCLONE_CONFIG_DESTINATION_SECRET_ACCESS_KEY"].ValueFrom.SecretKeyRef.Key)
+}
+
+func TestPostgresqlDestinationBackup_testGCSCredentialsAreMounted(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "nightly", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				GCS: &v1alpha1.KeycloakBackupGCSDestination{Bucket: "backups", CredentialsSecretName: "gcs-credentials"},
+			},
+		},
+	}
+
+	//when
+	podSpec := PostgresqlBackup(cr, &v1alpha1.Keycloak{}).Spec.Template.Spec
+
+	//then
+	assert.Equal(t, "gcs-credentials", podSpec.Volumes[1].Secret.SecretName)
+	assert.Len(t, podSpec.InitContainers[0].VolumeMounts, 1)
+	assert.Len(t, podSpec.Containers[0].VolumeMounts, 2)
+}
+
+func TestPostgresqlDestinationBackup_testValidation(t *testing.T) {
+	valid := &v1alpha1.KeycloakBackupDestination{
+		Azure: &v1alpha1.KeycloakBackupAzureDestination{Container: "backups", CredentialsSecretName: "azure-credentials"},
+	}
+	ambiguous := &v1alpha1.KeycloakBackupDestination{
+		Azure: valid.Azure,
+		S3:    &v1alpha1.KeycloakBackupS3Destination{Bucket: "backups", CredentialsSecretName: "s3-credentials"},
+	}
+	incomplete := &v1alpha1.KeycloakBackupDestination{
+		GCS: &v1alpha1.KeycloakBackupGCSDestination{Bucket: "backups"},
+	}
+
+	assert.NoError(t, ValidateBackupDestination(&v1alpha1.KeycloakBackup{}))
+	assert.NoError(t, ValidateBackupDestination(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{Destination: valid}}))
+	assert.Error(t, ValidateBackupDestination(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{Destination: ambiguous}}))
+	assert.Error(t, ValidateBackupDestination(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{Destination: incomplete}}))
+	assert.Error(t, ValidateBackupDestination(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		Destination: valid,
+		AWS:         v1alpha1.KeycloakAWSSpec{CredentialsSecretName: "aws-secret"},
+	}}))
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func PostgresqlPeriodicBackup(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) *v1beta1.CronJob {
+	return &v1beta1.CronJob{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      cr.Name,
+			Namespace: cr.Namespace,
+			Labels:    PostgresqlBackupLabels(cr),
+		},
+		Spec: v1beta1.CronJobSpec{
+			Schedule:          cr.Spec.Schedule,
+			ConcurrencyPolicy: v1beta1.ForbidConcurrent,
+			JobTemplate:       postgresqlPeriodicBackupJobTemplate(cr, keycloak),
+		},
+	}
+}
+
+func PostgresqlPeriodicBackupSelector(cr *v1alpha1.KeycloakBackup) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      cr.Name,
+		Namespace: cr.Namespace,
+	}
+}
+
+func PostgresqlPeriodicBackupReconciled(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, currentState *v1beta1.CronJob) *v1beta1.CronJob {
+	reconciled := currentState.DeepCopy()
+	reconciled.Labels = PostgresqlBackupLabels(cr)
+	reconciled.Spec.Schedule = cr.Spec.Schedule
+	reconciled.Spec.ConcurrencyPolicy = v1beta1.ForbidConcurrent
+	reconciled.Spec.JobTemplate = postgresqlPeriodicBackupJobTemplate(cr, keycloak)
+	return reconciled
+}
+
+func postgresqlPeriodicBackupJobTemplate(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) v1beta1.JobTemplateSpec {
+	return v1beta1.JobTemplateSpec{
+		ObjectMeta: v12.ObjectMeta{
+			Labels: PostgresqlBackupLabels(cr),
+		},
+		Spec: v13.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{
+					Labels: PostgresqlBackupLabels(cr),
+				},
+				Spec: postgresqlBackupPodSpec(cr, keycloak),
+			},
+		},
+	}
+}
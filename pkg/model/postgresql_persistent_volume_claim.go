@@ -1,6 +1,8 @@
 package model
 
 import (
+	"fmt"
+
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -11,17 +13,17 @@ import (
 func PostgresqlPersistentVolumeClaim(cr *v1alpha1.Keycloak) *v1.PersistentVolumeClaim {
 	return &v1.PersistentVolumeClaim{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      PostgresqlPersistentVolumeName,
+			Name:      PostgresqlPersistentVolumeName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 		},
 		Spec: v1.PersistentVolumeClaimSpec{
 			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
 			Resources: v1.ResourceRequirements{
 				Requests: v1.ResourceList{
-					v1.ResourceStorage: resource.MustParse(PostgresqlPersistentVolumeCapacity),
+					v1.ResourceStorage: postgresqlStorageSize(cr),
 				}},
 			StorageClassName: cr.Spec.StorageClassName,
 		},
@@ -30,20 +32,61 @@ func PostgresqlPersistentVolumeClaim(cr *v1alpha1.Keycloak) *v1.PersistentVolume
 
 func PostgresqlPersistentVolumeClaimSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      PostgresqlPersistentVolumeName,
+		Name:      PostgresqlPersistentVolumeName(cr),
 		Namespace: cr.Namespace,
 	}
 }
 
+// Increasing the requested size expands the claim if its StorageClass allows volume expansion, otherwise the update
+// is rejected. Claims can't shrink, a smaller size keeps the requested one.
 func PostgresqlPersistentVolumeClaimReconciled(cr *v1alpha1.Keycloak, currentState *v1.PersistentVolumeClaim) *v1.PersistentVolumeClaim {
+	size := postgresqlStorageSize(cr)
+	if requested, ok := currentState.Spec.Resources.Requests[v1.ResourceStorage]; ok && requested.Cmp(size) > 0 {
+		size = requested
+	}
+
 	reconciled := currentState.DeepCopy()
 	reconciled.Spec.AccessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}
 	reconciled.Spec.Resources = v1.ResourceRequirements{
 		Requests: v1.ResourceList{
-			v1.ResourceStorage: resource.MustParse(PostgresqlPersistentVolumeCapacity),
+			v1.ResourceStorage: size,
 		}}
 	if cr.Spec.StorageClassName != nil {
 		reconciled.Spec.StorageClassName = cr.Spec.StorageClassName
 	}
 	return reconciled
 }
+
+// PostgresqlStorageStatus returns the capacity of the claim and the progress of its expansion
+func PostgresqlStorageStatus(currentState *v1.PersistentVolumeClaim) (string, string) {
+	capacity, bound := currentState.Status.Capacity[v1.ResourceStorage]
+	if !bound {
+		return "", ""
+	}
+	requested := currentState.Spec.Resources.Requests[v1.ResourceStorage]
+
+	for _, condition := range currentState.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case v1.PersistentVolumeClaimResizing:
+			return capacity.String(), fmt.Sprintf("expanding the volume to %s", requested.String())
+		case v1.PersistentVolumeClaimFileSystemResizePending:
+			return capacity.String(), fmt.Sprintf("expanding the file system to %s, volumes not supporting online expansion are expanded when PostgreSQL restarts", requested.String())
+		}
+	}
+	if capacity.Cmp(requested) < 0 {
+		return capacity.String(), fmt.Sprintf("expansion to %s requested", requested.String())
+	}
+	return capacity.String(), ""
+}
+
+// The configured size, validated before
+func postgresqlStorageSize(cr *v1alpha1.Keycloak) resource.Quantity {
+	size, err := resource.ParseQuantity(cr.Spec.PostgresDeploymentSpec.StorageSize)
+	if err != nil {
+		return resource.MustParse(PostgresqlPersistentVolumeCapacity)
+	}
+	return size
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestPostgresqlPersistentVolumeClaim_testStorageSize(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+
+	//when
+	defaultClaim := PostgresqlPersistentVolumeClaim(cr)
+	cr.Spec.PostgresDeploymentSpec.StorageSize = "10Gi"
+	claim := PostgresqlPersistentVolumeClaim(cr)
+
+	//then
+	assert.Equal(t, resource.MustParse(PostgresqlPersistentVolumeCapacity), defaultClaim.Spec.Resources.Requests[v1.ResourceStorage])
+	assert.Equal(t, resource.MustParse("10Gi"), claim.Spec.Resources.Requests[v1.ResourceStorage])
+}
+
+func TestPostgresqlPersistentVolumeClaim_testExpansion(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.StorageSize = "10Gi"
+	currentState := PostgresqlPersistentVolumeClaim(&v1alpha1.Keycloak{})
+
+	//when
+	reconciled := PostgresqlPersistentVolumeClaimReconciled(cr, currentState)
+
+	//then
+	assert.Equal(t, resource.MustParse("10Gi"), reconciled.Spec.Resources.Requests[v1.ResourceStorage])
+}
+
+func TestPostgresqlPersistentVolumeClaim_testClaimDoesNotShrink(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.StorageSize = "5Gi"
+	currentState := PostgresqlPersistentVolumeClaim(&v1alpha1.Keycloak{})
+	currentState.Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse("10Gi")
+
+	//when
+	reconciled := PostgresqlPersistentVolumeClaimReconciled(cr, currentState)
+
+	//then
+	assert.Equal(t, resource.MustParse("10Gi"), reconciled.Spec.Resources.Requests[v1.ResourceStorage])
+}
+
+func TestPostgresqlPersistentVolumeClaim_testStorageStatus(t *testing.T) {
+	//given
+	claim := PostgresqlPersistentVolumeClaim(&v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{
+		PostgresDeploymentSpec: v1alpha1.PostgresqlDeploymentSpec{StorageSize: "10Gi"},
+	}})
+
+	// not bound yet
+	capacity, message := PostgresqlStorageStatus(claim)
+	assert.Empty(t, capacity)
+	assert.Empty(t, message)
+
+	// expansion requested
+	claim.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("1Gi")}
+	capacity, message = PostgresqlStorageStatus(claim)
+	assert.Equal(t, "1Gi", capacity)
+	assert.Equal(t, "expansion to 10Gi requested", message)
+
+	// waiting for the file system
+	claim.Status.Conditions = []v1.PersistentVolumeClaimCondition{{
+		Type:   v1.PersistentVolumeClaimFileSystemResizePending,
+		Status: v1.ConditionTrue,
+	}}
+	_, message = PostgresqlStorageStatus(claim)
+	assert.Contains(t, message, "expanding the file system to 10Gi")
+
+	// expanded
+	claim.Status.Conditions = nil
+	claim.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")}
+	capacity, message = PostgresqlStorageStatus(claim)
+	assert.Equal(t, "10Gi", capacity)
+	assert.Empty(t, message)
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"strconv"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const postgresqlRestoreVolumeName = "restore"
+
+// Selects the file to restore from the local Persistent Volume
+const postgresqlLocalRestoreSourceScript = `set -e
+FILE=${RESTORE_FILE:-$(ls -1 /backup | grep -E '` + postgresqlBackupFilePattern + `' | sort -r | head -n 1)}
+[ -n "$FILE" ] || { echo "no backup to restore" >&2; exit 1; }
+SOURCE=/backup/$FILE`
+
+// Downloads the file to restore from the destination
+const postgresqlDestinationRestoreDownloadScript = `set -e
+FILE=${RESTORE_FILE:-$(rclone lsf destination:$BACKUP_PATH --files-only --include 'backup-*.sql{,.age,.gpg}' | sort -r | head -n 1)}
+[ -n "$FILE" ] || { echo "no backup to restore" >&2; exit 1; }
+rclone copyto destination:$BACKUP_PATH/$FILE /restore/$FILE`
+
+// Selects the file downloaded from the destination
+const postgresqlDestinationRestoreSourceScript = `set -e
+FILE=$(ls -1 /restore | grep -E '` + postgresqlBackupFilePattern + `' | head -n 1)
+SOURCE=/restore/$FILE`
+
+// The backup is decrypted completely before it's restored in a single transaction, a failing decryption or restore
+// leaves the database untouched. The dump only drops the objects it contains, so the objects of the database user are
+// dropped first, e.g. the tables a newer Keycloak version created before a rollback. Unlike DROP SCHEMA public this
+// doesn't need a superuser, the public schema is owned by postgres before PostgreSQL 15.
+const postgresqlRestoreScript = postgresqlBackupDecryptScript + `
+trap 'rm -f /restore/restore.sql' EXIT
+decrypt $FILE < $SOURCE > /restore/restore.sql
+psql --single-transaction --set ON_ERROR_STOP=1 --quiet --command '` + postgresqlRestoreResetCommand + `' --file /restore/restore.sql $POSTGRES_DB
+printf '{"file":"%s"}' $FILE > /dev/termination-log`
+
+// Empties the database, the public schema is recreated if the database user owned it
+const postgresqlRestoreResetCommand = `DROP OWNED BY CURRENT_USER CASCADE; CREATE SCHEMA IF NOT EXISTS public`
+
+// PostgresqlRestore restores the backup file, see PostgresqlRestoreFile, into the database of the Keycloak instance
+func PostgresqlRestore(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, file string) *v13.Job {
+	return &v13.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      postgresqlRestoreName(cr),
+			Namespace: cr.Namespace,
+			Labels:    postgresqlRestoreLabels(cr),
+		},
+		Spec: v13.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{
+					Labels: postgresqlRestoreLabels(cr),
+				},
+				Spec: postgresqlRestorePodSpec(cr, keycloak, file),
+			},
+		},
+	}
+}
+
+// ValidateBackupRestore returns an error if the restore requested by the CR is not supported
+func ValidateBackupRestore(cr *v1alpha1.KeycloakBackup) error {
+	if cr.Spec.RestoreGeneration != 0 && cr.Spec.AWS != (v1alpha1.KeycloakAWSSpec{}) {
+		return errors.Errorf("restore of backup %v/%v to aws is not supported", cr.Namespace, cr.Name)
+	}
+	return nil
+}
+
+func PostgresqlRestoreSelector(cr *v1alpha1.KeycloakBackup) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      postgresqlRestoreName(cr),
+		Namespace: cr.Namespace,
+	}
+}
+
+// PostgresqlRestoreFile returns the file to restore, the restoreFile of the CR or else the one of the latest succeeded
+// backup of the history. Empty if there is none.
+func PostgresqlRestoreFile(cr *v1alpha1.KeycloakBackup, history []v1alpha1.KeycloakBackupHistoryEntry) string {
+	if cr.Spec.RestoreFile != "" {
+		return cr.Spec.RestoreFile
+	}
+	for _, entry := range history {
+		if entry.Result == v1alpha1.BackupResultSucceeded && entry.File != "" {
+			return entry.File
+		}
+	}
+	return ""
+}
+
+// Every restoreGeneration is restored by its own Job
+func postgresqlRestoreName(cr *v1alpha1.KeycloakBackup) string {
+	return cr.Name + "-restore-" + strconv.FormatInt(cr.Spec.RestoreGeneration, 10)
+}
+
+func postgresqlRestoreLabels(cr *v1alpha1.KeycloakBackup) map[string]string {
+	return map[string]string{
+		"app":       ApplicationName,
+		"component": PostgresqlRestoreComponent,
+		BackupLabel: cr.Name,
+	}
+}
+
+func postgresqlRestorePodSpec(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, file string) v1.PodSpec {
+	if cr.Spec.Type == v1alpha1.RealmsBackupType {
+		return realmRestorePodSpec(cr, keycloak, file)
+	}
+
+	podSpec := postgresqlBackupSourcePodSpec(cr, file, v1.Container{
+		Name:  postgresqlRestoreName(cr),
+		Image: postgresqlBackupImage(cr, keycloak),
+		Args:  []string{postgresqlRestoreScript},
+		Env:   postgresqlBackupDatabaseEnv(keycloak),
+	})
+	podSpec.ImagePullSecrets = keycloak.Spec.PostgresDeploymentSpec.ImagePullSecrets
+	return podSpec
+}
+
+// Pod running the script of the container on a backup file, the latest one if file is empty. The script is started
+// with FILE set to the name and SOURCE to the path of the backup file, decryption keys are mounted if encrypted.
+func postgresqlBackupSourcePodSpec(cr *v1alpha1.KeycloakBackup, file string, container v1.Container) v1.PodSpec {
+	restoreVolumeMount := v1.VolumeMount{
+		Name:      postgresqlRestoreVolumeName,
+		MountPath: "/restore",
+	}
+	volumes := []v1.Volume{
+		{
+			Name: postgresqlRestoreVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+	volumeMounts := []v1.VolumeMount{restoreVolumeMount}
+	fileEnv := v1.EnvVar{
+		Name:  "RESTORE_FILE",
+		Value: file,
+	}
+
+	var initContainers []v1.Container
+	sourceScript, downloadScript, downloadedSourceScript := backupSourceScripts(cr)
+	if cr.Spec.Destination != nil {
+		credentialsVolumes, credentialsVolumeMounts := postgresqlDestinationCredentials(cr.Spec.Destination)
+		volumes = append(volumes, credentialsVolumes...)
+		initContainers = append(initContainers, v1.Container{
+			Name:         container.Name + "-download",
+			Image:        Images.Images[BackupUploader],
+			Command:      []string{"/bin/sh", "-c"},
+			Args:         []string{downloadScript},
+			Env:          append(postgresqlDestinationEnv(cr.Spec.Destination), fileEnv),
+			VolumeMounts: append([]v1.VolumeMount{restoreVolumeMount}, credentialsVolumeMounts...),
+		})
+		sourceScript = downloadedSourceScript
+	} else {
+		volumes = append(volumes, postgresqlLocalBackupVolume(cr))
+		volumeMounts = append(volumeMounts, postgresqlLocalBackupVolumeMount(cr))
+	}
+
+	if secretName := postgresqlBackupKeysSecretName(cr, true); secretName != "" {
+		volumes = append(volumes, postgresqlBackupKeysVolume(secretName))
+		volumeMounts = append(volumeMounts, postgresqlBackupKeysVolumeMount())
+	}
+
+	container.Command = []string{"/bin/sh", "-c"}
+	container.Args = []string{sourceScript + "\n" + container.Args[0]}
+	container.Env = append(container.Env, fileEnv)
+	container.VolumeMounts = append(volumeMounts, container.VolumeMounts...)
+
+	return v1.PodSpec{
+		Volumes:            volumes,
+		InitContainers:     initContainers,
+		Containers:         []v1.Container{container},
+		RestartPolicy:      v1.RestartPolicyNever,
+		ServiceAccountName: PostgresqlBackupServiceAccountName,
+	}
+}
+
+// The scripts selecting the backup in the Persistent Volume, downloading it from the destination and selecting the
+// downloaded backup
+func backupSourceScripts(cr *v1alpha1.KeycloakBackup) (string, string, string) {
+	if cr.Spec.Type == v1alpha1.RealmsBackupType {
+		return realmLocalRestoreSourceScript, realmDestinationRestoreDownloadScript, realmDestinationRestoreSourceScript
+	}
+	return postgresqlLocalRestoreSourceScript, postgresqlDestinationRestoreDownloadScript, postgresqlDestinationRestoreSourceScript
+}
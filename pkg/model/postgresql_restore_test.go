@@ -0,0 +1,108 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPostgresqlRestore_testLocalBackupIsDecrypted(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "nightly", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			RestoreGeneration: 1,
+			RestoreFile:       "backup-20261018030000.sql.gpg",
+			Encryption: &v1alpha1.KeycloakBackupEncryption{
+				Type:                  v1alpha1.GPGBackupEncryptionType,
+				PublicKeysSecretName:  "backup-public-keys",
+				PrivateKeysSecretName: "backup-private-keys",
+			},
+		},
+	}
+
+	//when
+	job := PostgresqlRestore(cr, &v1alpha1.Keycloak{}, cr.Spec.RestoreFile)
+
+	//then
+	assert.Equal(t, "nightly-restore-1", job.Name)
+	assert.Equal(t, PostgresqlRestoreComponent, job.Labels["component"])
+	podSpec := job.Spec.Template.Spec
+	assert.Empty(t, podSpec.InitContainers)
+	assert.Equal(t, PostgresqlBackupPersistentVolumeName+"-nightly", podSpec.Volumes[1].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "backup-private-keys", podSpec.Volumes[2].Secret.SecretName)
+	assert.Equal(t, Images.Images[PostgresqlImage], podSpec.Containers[0].Image)
+	assert.Contains(t, podSpec.Containers[0].Args[0], "psql --single-transaction")
+	assert.Contains(t, podSpec.Containers[0].Env, v1.EnvVar{Name: "RESTORE_FILE", Value: "backup-20261018030000.sql.gpg"})
+}
+
+func TestPostgresqlRestore_testDestinationBackupIsDownloaded(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "nightly", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			RestoreGeneration: 1,
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				S3: &v1alpha1.KeycloakBackupS3Destination{Bucket: "backups", CredentialsSecretName: "s3-credentials"},
+			},
+		},
+	}
+
+	//when
+	podSpec := PostgresqlRestore(cr, &v1alpha1.Keycloak{}, cr.Spec.RestoreFile).Spec.Template.Spec
+
+	//then
+	assert.Len(t, podSpec.Volumes, 1)
+	assert.Equal(t, Images.Images[BackupUploader], podSpec.InitContainers[0].Image)
+	assert.Contains(t, podSpec.InitContainers[0].Args[0], "rclone copyto destination:$BACKUP_PATH/$FILE /restore/$FILE")
+	assert.Equal(t, Images.Images[PostgresqlImage], podSpec.Containers[0].Image)
+}
+
+func TestPostgresqlRestore_testLatestSucceededBackupIsRestored(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	history := []v1alpha1.KeycloakBackupHistoryEntry{
+		{Job: "nightly-3", Result: v1alpha1.BackupResultFailed},
+		{Job: "nightly-2", File: "backup-20261017030000.sql", Result: v1alpha1.BackupResultSucceeded},
+		{Job: "nightly-1", File: "backup-20261016030000.sql", Result: v1alpha1.BackupResultSucceeded},
+	}
+
+	//when
+	file := PostgresqlRestoreFile(cr, history)
+
+	//then
+	assert.Equal(t, "backup-20261017030000.sql", file)
+	assert.Empty(t, PostgresqlRestoreFile(cr, history[:1]))
+	cr.Spec.RestoreFile = "backup-20261016030000.sql"
+	assert.Equal(t, "backup-20261016030000.sql", PostgresqlRestoreFile(cr, nil))
+}
+
+func TestPostgresqlRestore_testValidation(t *testing.T) {
+	assert.NoError(t, ValidateBackupRestore(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{RestoreGeneration: 1}}))
+	assert.NoError(t, ValidateBackupRestore(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		AWS: v1alpha1.KeycloakAWSSpec{CredentialsSecretName: "aws-secret"},
+	}}))
+	assert.Error(t, ValidateBackupRestore(&v1alpha1.KeycloakBackup{Spec: v1alpha1.KeycloakBackupSpec{
+		RestoreGeneration: 1,
+		AWS:               v1alpha1.KeycloakAWSSpec{CredentialsSecretName: "aws-secret"},
+	}}))
+}
+
+func TestPostgresqlRestore_testDatabaseIsEmptiedBeforeRestore(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{
+		ObjectMeta: v12.ObjectMeta{Name: "migrate", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakBackupSpec{
+			RestoreGeneration: 1,
+		},
+	}
+
+	//when
+	script := PostgresqlRestore(cr, &v1alpha1.Keycloak{}, "backup-20261018030000.sql").Spec.Template.Spec.Containers[0].Args[0]
+
+	//then
+	assert.Contains(t, script, "psql --single-transaction --set ON_ERROR_STOP=1 --quiet --command 'DROP OWNED BY CURRENT_USER CASCADE; CREATE SCHEMA IF NOT EXISTS public' --file /restore/restore.sql")
+}
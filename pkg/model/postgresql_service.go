@@ -10,7 +10,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func getSpec(dbSecret *v1.Secret, serviceTypeExternal bool) v1.ServiceSpec {
+func getSpec(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, serviceTypeExternal bool) v1.ServiceSpec {
 	spec := v1.ServiceSpec{}
 	isIPAddress := dbSecret != nil && dbSecret.Data[DatabaseSecretExternalAddressProperty] != nil && IsIP(dbSecret.Data[DatabaseSecretExternalAddressProperty])
 
@@ -26,10 +26,7 @@ func getSpec(dbSecret *v1.Secret, serviceTypeExternal bool) v1.ServiceSpec {
 		}
 	} else {
 		spec.Type = v1.ServiceTypeClusterIP
-		spec.Selector = map[string]string{
-			"app":       ApplicationName,
-			"component": PostgresqlDeploymentComponent,
-		}
+		spec.Selector = PostgresqlLabelsSelector(cr)
 	}
 
 	spec.Ports = []v1.ServicePort{
@@ -45,31 +42,28 @@ func getSpec(dbSecret *v1.Secret, serviceTypeExternal bool) v1.ServiceSpec {
 func PostgresqlService(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, serviceTypeExternal bool) *v1.Service {
 	return &v1.Service{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      PostgresqlServiceName,
+			Name:      PostgresqlServiceName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 		},
-		Spec: getSpec(dbSecret, serviceTypeExternal),
+		Spec: getSpec(cr, dbSecret, serviceTypeExternal),
 	}
 }
 
 func PostgresqlServiceSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      PostgresqlServiceName,
+		Name:      PostgresqlServiceName(cr),
 		Namespace: cr.Namespace,
 	}
 }
 
-func PostgresqlServiceReconciled(currentState *v1.Service, dbSecret *v1.Secret, serviceTypeExternal bool) *v1.Service {
+func PostgresqlServiceReconciled(cr *v1alpha1.Keycloak, currentState *v1.Service, dbSecret *v1.Secret, serviceTypeExternal bool) *v1.Service {
 	reconciled := currentState.DeepCopy()
 	if !serviceTypeExternal {
 		reconciled.Spec.Type = v1.ServiceTypeClusterIP
-		reconciled.Spec.Selector = map[string]string{
-			"app":       ApplicationName,
-			"component": PostgresqlDeploymentComponent,
-		}
+		reconciled.Spec.Selector = PostgresqlLabelsSelector(cr)
 		reconciled.Spec.Ports = []v1.ServicePort{
 			{
 				Port:       5432,
@@ -77,7 +71,7 @@ func PostgresqlServiceReconciled(currentState *v1.Service, dbSecret *v1.Secret,
 			},
 		}
 	} else {
-		reconciled.Spec = getSpec(dbSecret, serviceTypeExternal)
+		reconciled.Spec = getSpec(cr, dbSecret, serviceTypeExternal)
 	}
 	return reconciled
 }
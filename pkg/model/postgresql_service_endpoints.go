@@ -12,11 +12,11 @@ import (
 func PostgresqlServiceEndpoints(cr *v1alpha1.Keycloak) *v1.Endpoints {
 	return &v1.Endpoints{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      PostgresqlServiceName,
+			Name:      PostgresqlServiceName(cr),
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
+			Labels: instanceLabels(cr, map[string]string{
 				"app": ApplicationName,
-			},
+			}),
 		},
 		Subsets: []v1.EndpointSubset{{
 			Addresses: []v1.EndpointAddress{{}},
@@ -27,7 +27,7 @@ func PostgresqlServiceEndpoints(cr *v1alpha1.Keycloak) *v1.Endpoints {
 
 func PostgresqlServiceEndpointsSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      PostgresqlServiceName,
+		Name:      PostgresqlServiceName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Moves the data directory of the version upgraded from aside, the new version initializes an empty one on its first
+// start. The images keep the data in the userdata directory of the volume. The dump is restored next to the moved
+// directory, so the volume needs the free space for a second copy of the data.
+const postgresqlUpgradeScript = `set -e
+cd ` + PostgresqlPersistentVolumeMountPath + `
+if [ -d userdata ]; then
+  [ ! -e userdata-$FROM_VERSION ] || { echo "userdata-$FROM_VERSION already exists" >&2; exit 1; }
+  USED=$(du -sk userdata | cut -f1)
+  FREE=$(df -Pk . | awk 'NR == 2 {print $4}')
+  if [ "$FREE" -lt "$USED" ]; then
+    echo "the volume has ${FREE}KiB free, the data of PostgreSQL $FROM_VERSION uses ${USED}KiB" | tee /dev/termination-log >&2
+    exit 1
+  fi
+  mv userdata userdata-$FROM_VERSION
+fi`
+
+// PostgresqlUpgrade prepares the volume of the scaled down PostgreSQL for the new major version of the upgrade in
+// the status
+func PostgresqlUpgrade(cr *v1alpha1.Keycloak) *v13.Job {
+	upgrade := cr.Status.Postgres.Upgrade
+	job := &v13.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      PostgresqlUpgradeName(cr, upgrade.FromVersion),
+			Namespace: cr.Namespace,
+			Labels:    postgresqlUpgradeLabels(cr),
+		},
+		Spec: v13.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(2),
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{
+					Labels: postgresqlUpgradeLabels(cr),
+				},
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyNever,
+					Containers: []v1.Container{
+						{
+							Name:    PostgresqlUpgradeComponent,
+							Image:   upgrade.FromImage,
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{postgresqlUpgradeScript},
+							Env: []v1.EnvVar{
+								{
+									Name:  "FROM_VERSION",
+									Value: upgrade.FromVersion,
+								},
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      PostgresqlPersistentVolumeName(cr),
+									MountPath: PostgresqlPersistentVolumeMountPath,
+								},
+							},
+							ImagePullPolicy: cr.Spec.PostgresDeploymentSpec.ImagePullPolicy,
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: PostgresqlPersistentVolumeName(cr),
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: PostgresqlPersistentVolumeName(cr),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Scheduled like PostgreSQL to be able to attach its volume
+	applyDeploymentSpec(&job.Spec.Template.Spec, cr.Spec.PostgresDeploymentSpec.DeploymentSpec)
+	return job
+}
+
+func PostgresqlUpgradeSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      PostgresqlUpgradeName(cr, cr.Status.Postgres.Upgrade.FromVersion),
+		Namespace: cr.Namespace,
+	}
+}
+
+func postgresqlUpgradeLabels(cr *v1alpha1.Keycloak) map[string]string {
+	return instanceLabels(cr, map[string]string{
+		"app":       ApplicationName,
+		"component": PostgresqlUpgradeComponent,
+	})
+}
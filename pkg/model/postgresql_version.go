@@ -0,0 +1,80 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Major version of PostgreSQL run by the default image
+const DefaultPostgresqlVersion = "10"
+
+// The images of the supported major versions of PostgreSQL
+var postgresqlVersionImages = map[string]string{
+	"10": PostgresqlImage,
+	"12": PostgresqlImage12,
+	"13": PostgresqlImage13,
+	"15": PostgresqlImage15,
+}
+
+// Major version in image names like rhscl/postgresql-10-rhel7, rhel8/postgresql-13 or postgres:15
+var postgresqlImageVersionRegexp = regexp.MustCompile(`^postgres(?:ql)?[-:]([0-9]+)`)
+
+// PostgresqlVersion returns the major version of the embedded PostgreSQL
+func PostgresqlVersion(cr *v1alpha1.Keycloak) string {
+	if cr != nil && cr.Spec.PostgresVersion != "" {
+		return cr.Spec.PostgresVersion
+	}
+	return DefaultPostgresqlVersion
+}
+
+// PostgresqlImageVersion returns the major version of PostgreSQL run by a deployed image, or an empty string if it's
+// unknown. The version in the name of the image is preferred over the images of the versions, as the image of a
+// version can be overridden with any image.
+func PostgresqlImageVersion(cr *v1alpha1.Keycloak, image string) string {
+	if cr.Spec.PostgresImage != "" && image == cr.Spec.PostgresImage {
+		return PostgresqlVersion(cr)
+	}
+
+	name := image[strings.LastIndex(image, "/")+1:]
+	if match := postgresqlImageVersionRegexp.FindStringSubmatch(name); match != nil {
+		return match[1]
+	}
+
+	for version, imageKey := range postgresqlVersionImages {
+		if Images.Images[imageKey] == image {
+			return version
+		}
+	}
+	return ""
+}
+
+// ComparePostgresqlVersions returns a negative number if version a is older than b, zero if they are the same and a
+// positive number if a is newer. Versions are validated to be numbers.
+func ComparePostgresqlVersions(a string, b string) int {
+	versionA, _ := strconv.Atoi(a)
+	versionB, _ := strconv.Atoi(b)
+	return versionA - versionB
+}
+
+// ValidatePostgresql returns an error if the version or the storage size of the embedded PostgreSQL is invalid
+func ValidatePostgresql(cr *v1alpha1.Keycloak) error {
+	version := PostgresqlVersion(cr)
+	if _, err := strconv.Atoi(version); err != nil {
+		return errors.Errorf("postgresVersion %q needs to be a major version like 13", version)
+	}
+	if _, ok := postgresqlVersionImages[version]; !ok && cr.Spec.PostgresImage == "" {
+		return errors.Errorf("postgresVersion %q is not supported, use 10, 12, 13 or 15 or set postgresImage", version)
+	}
+
+	if size := cr.Spec.PostgresDeploymentSpec.StorageSize; size != "" {
+		if _, err := resource.ParseQuantity(size); err != nil {
+			return errors.Wrapf(err, "invalid postgresDeploymentSpec.storageSize %q", size)
+		}
+	}
+	return nil
+}
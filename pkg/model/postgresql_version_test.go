@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresqlVersion_testValidation(t *testing.T) {
+	assert.Nil(t, ValidatePostgresql(&v1alpha1.Keycloak{}))
+	assert.Nil(t, ValidatePostgresql(&v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{PostgresVersion: "15"}}))
+	assert.Nil(t, ValidatePostgresql(&v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{PostgresVersion: "16", PostgresImage: "postgresql:16"}}))
+	assert.Error(t, ValidatePostgresql(&v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{PostgresVersion: "16"}}))
+	assert.Error(t, ValidatePostgresql(&v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{PostgresVersion: "13.2"}}))
+	assert.Error(t, ValidatePostgresql(&v1alpha1.Keycloak{Spec: v1alpha1.KeycloakSpec{
+		PostgresDeploymentSpec: v1alpha1.PostgresqlDeploymentSpec{StorageSize: "ten gigs"},
+	}}))
+}
+
+func TestPostgresqlVersion_testImageVersion(t *testing.T) {
+	cr := &v1alpha1.Keycloak{}
+	assert.Equal(t, "10", PostgresqlImageVersion(cr, DefaultPostgresqlImage))
+	assert.Equal(t, "15", PostgresqlImageVersion(cr, DefaultPostgresqlImage15))
+	assert.Equal(t, "12", PostgresqlImageVersion(cr, "registry.example.com/postgresql-12-custom@sha256:0123"))
+	assert.Equal(t, "13", PostgresqlImageVersion(cr, "docker.io/library/postgres:13.4"))
+	assert.Empty(t, PostgresqlImageVersion(cr, "registry.example.com/database:1"))
+
+	cr.Spec.PostgresVersion = "16"
+	cr.Spec.PostgresImage = "registry.example.com/database:1"
+	assert.Equal(t, "16", PostgresqlImageVersion(cr, "registry.example.com/database:1"))
+}
+
+func TestPostgresqlVersion_testCompare(t *testing.T) {
+	assert.True(t, ComparePostgresqlVersions("10", "13") < 0)
+	assert.True(t, ComparePostgresqlVersions("15", "13") > 0)
+	assert.Equal(t, 0, ComparePostgresqlVersions("13", "13"))
+}
@@ -37,6 +37,9 @@ func (p *ProfileManager) IsRHSSO(cr *v1alpha1.Keycloak) bool {
 }
 
 func (p *ProfileManager) GetKeycloakOrRHSSOImage(cr *v1alpha1.Keycloak) string {
+	if cr != nil && cr.Spec.Image != "" {
+		return cr.Spec.Image
+	}
 	if p.IsRHSSO(cr) {
 		return Images.Images[RHSSOImage]
 	}
@@ -44,12 +47,22 @@ func (p *ProfileManager) GetKeycloakOrRHSSOImage(cr *v1alpha1.Keycloak) string {
 }
 
 func (p *ProfileManager) GetInitContainerImage(cr *v1alpha1.Keycloak) string {
+	if cr != nil && cr.Spec.InitContainerImage != "" {
+		return cr.Spec.InitContainerImage
+	}
 	if p.IsRHSSO(cr) {
 		return Images.Images[RHSSOInitContainer]
 	}
 	return Images.Images[KeycloakInitContainer]
 }
 
+func (p *ProfileManager) GetPostgresqlImage(cr *v1alpha1.Keycloak) string {
+	if cr != nil && cr.Spec.PostgresImage != "" {
+		return cr.Spec.PostgresImage
+	}
+	return Images.Images[postgresqlVersionImages[PostgresqlVersion(cr)]]
+}
+
 func (p *ProfileManager) getProfiles() []string {
 	env := os.Getenv(ProfileEnvironmentalVariable)
 	if env == "" {
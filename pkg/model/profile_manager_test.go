@@ -152,3 +152,41 @@ func TestProfileManager_get_init_container_image_with_with_RHSSO_profile(t *test
 	//then
 	assert.Equal(t, DefaultRHSSOInitContainer, image)
 }
+
+func TestProfileManager_get_images_overridden_by_cr(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			Profile:            RHSSOProfile,
+			Image:              "registry:5000/sso:7.6@sha256:4f2a",
+			InitContainerImage: "registry:5000/init-container:7.6",
+			PostgresImage:      "registry:5000/postgresql:13",
+		},
+	}
+
+	//when
+	profileManager := NewProfileManager()
+
+	//then
+	assert.Equal(t, "registry:5000/sso:7.6@sha256:4f2a", profileManager.GetKeycloakOrRHSSOImage(cr))
+	assert.Equal(t, "registry:5000/init-container:7.6", profileManager.GetInitContainerImage(cr))
+	assert.Equal(t, "registry:5000/postgresql:13", profileManager.GetPostgresqlImage(cr))
+	assert.Equal(t, DefaultPostgresqlImage, profileManager.GetPostgresqlImage(&v1alpha1.Keycloak{}))
+}
+
+func TestProfileManager_get_postgresql_image_of_version(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{
+		Spec: v1alpha1.KeycloakSpec{
+			PostgresVersion: "13",
+		},
+	}
+
+	//when
+	profileManager := NewProfileManager()
+
+	//then
+	assert.Equal(t, DefaultPostgresqlImage13, profileManager.GetPostgresqlImage(cr))
+	cr.Spec.PostgresImage = "registry:5000/postgresql:13"
+	assert.Equal(t, "registry:5000/postgresql:13", profileManager.GetPostgresqlImage(cr))
+}
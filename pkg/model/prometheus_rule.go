@@ -107,7 +107,7 @@ func PrometheusRule(cr *v1alpha1.Keycloak) *monitoringv1.PrometheusRule {
 
 	return &monitoringv1.PrometheusRule{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      ApplicationName,
+			Name:      KeycloakServiceName(cr),
 			Namespace: cr.Namespace,
 			Labels: map[string]string{
 				"monitoring-key": MonitoringKey,
@@ -126,7 +126,7 @@ func PrometheusRule(cr *v1alpha1.Keycloak) *monitoringv1.PrometheusRule {
 
 func PrometheusRuleSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      ApplicationName,
+		Name:      KeycloakServiceName(cr),
 		Namespace: cr.Namespace,
 	}
 }
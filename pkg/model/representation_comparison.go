@@ -0,0 +1,186 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+)
+
+// Client fields that are reconciled through dedicated actions and must not trigger a client update.
+var clientIgnoredFields = []string{"defaultRoles", "defaultClientScopes", "optionalClientScopes"}
+
+// User fields that are either reconciled through dedicated actions (roles), only applied on create (groups,
+// federated identities) or never returned by Keycloak (credentials).
+var userIgnoredFields = []string{"realmRoles", "clientRoles", "groups", "federatedIdentities", "credentials"}
+
+// Maps that Keycloak replaces as a whole on update instead of merging the provided keys.
+var userReplacedMaps = []string{"attributes"}
+
+// ClientRepresentationChanged returns true if updating the current client with the desired representation would
+// change anything in Keycloak. Keycloak leaves omitted fields untouched on update, so only the fields present in the
+// desired representation are compared. Defaults Keycloak fills in on its own are therefore never a difference.
+func ClientRepresentationChanged(desired, current *v1alpha1.KeycloakAPIClient) bool {
+	if desired == nil || current == nil {
+		return desired != current
+	}
+	return representationChanged(desired, current, clientIgnoredFields, nil)
+}
+
+// UserRepresentationChanged returns true if updating the current user with the desired representation would change
+// anything in Keycloak. Credentials are not part of the comparison because Keycloak never returns them, use
+// UserCredentialsHash to detect credential changes.
+func UserRepresentationChanged(desired, current *v1alpha1.KeycloakAPIUser) bool {
+	if desired == nil || current == nil {
+		return desired != current
+	}
+
+	// Keycloak stores usernames and emails in lower case.
+	normalizedDesired := desired.DeepCopy()
+	normalizedDesired.UserName = strings.ToLower(normalizedDesired.UserName)
+	normalizedDesired.Email = strings.ToLower(normalizedDesired.Email)
+
+	return representationChanged(normalizedDesired, current, userIgnoredFields, userReplacedMaps)
+}
+
+// UserCredentialsHash returns a stable HMAC of the desired user credentials or an empty string if the user has none.
+// The key keeps the hash in the status from being used to guess the credentials.
+func UserCredentialsHash(user *v1alpha1.KeycloakAPIUser, key []byte) string {
+	if user == nil || len(user.Credentials) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(user.Credentials)
+	if err != nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+// UserCredentialsTarget identifies the realm of a Keycloak instance the credentials of a user are applied to
+func UserCredentialsTarget(keycloak *v1alpha1.Keycloak, realm string) string {
+	return fmt.Sprintf("%v/%v/%v", keycloak.Namespace, keycloak.Name, realm)
+}
+
+func representationChanged(desired, current interface{}, ignoredFields, replacedMaps []string) bool {
+	desiredFields, err := toFieldMap(desired)
+	if err != nil {
+		return true
+	}
+	currentFields, err := toFieldMap(current)
+	if err != nil {
+		return true
+	}
+
+	for _, field := range ignoredFields {
+		delete(desiredFields, field)
+	}
+
+	for _, field := range replacedMaps {
+		desiredMap, ok := desiredFields[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		currentMap, _ := currentFields[field].(map[string]interface{})
+		if len(desiredMap) != len(currentMap) {
+			return true
+		}
+	}
+
+	return !containedIn(desiredFields, currentFields)
+}
+
+// Convert a representation to its generic JSON form so that only the fields sent to Keycloak are compared.
+func toFieldMap(representation interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(representation)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// containedIn returns true if every value in desired is also present and equal in current.
+func containedIn(desired, current interface{}) bool {
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		currentValue, ok := current.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range desiredValue {
+			other, exists := currentValue[key]
+			if !exists || !containedIn(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		currentValue, ok := current.([]interface{})
+		if !ok {
+			return len(desiredValue) == 0 && current == nil
+		}
+		return listContainedIn(desiredValue, currentValue)
+	default:
+		return reflect.DeepEqual(desired, current)
+	}
+}
+
+// Lists are replaced on update, so they have to match exactly. The order of the elements is not significant.
+func listContainedIn(desired, current []interface{}) bool {
+	if len(desired) == 0 || !isObjectList(desired) {
+		return reflect.DeepEqual(toSet(desired), toSet(current))
+	}
+
+	if len(desired) != len(current) {
+		return false
+	}
+
+	for i, value := range desired {
+		object := value.(map[string]interface{})
+		name, named := object["name"]
+		if !named {
+			// Without a name the elements can only be matched by position.
+			if !containedIn(object, current[i]) {
+				return false
+			}
+			continue
+		}
+
+		matched := false
+		for _, other := range current {
+			otherObject, ok := other.(map[string]interface{})
+			if ok && reflect.DeepEqual(otherObject["name"], name) {
+				matched = containedIn(object, otherObject)
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func isObjectList(list []interface{}) bool {
+	for _, value := range list {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func toSet(list []interface{}) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range list {
+		set[fmt.Sprintf("%v", value)] = true
+	}
+	return set
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepresentationComparison_ClientDefaultsAreIgnored(t *testing.T) {
+	desired := &v1alpha1.KeycloakAPIClient{
+		ClientID:            "test",
+		RedirectUris:        []string{"b", "a"},
+		DefaultClientScopes: []string{"profile"},
+		ProtocolMappers: []v1alpha1.KeycloakProtocolMapper{
+			{Name: "email", Protocol: "openid-connect", Config: map[string]string{"claim.name": "email"}},
+		},
+	}
+	current := &v1alpha1.KeycloakAPIClient{
+		ID:           "12345",
+		ClientID:     "test",
+		Enabled:      true,
+		RedirectUris: []string{"a", "b"},
+		Attributes:   map[string]string{"pkce.code.challenge.method": ""},
+		ProtocolMappers: []v1alpha1.KeycloakProtocolMapper{
+			{ID: "1", Name: "email", Protocol: "openid-connect", Config: map[string]string{"claim.name": "email", "jsonType.label": "String"}},
+		},
+	}
+
+	assert.False(t, ClientRepresentationChanged(desired, current))
+}
+
+func TestRepresentationComparison_ClientChanges(t *testing.T) {
+	current := &v1alpha1.KeycloakAPIClient{
+		ClientID:     "test",
+		RedirectUris: []string{"a", "b"},
+		Attributes:   map[string]string{"access.token.lifespan": "300"},
+		ProtocolMappers: []v1alpha1.KeycloakProtocolMapper{
+			{Name: "email", Config: map[string]string{"claim.name": "email"}},
+		},
+	}
+
+	assert.True(t, ClientRepresentationChanged(&v1alpha1.KeycloakAPIClient{ClientID: "test", RedirectUris: []string{"a"}}, current))
+	assert.True(t, ClientRepresentationChanged(&v1alpha1.KeycloakAPIClient{ClientID: "test", Description: "new"}, current))
+	assert.True(t, ClientRepresentationChanged(&v1alpha1.KeycloakAPIClient{ClientID: "test", Attributes: map[string]string{"access.token.lifespan": "600"}}, current))
+	assert.True(t, ClientRepresentationChanged(&v1alpha1.KeycloakAPIClient{ClientID: "test", StandardFlowEnabled: true}, current))
+	assert.True(t, ClientRepresentationChanged(&v1alpha1.KeycloakAPIClient{
+		ClientID: "test",
+		ProtocolMappers: []v1alpha1.KeycloakProtocolMapper{
+			{Name: "email", Config: map[string]string{"claim.name": "mail"}},
+		},
+	}, current))
+}
+
+func TestRepresentationComparison_User(t *testing.T) {
+	current := &v1alpha1.KeycloakAPIUser{
+		ID:         "1",
+		UserName:   "john",
+		Email:      "john@example.com",
+		Enabled:    true,
+		Attributes: map[string][]string{"department": {"it"}},
+	}
+
+	desired := &v1alpha1.KeycloakAPIUser{
+		UserName:    "John",
+		Email:       "John@example.com",
+		Enabled:     true,
+		RealmRoles:  []string{"admin"},
+		Credentials: []v1alpha1.KeycloakCredential{{Type: "password", Value: "secret"}},
+		Attributes:  map[string][]string{"department": {"it"}},
+	}
+	assert.False(t, UserRepresentationChanged(desired, current))
+
+	// Keycloak replaces all user attributes on update
+	desired.Attributes = map[string][]string{}
+	assert.False(t, UserRepresentationChanged(desired, current))
+	desired.Attributes = map[string][]string{"location": {"berlin"}}
+	assert.True(t, UserRepresentationChanged(desired, current))
+}
+
+func TestRepresentationComparison_UserCredentialsHash(t *testing.T) {
+	key := []byte("key")
+	user := &v1alpha1.KeycloakAPIUser{}
+	assert.Equal(t, "", UserCredentialsHash(user, key))
+
+	user.Credentials = []v1alpha1.KeycloakCredential{{Type: "password", Value: "secret"}}
+	hash := UserCredentialsHash(user, key)
+	assert.NotEqual(t, "", hash)
+	assert.Equal(t, hash, UserCredentialsHash(user.DeepCopy(), key))
+
+	user.Credentials[0].Value = "other"
+	assert.NotEqual(t, hash, UserCredentialsHash(user, key))
+
+	// the unkeyed hash of the credentials is not revealed
+	data, err := json.Marshal(user.Credentials)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fmt.Sprintf("%x", sha256.Sum256(data)), UserCredentialsHash(user, key))
+	assert.NotEqual(t, UserCredentialsHash(user, key), UserCredentialsHash(user, []byte("other key")))
+}
@@ -15,11 +15,11 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		// Database settings
 		{
 			Name:  "DB_SERVICE_PREFIX_MAPPING",
-			Value: PostgresqlServiceName + "=DB",
+			Value: PostgresqlServiceName(cr) + "=DB",
 		},
 		{
 			Name:  "TX_DATABASE_PREFIX_MAPPING",
-			Value: PostgresqlServiceName + "=DB",
+			Value: PostgresqlServiceName(cr) + "=DB",
 		},
 		{
 			Name:  "DB_JNDI",
@@ -34,7 +34,7 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
-						Name: DatabaseSecretName,
+						Name: DatabaseSecretName(cr),
 					},
 					Key: DatabaseSecretUsernameProperty,
 				},
@@ -45,7 +45,7 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
-						Name: DatabaseSecretName,
+						Name: DatabaseSecretName(cr),
 					},
 					Key: DatabaseSecretPasswordProperty,
 				},
@@ -62,7 +62,7 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		},
 		{
 			Name:  "OPENSHIFT_DNS_PING_SERVICE_NAME",
-			Value: KeycloakDiscoveryServiceName + "." + cr.Namespace + ".svc.cluster.local",
+			Value: KeycloakDiscoveryServiceName(cr) + "." + cr.Namespace + ".svc.cluster.local",
 		},
 		// Cache settings
 		{
@@ -107,15 +107,23 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 
 	if cr.Spec.ExternalDatabase.Enabled {
 		env = append(env, v1.EnvVar{
-			Name:  GetServiceEnvVar("SERVICE_HOST"),
-			Value: PostgresqlServiceName + "." + cr.Namespace + ".svc.cluster.local",
+			Name:  GetServiceEnvVar(cr, "SERVICE_HOST"),
+			Value: PostgresqlServiceName(cr) + "." + cr.Namespace + ".svc.cluster.local",
 		})
 		env = append(env, v1.EnvVar{
-			Name:  GetServiceEnvVar("SERVICE_PORT"),
+			Name:  GetServiceEnvVar(cr, "SERVICE_PORT"),
 			Value: fmt.Sprintf("%v", GetExternalDatabasePort(dbSecret)),
 		})
 	}
 
+	// Redirects and links need to include the path prefix RH-SSO is exposed under
+	if frontendURL := KeycloakFrontendURL(cr); frontendURL != "" {
+		env = append(env, v1.EnvVar{
+			Name:  "JAVA_OPTS_APPEND",
+			Value: "-Dkeycloak.frontendUrl=" + frontendURL,
+		})
+	}
+
 	if len(cr.Spec.KeycloakDeploymentSpec.Experimental.Env) > 0 {
 		// We override Keycloak pre-defined envs with what user specified. Not the other way around.
 		env = MergeEnvs(cr.Spec.KeycloakDeploymentSpec.Experimental.Env, env)
@@ -148,11 +156,11 @@ func RHSSOSslEnvVariables(dbSecret *v1.Secret, env []v1.EnvVar) []v1.EnvVar {
 }
 
 func RHSSODeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1.Secret) *v13.StatefulSet {
-	podLabels := AddPodLabels(cr, GetLabelsSelector())
+	podLabels := AddPodLabels(cr, GetLabelsSelector(cr))
 	podAnnotations := cr.Spec.KeycloakDeploymentSpec.PodAnnotations
 	rhssoStatefulSet := &v13.StatefulSet{
 		ObjectMeta: v12.ObjectMeta{
-			Name:        KeycloakDeploymentName,
+			Name:        KeycloakDeploymentName(cr),
 			Namespace:   cr.Namespace,
 			Labels:      podLabels,
 			Annotations: podAnnotations,
@@ -160,11 +168,11 @@ func RHSSODeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1
 		Spec: v13.StatefulSetSpec{
 			Replicas: SanitizeNumberOfReplicas(cr.Spec.Instances, true),
 			Selector: &v12.LabelSelector{
-				MatchLabels: GetLabelsSelector(),
+				MatchLabels: GetLabelsSelector(cr),
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: v12.ObjectMeta{
-					Name:        KeycloakDeploymentName,
+					Name:        KeycloakDeploymentName(cr),
 					Namespace:   cr.Namespace,
 					Labels:      podLabels,
 					Annotations: podAnnotations,
@@ -175,8 +183,8 @@ func RHSSODeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1
 					Affinity:       KeycloakPodAffinity(cr),
 					Containers: []v1.Container{
 						{
-							Name:  KeycloakDeploymentName,
-							Image: Images.Images[RHSSOImage],
+							Name:  KeycloakContainerName,
+							Image: Profiles.GetKeycloakOrRHSSOImage(cr),
 							Ports: []v1.ContainerPort{
 								{
 									ContainerPort: KeycloakServicePort,
@@ -200,7 +208,7 @@ func RHSSODeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1
 							Env:             getRHSSOEnv(cr, dbSecret),
 							Args:            cr.Spec.KeycloakDeploymentSpec.Experimental.Args,
 							Command:         cr.Spec.KeycloakDeploymentSpec.Experimental.Command,
-							VolumeMounts:    KeycloakVolumeMounts(cr, RhssoExtensionPath, dbSSLSecret, RhssoCertificatePath),
+							VolumeMounts:    KeycloakVolumeMounts(cr, RhssoExtensionPath, RhssoThemesPath, dbSSLSecret, RhssoCertificatePath),
 							Resources:       getResources(cr),
 							ImagePullPolicy: cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
 						},
@@ -217,12 +225,16 @@ func RHSSODeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1
 		rhssoStatefulSet.Spec.Template.Spec.Affinity = KeycloakPodAffinity(cr)
 	}
 
+	mergeKeycloakContainers(cr, &rhssoStatefulSet.Spec.Template, nil)
+	addKeycloakExtensionsHash(cr, &rhssoStatefulSet.Spec.Template)
+	applyDeploymentSpec(&rhssoStatefulSet.Spec.Template.Spec, cr.Spec.KeycloakDeploymentSpec.DeploymentSpec)
+
 	return rhssoStatefulSet
 }
 
 func RHSSODeploymentSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      KeycloakDeploymentName,
+		Name:      KeycloakDeploymentName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -234,18 +246,18 @@ func RHSSODeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Stateful
 	reconciled.ObjectMeta.Annotations = AddPodAnnotations(cr, reconciled.ObjectMeta.Annotations)
 	reconciled.Spec.Template.ObjectMeta.Labels = AddPodLabels(cr, reconciled.Spec.Template.ObjectMeta.Labels)
 	reconciled.Spec.Template.ObjectMeta.Annotations = AddPodAnnotations(cr, reconciled.Spec.Template.ObjectMeta.Annotations)
-	reconciled.Spec.Selector.MatchLabels = GetLabelsSelector()
+	reconciled.Spec.Selector.MatchLabels = GetLabelsSelector(cr)
 	reconciled.Spec.Template.Spec.ServiceAccountName = cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName
 
 	reconciled.ResourceVersion = currentState.ResourceVersion
-	if !cr.Spec.DisableReplicasSyncing {
+	if KeycloakReplicasSynced(cr) {
 		reconciled.Spec.Replicas = SanitizeNumberOfReplicas(cr.Spec.Instances, false)
 	}
 	reconciled.Spec.Template.Spec.Volumes = KeycloakVolumes(cr, dbSSLSecret)
 	reconciled.Spec.Template.Spec.Containers = []v1.Container{
 		{
-			Name:    KeycloakDeploymentName,
-			Image:   Images.Images[RHSSOImage],
+			Name:    KeycloakContainerName,
+			Image:   Profiles.GetKeycloakOrRHSSOImage(cr),
 			Args:    cr.Spec.KeycloakDeploymentSpec.Experimental.Args,
 			Command: cr.Spec.KeycloakDeploymentSpec.Experimental.Command,
 			Ports: []v1.ContainerPort{
@@ -266,7 +278,7 @@ func RHSSODeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Stateful
 					Protocol:      "TCP",
 				},
 			},
-			VolumeMounts:    KeycloakVolumeMounts(cr, RhssoExtensionPath, dbSSLSecret, RhssoCertificatePath),
+			VolumeMounts:    KeycloakVolumeMounts(cr, RhssoExtensionPath, RhssoThemesPath, dbSSLSecret, RhssoCertificatePath),
 			LivenessProbe:   livenessProbe(),
 			ReadinessProbe:  readinessProbe(),
 			Env:             getRHSSOEnv(cr, dbSecret),
@@ -279,5 +291,9 @@ func RHSSODeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Stateful
 		reconciled.Spec.Template.Spec.Affinity = cr.Spec.KeycloakDeploymentSpec.Experimental.Affinity
 	}
 
+	mergeKeycloakContainers(cr, &reconciled.Spec.Template, &currentState.Spec.Template)
+	addKeycloakExtensionsHash(cr, &reconciled.Spec.Template)
+	applyDeploymentSpec(&reconciled.Spec.Template.Spec, cr.Spec.KeycloakDeploymentSpec.DeploymentSpec)
+
 	return reconciled
 }
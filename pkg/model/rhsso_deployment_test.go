@@ -28,6 +28,14 @@ func TestRHSSODeployment_testAffinityExperimental(t *testing.T) {
 	testAffinityExperimentalAffinitySet(t, RHSSODeployment)
 }
 
+func TestRHSSODeployment_testDeploymentSpecPodSettings(t *testing.T) {
+	testDeploymentSpecPodSettings(t, RHSSODeployment, RHSSODeploymentReconciled)
+}
+
+func TestRHSSODeployment_testSidecarsMergedByName(t *testing.T) {
+	testSidecarsMergedByName(t, RHSSODeployment, RHSSODeploymentReconciled)
+}
+
 func TestRHSSODeployment_testDeploymentSpecImagePolicy(t *testing.T) {
 	testDeploymentSpecImagePolicy(t, RHSSODeployment)
 }
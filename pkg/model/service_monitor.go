@@ -10,7 +10,7 @@ import (
 func ServiceMonitor(cr *v1alpha1.Keycloak) *monitoringv1.ServiceMonitor {
 	return &monitoringv1.ServiceMonitor{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      ServiceMonitorName,
+			Name:      ServiceMonitorName(cr),
 			Namespace: cr.Namespace,
 			Labels: map[string]string{
 				"monitoring-key": MonitoringKey,
@@ -28,7 +28,7 @@ func ServiceMonitor(cr *v1alpha1.Keycloak) *monitoringv1.ServiceMonitor {
 				},
 				{
 					Path:   "/metrics",
-					Port:   KeycloakMonitoringServiceName,
+					Port:   KeycloakMonitoringPortName,
 					Scheme: "http",
 					TLSConfig: &monitoringv1.TLSConfig{
 						InsecureSkipVerify: true,
@@ -36,9 +36,9 @@ func ServiceMonitor(cr *v1alpha1.Keycloak) *monitoringv1.ServiceMonitor {
 				},
 			},
 			Selector: metav1.LabelSelector{
-				MatchLabels: map[string]string{
+				MatchLabels: instanceLabels(cr, map[string]string{
 					"app": ApplicationName,
-				},
+				}),
 			},
 		},
 	}
@@ -46,7 +46,7 @@ func ServiceMonitor(cr *v1alpha1.Keycloak) *monitoringv1.ServiceMonitor {
 
 func ServiceMonitorSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      ServiceMonitorName,
+		Name:      ServiceMonitorName(cr),
 		Namespace: cr.Namespace,
 	}
 }
@@ -5,13 +5,18 @@ import (
 
 	"github.com/jaconi-io/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestUtil_Test_GetServiceEnvVar(t *testing.T) {
-	assert.Equal(t, GetServiceEnvVar("SERVICE_HOST"), "KEYCLOAK_POSTGRESQL_SERVICE_HOST")
-	assert.Equal(t, GetServiceEnvVar("SERVICE_PORT"), "KEYCLOAK_POSTGRESQL_SERVICE_PORT")
+	cr := &v1alpha1.Keycloak{Status: v1alpha1.KeycloakStatus{LegacyNames: true}}
+	assert.Equal(t, GetServiceEnvVar(cr, "SERVICE_HOST"), "KEYCLOAK_POSTGRESQL_SERVICE_HOST")
+	assert.Equal(t, GetServiceEnvVar(cr, "SERVICE_PORT"), "KEYCLOAK_POSTGRESQL_SERVICE_PORT")
+
+	cr = &v1alpha1.Keycloak{ObjectMeta: v12.ObjectMeta{Name: "sso-dev"}}
+	assert.Equal(t, GetServiceEnvVar(cr, "SERVICE_HOST"), "SSO_DEV_POSTGRESQL_SERVICE_HOST")
 }
 
 func TestUtil_SanitizeResourceName(t *testing.T) {
@@ -0,0 +1,299 @@
+package tracing
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	exportBatchSize = 512
+	exportInterval  = 5 * time.Second
+	exportQueueSize = 4096
+)
+
+// exporter sends finished spans in batches to an OTLP/HTTP endpoint
+type exporter struct {
+	endpoint    string
+	headers     map[string]string
+	sampler     Sampler
+	serviceName string
+	requester   *http.Client
+	queue       chan *Span
+	done        chan struct{}
+	stopOnce    sync.Once
+	stopped     sync.WaitGroup
+}
+
+func newExporter(options Options, serviceName string, requester *http.Client) *exporter {
+	sampler := defaultSampler
+	if options.Sampler != nil {
+		sampler = *options.Sampler
+	}
+
+	return &exporter{
+		endpoint:    options.Endpoint,
+		headers:     options.Headers,
+		sampler:     sampler,
+		serviceName: serviceName,
+		requester:   requester,
+		queue:       make(chan *Span, exportQueueSize),
+		done:        make(chan struct{}),
+	}
+}
+
+// Returns a client verifying the collector with the configured CA certificates and authenticating with the
+// configured client certificate
+func newRequester(options Options) (*http.Client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if options.CertificateFile != "" {
+		certificates, err := os.ReadFile(options.CertificateFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading the collector CA certificate")
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(certificates) {
+			return nil, errors.Errorf("no PEM encoded certificate found in %s", options.CertificateFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	if options.ClientCertificateFile != "" || options.ClientKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.ClientCertificateFile, options.ClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading the client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}, nil
+}
+
+func (e *exporter) start() {
+	e.stopped.Add(1)
+	go e.run()
+}
+
+// Queue a span for export. Spans are dropped instead of blocking the reconcile when the queue is full.
+func (e *exporter) export(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+		logrus.Warnf("tracing queue full, dropping span %s", span.name)
+	}
+}
+
+func (e *exporter) shutdown() {
+	e.stopOnce.Do(func() {
+		close(e.done)
+		e.stopped.Wait()
+	})
+}
+
+func (e *exporter) run() {
+	defer e.stopped.Done()
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= exportBatchSize {
+				e.send(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			e.send(batch)
+			batch = nil
+		case <-e.done:
+			// Drain the remaining spans before stopping
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+				default:
+					e.send(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *exporter) send(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(e.encode(batch))
+	if err != nil {
+		logrus.Errorf("error encoding spans %+v", err)
+		return
+	}
+
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		logrus.Errorf("error creating span export request %+v", err)
+		return
+	}
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := e.requester.Do(req)
+	if err != nil {
+		logrus.Errorf("error exporting spans %+v", err)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		logrus.Errorf("failed to export spans: (%d) %s", res.StatusCode, res.Status)
+	}
+}
+
+// OTLP JSON representation, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+func (e *exporter) encode(batch []*Span) otlpTraces {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		spans = append(spans, encodeSpan(span))
+	}
+
+	return otlpTraces{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: []otlpAttribute{encodeAttribute("service.name", e.serviceName)},
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: DefaultServiceName},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+func encodeSpan(span *Span) otlpSpan {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+
+	encoded := otlpSpan{
+		TraceID:           hex.EncodeToString(span.traceID[:]),
+		SpanID:            hex.EncodeToString(span.spanID[:]),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+	}
+
+	if span.parentSpanID != [8]byte{} {
+		encoded.ParentSpanID = hex.EncodeToString(span.parentSpanID[:])
+	}
+
+	for key, value := range span.attributes {
+		encoded.Attributes = append(encoded.Attributes, encodeAttribute(key, value))
+	}
+	sort.Slice(encoded.Attributes, func(i, j int) bool {
+		return encoded.Attributes[i].Key < encoded.Attributes[j].Key
+	})
+
+	if span.err != nil {
+		encoded.Status = &otlpStatus{Code: statusCodeError, Message: span.err.Error()}
+	}
+	return encoded
+}
+
+func encodeAttribute(key string, value interface{}) otlpAttribute {
+	attribute := otlpAttribute{Key: key}
+	switch v := value.(type) {
+	case bool:
+		attribute.Value.BoolValue = &v
+	case int:
+		s := strconv.Itoa(v)
+		attribute.Value.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		attribute.Value.IntValue = &s
+	case string:
+		attribute.Value.StringValue = &v
+	default:
+		s := ""
+		if value != nil {
+			b, _ := json.Marshal(value)
+			s = string(b)
+		}
+		attribute.Value.StringValue = &s
+	}
+	return attribute
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"encoding/binary"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Sampler decides which traces are exported, it supports the samplers of the OpenTelemetry specification
+type Sampler struct {
+	// Ratio of the traces that are sampled, 1 samples all and 0 none
+	Ratio float64
+	// Spans follow the decision of their parent, the ratio only applies to new traces
+	ParentBased bool
+}
+
+// The default sampler of the OpenTelemetry specification, parentbased_always_on
+var defaultSampler = Sampler{Ratio: 1, ParentBased: true}
+
+// ParseSampler returns the sampler of an OTEL_TRACES_SAMPLER value and its argument, the default sampler if empty
+func ParseSampler(name, arg string) (Sampler, error) {
+	ratio := 1.0
+	if arg != "" {
+		parsed, err := strconv.ParseFloat(arg, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return Sampler{}, errors.Errorf("sampler argument %q is not a ratio between 0 and 1", arg)
+		}
+		ratio = parsed
+	}
+
+	switch name {
+	case "":
+		return defaultSampler, nil
+	case "always_on":
+		return Sampler{Ratio: 1}, nil
+	case "always_off":
+		return Sampler{Ratio: 0}, nil
+	case "traceidratio":
+		return Sampler{Ratio: ratio}, nil
+	case "parentbased_always_on":
+		return Sampler{Ratio: 1, ParentBased: true}, nil
+	case "parentbased_always_off":
+		return Sampler{Ratio: 0, ParentBased: true}, nil
+	case "parentbased_traceidratio":
+		return Sampler{Ratio: ratio, ParentBased: true}, nil
+	default:
+		return Sampler{}, errors.Errorf("sampler %q is not supported", name)
+	}
+}
+
+// Samples a ratio of the trace IDs the same way as the TraceIdRatioBased sampler of the OpenTelemetry SDKs, so the
+// decision is consistent for all spans of a trace
+func (s Sampler) sample(traceID [16]byte, parent *Span) bool {
+	if s.ParentBased && parent != nil {
+		return parent.sampled
+	}
+	if s.Ratio >= 1 {
+		return true
+	}
+	if s.Ratio <= 0 {
+		return false
+	}
+	return binary.BigEndian.Uint64(traceID[8:16])>>1 < uint64(s.Ratio*(1<<63))
+}
@@ -0,0 +1,279 @@
+// Package tracing records spans of reconcile loops and Keycloak admin API calls and exports them to an OpenTelemetry
+// collector using the OTLP/HTTP JSON encoding. The OpenTelemetry Go SDK depends on logr v1 (logr/funcr), while
+// klog and controller-runtime used here require the logr v0.1.0 interface, so only the small subset needed by the
+// operator is implemented here. It is configured through the standard OpenTelemetry environment variables.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// W3C trace context header
+	TraceParentHeader = "traceparent"
+
+	DefaultServiceName = "keycloak-operator"
+
+	// Environment variables defined by the OpenTelemetry specification, the traces variants take precedence
+	EndpointEnvVar                = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	TracesEndpointEnvVar          = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	HeadersEnvVar                 = "OTEL_EXPORTER_OTLP_HEADERS"
+	TracesHeadersEnvVar           = "OTEL_EXPORTER_OTLP_TRACES_HEADERS"
+	CertificateEnvVar             = "OTEL_EXPORTER_OTLP_CERTIFICATE"
+	TracesCertificateEnvVar       = "OTEL_EXPORTER_OTLP_TRACES_CERTIFICATE"
+	ClientCertificateEnvVar       = "OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE"
+	TracesClientCertificateEnvVar = "OTEL_EXPORTER_OTLP_TRACES_CLIENT_CERTIFICATE"
+	ClientKeyEnvVar               = "OTEL_EXPORTER_OTLP_CLIENT_KEY"
+	TracesClientKeyEnvVar         = "OTEL_EXPORTER_OTLP_TRACES_CLIENT_KEY"
+	SamplerEnvVar                 = "OTEL_TRACES_SAMPLER"
+	SamplerArgEnvVar              = "OTEL_TRACES_SAMPLER_ARG"
+	ServiceNameEnvVar             = "OTEL_SERVICE_NAME"
+)
+
+// Span kinds and status codes as defined by OTLP
+const (
+	SpanKindInternal = 1
+	SpanKindClient   = 3
+
+	statusCodeError = 2
+)
+
+type spanContextKey struct{}
+
+// Span is a single timed operation of a trace. All methods can be called on a nil span, which is returned when
+// tracing is disabled.
+type Span struct {
+	mutex        sync.Mutex
+	traceID      [16]byte
+	spanID       [8]byte
+	parentSpanID [8]byte
+	name         string
+	kind         int
+	start        time.Time
+	end          time.Time
+	attributes   map[string]interface{}
+	err          error
+	sampled      bool
+	ended        bool
+}
+
+// Options configure the export of spans
+type Options struct {
+	// OTLP/HTTP traces endpoint, tracing is disabled if empty
+	Endpoint string
+	// Headers sent with every export request, e.g. to authenticate with the collector
+	Headers map[string]string
+	// PEM encoded CA certificates verifying the collector, the system roots are used if empty
+	CertificateFile string
+	// PEM encoded client certificate and key for mutual TLS
+	ClientCertificateFile string
+	ClientKeyFile         string
+	// Decides which traces are exported, all traces are sampled if nil
+	Sampler *Sampler
+}
+
+// The exporter in use, nil if tracing is disabled
+var activeExporter *exporter
+
+// EndpointFromEnv returns the OTLP traces endpoint configured with the standard OpenTelemetry environment variables
+func EndpointFromEnv() string {
+	if endpoint := os.Getenv(TracesEndpointEnvVar); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := os.Getenv(EndpointEnvVar); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	return ""
+}
+
+// OptionsFromEnv returns the export options configured with the standard OpenTelemetry environment variables
+func OptionsFromEnv() (Options, error) {
+	headers, err := parseHeaders(os.Getenv(HeadersEnvVar))
+	if err != nil {
+		return Options{}, errors.Wrapf(err, "invalid %s", HeadersEnvVar)
+	}
+	tracesHeaders, err := parseHeaders(os.Getenv(TracesHeadersEnvVar))
+	if err != nil {
+		return Options{}, errors.Wrapf(err, "invalid %s", TracesHeadersEnvVar)
+	}
+	for key, value := range tracesHeaders {
+		headers[key] = value
+	}
+
+	sampler, err := ParseSampler(os.Getenv(SamplerEnvVar), os.Getenv(SamplerArgEnvVar))
+	if err != nil {
+		return Options{}, errors.Wrapf(err, "invalid %s", SamplerEnvVar)
+	}
+
+	return Options{
+		Endpoint:              EndpointFromEnv(),
+		Headers:               headers,
+		CertificateFile:       envWithTracesOverride(TracesCertificateEnvVar, CertificateEnvVar),
+		ClientCertificateFile: envWithTracesOverride(TracesClientCertificateEnvVar, ClientCertificateEnvVar),
+		ClientKeyFile:         envWithTracesOverride(TracesClientKeyEnvVar, ClientKeyEnvVar),
+		Sampler:               &sampler,
+	}, nil
+}
+
+func envWithTracesOverride(tracesEnvVar, envVar string) string {
+	if value := os.Getenv(tracesEnvVar); value != "" {
+		return value
+	}
+	return os.Getenv(envVar)
+}
+
+// Headers are a comma separated list of key=value pairs with URL encoded values
+func parseHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, encoded, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, errors.Errorf("header %q is not a key=value pair", strings.TrimSpace(key))
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, errors.Wrapf(err, "header %q", strings.TrimSpace(key))
+		}
+		headers[strings.TrimSpace(key)] = decoded
+	}
+	return headers, nil
+}
+
+// Setup enables the export of spans to the configured OTLP/HTTP traces endpoint. The returned function flushes all
+// pending spans and stops the export.
+func Setup(options Options) (func(), error) {
+	if options.Endpoint == "" {
+		return func() {}, nil
+	}
+
+	serviceName := os.Getenv(ServiceNameEnvVar)
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+
+	requester, err := newRequester(options)
+	if err != nil {
+		return nil, err
+	}
+
+	activeExporter = newExporter(options, serviceName, requester)
+	activeExporter.start()
+	return activeExporter.shutdown, nil
+}
+
+// Enabled returns true if spans are exported
+func Enabled() bool {
+	return activeExporter != nil
+}
+
+// StartSpan starts a new span as a child of the span in the given context or as a new root span
+func StartSpan(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	span := &Span{
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: map[string]interface{}{},
+	}
+
+	parent := SpanFromContext(ctx)
+	if parent != nil {
+		span.traceID = parent.traceID
+		span.parentSpanID = parent.spanID
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+	}
+	_, _ = rand.Read(span.spanID[:])
+
+	// Spans of traces that are not sampled still propagate the trace context, but are not exported
+	span.sampled = activeExporter.sampler.sample(span.traceID, parent)
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// SpanFromContext returns the current span of the context or nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SetAttribute adds an attribute to the span, supported values are strings, booleans and integers
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.attributes[key] = value
+}
+
+// RecordError marks the span as failed
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+// End completes the span and queues it for export
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mutex.Unlock()
+
+	if activeExporter != nil && s.sampled {
+		activeExporter.export(s)
+	}
+}
+
+// TraceParent returns the W3C traceparent header value of the span, flagged as sampled if the span is exported
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(s.traceID[:]), hex.EncodeToString(s.spanID[:]), flags)
+}
+
+// Inject propagates the trace context of the current span to an outgoing request
+func Inject(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set(TraceParentHeader, span.TraceParent())
+	}
+}
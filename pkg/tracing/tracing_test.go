@@ -0,0 +1,243 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracing_DisabledSpansAreNoop(t *testing.T) {
+	// given
+	activeExporter = nil
+
+	// when
+	ctx, span := StartSpan(context.Background(), "dummy", SpanKindInternal)
+	span.SetAttribute("dummy", "dummy")
+	span.RecordError(errors.New("dummy"))
+	span.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+
+	// then
+	assert.Nil(t, span)
+	assert.Equal(t, "", header.Get(TraceParentHeader))
+}
+
+func TestTracing_ExportsSpansWithParent(t *testing.T) {
+	// given
+	var mutex sync.Mutex
+	var received otlpTraces
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/v1/traces", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.NoError(t, json.Unmarshal(body, &received))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	shutdown, err := Setup(Options{Endpoint: server.URL + "/v1/traces"})
+	assert.NoError(t, err)
+	defer func() { activeExporter = nil }()
+
+	// when
+	ctx, root := StartSpan(context.Background(), "KeycloakClient.Reconcile", SpanKindInternal)
+	childCtx, child := StartSpan(ctx, "GET realms/{realm}", SpanKindClient)
+	child.SetAttribute("http.status_code", 404)
+	child.RecordError(errors.New("404 Not Found"))
+
+	header := http.Header{}
+	Inject(childCtx, header)
+
+	child.End()
+	root.End()
+	shutdown()
+
+	// then
+	assert.True(t, strings.HasPrefix(header.Get(TraceParentHeader), "00-"))
+	assert.True(t, strings.HasSuffix(header.Get(TraceParentHeader), "-01"))
+	assert.Equal(t, child.TraceParent(), header.Get(TraceParentHeader))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Len(t, received.ResourceSpans, 1)
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 2)
+
+	exportedChild, exportedRoot := spans[0], spans[1]
+	assert.Equal(t, "GET realms/{realm}", exportedChild.Name)
+	assert.Equal(t, exportedRoot.TraceID, exportedChild.TraceID)
+	assert.Equal(t, exportedRoot.SpanID, exportedChild.ParentSpanID)
+	assert.Equal(t, "", exportedRoot.ParentSpanID)
+	assert.Equal(t, statusCodeError, exportedChild.Status.Code)
+	assert.Equal(t, "http.status_code", exportedChild.Attributes[0].Key)
+	assert.Equal(t, "404", *exportedChild.Attributes[0].Value.IntValue)
+}
+
+func TestTracing_EndpointFromEnv(t *testing.T) {
+	t.Setenv(TracesEndpointEnvVar, "")
+	t.Setenv(EndpointEnvVar, "http://collector:4318/")
+	assert.Equal(t, "http://collector:4318/v1/traces", EndpointFromEnv())
+
+	t.Setenv(TracesEndpointEnvVar, "http://collector:4318/custom")
+	assert.Equal(t, "http://collector:4318/custom", EndpointFromEnv())
+}
+
+func TestTracing_EncodesOTLPJSON(t *testing.T) {
+	// given
+	span := &Span{
+		name:       "GET realms/{realm}",
+		kind:       SpanKindClient,
+		start:      time.Unix(1544712660, 0),
+		end:        time.Unix(1544712661, 0),
+		attributes: map[string]interface{}{"keycloak.cached": false, "http.status_code": 404, "http.method": "GET"},
+		err:        errors.New("404 Not Found"),
+	}
+	decodeHex(t, span.traceID[:], "5b8efff798038103d269b633813fc60c")
+	decodeHex(t, span.spanID[:], "eee19b7ec3c1b174")
+	decodeHex(t, span.parentSpanID[:], "eee19b7ec3c1b173")
+	exporter := newExporter(Options{}, DefaultServiceName, nil)
+
+	// when
+	encoded, err := json.Marshal(exporter.encode([]*Span{span}))
+
+	// then
+	assert.NoError(t, err)
+	expected, err := os.ReadFile("testdata/traces.json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(encoded))
+}
+
+func TestTracing_ExportsWithHeadersOverTLS(t *testing.T) {
+	// given
+	var mutex sync.Mutex
+	var authorization string
+	var received otlpTraces
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		authorization = req.Header.Get("Authorization")
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	certificateFile := filepath.Join(t.TempDir(), "ca.crt")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(certificateFile, certificate, 0600))
+
+	t.Setenv(TracesEndpointEnvVar, server.URL+"/v1/traces")
+	t.Setenv(HeadersEnvVar, "Authorization=Bearer%20general,X-Tenant=keycloak")
+	t.Setenv(TracesHeadersEnvVar, "Authorization=Bearer%20traces")
+	t.Setenv(CertificateEnvVar, certificateFile)
+	options, err := OptionsFromEnv()
+	assert.NoError(t, err)
+
+	shutdown, err := Setup(options)
+	assert.NoError(t, err)
+	defer func() { activeExporter = nil }()
+
+	// when
+	_, span := StartSpan(context.Background(), "KeycloakRealm.Reconcile", SpanKindInternal)
+	span.End()
+	shutdown()
+
+	// then
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, map[string]string{"Authorization": "Bearer traces", "X-Tenant": "keycloak"}, options.Headers)
+	assert.Equal(t, "Bearer traces", authorization)
+	assert.Len(t, received.ResourceSpans, 1)
+}
+
+func TestTracing_UntrustedCollectorCertificateIsRejected(t *testing.T) {
+	t.Setenv(TracesCertificateEnvVar, filepath.Join(t.TempDir(), "missing.crt"))
+	options, err := OptionsFromEnv()
+	assert.NoError(t, err)
+
+	options.Endpoint = "https://collector:4318/v1/traces"
+	_, err = Setup(options)
+	assert.Error(t, err)
+}
+
+func TestTracing_InvalidHeadersAreRejected(t *testing.T) {
+	t.Setenv(HeadersEnvVar, "Authorization")
+	_, err := OptionsFromEnv()
+	assert.Error(t, err)
+}
+
+func TestTracing_UnsampledSpansPropagateButAreNotExported(t *testing.T) {
+	// given
+	sampler, err := ParseSampler("always_off", "")
+	assert.NoError(t, err)
+	activeExporter = newExporter(Options{Endpoint: "http://collector:4318/v1/traces", Sampler: &sampler}, DefaultServiceName, nil)
+	defer func() { activeExporter = nil }()
+
+	// when
+	ctx, span := StartSpan(context.Background(), "KeycloakUser.Reconcile", SpanKindInternal)
+	header := http.Header{}
+	Inject(ctx, header)
+	span.End()
+
+	// then
+	assert.NotNil(t, span)
+	assert.True(t, strings.HasSuffix(header.Get(TraceParentHeader), "-00"))
+	assert.Len(t, activeExporter.queue, 0)
+}
+
+func TestTracing_Samplers(t *testing.T) {
+	var low, high [16]byte
+	decodeHex(t, low[:], "00000000000000000000000000000001")
+	decodeHex(t, high[:], "0000000000000000ffffffffffffffff")
+	sampled := &Span{sampled: true}
+	unsampled := &Span{}
+
+	sampler, err := ParseSampler("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultSampler, sampler)
+	assert.True(t, sampler.sample(high, nil))
+	assert.False(t, sampler.sample(high, unsampled))
+
+	sampler, err = ParseSampler("traceidratio", "0.5")
+	assert.NoError(t, err)
+	assert.True(t, sampler.sample(low, nil))
+	assert.False(t, sampler.sample(high, nil))
+	assert.False(t, sampler.sample(high, sampled))
+
+	sampler, err = ParseSampler("parentbased_traceidratio", "0.5")
+	assert.NoError(t, err)
+	assert.True(t, sampler.sample(high, sampled))
+	assert.False(t, sampler.sample(low, unsampled))
+
+	_, err = ParseSampler("traceidratio", "2")
+	assert.Error(t, err)
+	_, err = ParseSampler("jaeger_remote", "")
+	assert.Error(t, err)
+}
+
+func decodeHex(t *testing.T, destination []byte, value string) {
+	decoded, err := hex.DecodeString(value)
+	assert.NoError(t, err)
+	copy(destination, decoded)
+}
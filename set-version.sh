@@ -16,3 +16,4 @@ replace_value_in_file ".*Version = " "$1" "version/version.go" "\""
 replace_value_in_file ".*DefaultKeycloakImage.*= " "quay.io\/keycloak\/keycloak:$1-legacy" "pkg/model/image_manager.go" "\""
 replace_value_in_file ".*image: " "quay.io\/keycloak\/keycloak-operator:$1-legacy" "deploy/operator.yaml"
 replace_value_in_file ".*DefaultKeycloakInitContainer.*= " "quay.io\/keycloak\/keycloak-init-container:$1-legacy" "pkg/model/image_manager.go" "\""
+replace_value_in_file ".*DefaultRealmExporter.*= " "ghcr.io\/jaconi-io\/keycloak-realm-exporter:$1" "pkg/model/image_manager.go" "\""
@@ -23,7 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const podName = "keycloak-0"
+const podName = testKeycloakCRName + "-0"
 const extraLabelName = "extra"
 const extraLabelValue = "value"
 
@@ -93,7 +93,7 @@ func NewKeycloakStatefulSetSelectorTestStruct() *CRDTestStruct {
 func keycloakSSLDBTest(t *testing.T, f *framework.Framework, ctx *framework.Context, namespace string) error {
 	// get the Keycloak Statefulset
 	keycloakStatefulset := v1apps.StatefulSet{}
-	err := GetNamespacedObject(f, namespace, model.KeycloakDeploymentName, &keycloakStatefulset)
+	err := GetNamespacedObject(f, namespace, model.KeycloakDeploymentName(getKeycloakCR(namespace)), &keycloakStatefulset)
 	if err != nil {
 		return err
 	}
@@ -113,7 +113,7 @@ func keycloakSSLDBTest(t *testing.T, f *framework.Framework, ctx *framework.Cont
 	// check the volume to the crt exists too
 	volumeExists := false
 	for _, vol := range keycloakStatefulset.Spec.Template.Spec.Volumes {
-		if vol.Name == model.DatabaseSecretSslCert+"-vol" {
+		if vol.Name == model.DatabaseSecretSslCert(getKeycloakCR(namespace))+"-vol" {
 			volumeExists = true
 			break
 		}
@@ -181,7 +181,7 @@ func deployPostgreSQLWithSSLon(secretWithSSLCertForPostgres *v1.Secret, cr *keyc
 	modeCrt := int32(0444)
 	modeKey := int32(0440)
 	volume := v1.Volume{
-		Name: model.DatabaseSecretSslCert + "-vol",
+		Name: model.DatabaseSecretSslCert(cr) + "-vol",
 		VolumeSource: v1.VolumeSource{
 			Projected: &v1.ProjectedVolumeSource{
 				Sources: []v1.VolumeProjection{
@@ -211,7 +211,7 @@ func deployPostgreSQLWithSSLon(secretWithSSLCertForPostgres *v1.Secret, cr *keyc
 		},
 	}
 	volumeMount := v1.VolumeMount{
-		Name:      model.DatabaseSecretSslCert + "-vol",
+		Name:      model.DatabaseSecretSslCert(cr) + "-vol",
 		MountPath: "/opt/app-root/src/certificates/",
 	}
 	volumeMountConfig := v1.VolumeMount{
@@ -238,7 +238,7 @@ func deployPostgreSQLWithSSLon(secretWithSSLCertForPostgres *v1.Secret, cr *keyc
 		SupplementalGroups: []int64{999, 1000},
 	}
 	for _, vol := range postgresql.Spec.Template.Spec.Volumes {
-		if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == "keycloak-postgresql-claim" {
+		if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == model.PostgresqlPersistentVolumeName(cr) {
 			vol.PersistentVolumeClaim.ClaimName = externalPostgresClaim
 		}
 	}
@@ -262,7 +262,7 @@ func getSecretWithSSLCertForPostgres(namespace string) *v1.Secret {
 	serverKey, _ := os.ReadFile("testdata/server.key")
 	return &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      model.DatabaseSecretSslCert,
+			Name:      model.DatabaseSecretSslCert(getKeycloakCR(namespace)),
 			Namespace: namespace,
 			Labels:    CreateLabel(namespace),
 		},
@@ -356,7 +356,7 @@ func deployKeycloaksCR(t *testing.T, f *framework.Framework, ctx *framework.Cont
 		return err
 	}
 
-	err = WaitForStatefulSetReplicasReady(t, f.KubeClient, model.ApplicationName, namespace)
+	err = WaitForStatefulSetReplicasReady(t, f.KubeClient, model.KeycloakDeploymentName(keycloakCR), namespace)
 	if err != nil {
 		return err
 	}
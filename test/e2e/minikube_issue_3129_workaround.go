@@ -18,7 +18,7 @@ func doWorkaroundIfNecessary(f *framework.Framework, ctx *framework.Context, nam
 
 		postqresqlPVC := &v1.PersistentVolumeClaim{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      "keycloak-postgresql-claim",
+				Name:      testKeycloakCRName + "-postgresql-claim",
 				Labels:    map[string]string{"app": "keycloak"},
 				Namespace: namespace,
 			},